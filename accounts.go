@@ -0,0 +1,161 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownAccount is returned by Accounts when there is
+// no FS registered under the requested name.
+var ErrUnknownAccount = errors.New("unknown account")
+
+// Accounts holds FS instances of several Yandex Disk accounts
+// (e.g. personal and work ones) keyed by account name.
+// Accounts is safe for concurrent use.
+type Accounts struct {
+	mu       sync.RWMutex
+	accounts map[string]FS
+}
+
+// NewAccounts returns an empty set of accounts.
+func NewAccounts() *Accounts {
+	return &Accounts{accounts: make(map[string]FS)}
+}
+
+// Add registers fsys under name replacing FS previously
+// registered under the same name if any.
+func (a *Accounts) Add(name string, fsys FS) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.accounts[name] = fsys
+}
+
+// Login creates FS with New and registers it under name.
+func (a *Accounts) Login(name, token string, client *http.Client, opts ...Option) (FS, error) {
+	fsys, err := New(token, client, opts...)
+	if err != nil {
+		return nil, err
+	}
+	a.Add(name, fsys)
+	return fsys, nil
+}
+
+// Get returns FS registered under name.
+func (a *Accounts) Get(name string) (FS, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	fsys, ok := a.accounts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAccount, name)
+	}
+	return fsys, nil
+}
+
+// Remove unregisters FS registered under name.
+func (a *Accounts) Remove(name string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.accounts, name)
+}
+
+// Names returns sorted names of registered accounts.
+func (a *Accounts) Names() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	names := make([]string, 0, len(a.accounts))
+	for name := range a.accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Copy copies srcPath of account src to dstPath of account dst.
+// See CopyBetween.
+func (a *Accounts) Copy(ctx context.Context, src, srcPath, dst, dstPath string) error {
+	srcFS, err := a.Get(src)
+	if err != nil {
+		return err
+	}
+	dstFS, err := a.Get(dst)
+	if err != nil {
+		return err
+	}
+	return CopyBetween(ctx, srcFS, srcPath, dstFS, dstPath)
+}
+
+// CopyBetween copies file or directory srcPath of srcFS to dstPath
+// of dstFS, which usually belong to different accounts. Contents of
+// files are streamed from download to upload without being held
+// in memory. Directories are copied recursively, existing files
+// at destination are overwritten.
+func CopyBetween(ctx context.Context, srcFS FS, srcPath string, dstFS FS, dstPath string) error {
+	info, err := srcFS.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return copyFile(ctx, srcFS, srcPath, dstFS, dstPath, info.Size())
+	}
+	dstInfo, err := dstFS.Stat(dstPath)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	} else if err == nil && !dstInfo.IsDir() {
+		return &fs.PathError{Op: "copy", Path: dstPath, Err: ErrNotDir}
+	} else if err != nil {
+		if err := dstFS.Mkdir(dstPath); err != nil {
+			return err
+		}
+	}
+	entries, err := srcFS.ReadDir(srcPath)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := path.Base(e.Name())
+		if err := CopyBetween(ctx, srcFS, path.Join(srcPath, name), dstFS, path.Join(dstPath, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile streams contents of a single file of known size.
+func copyFile(ctx context.Context, srcFS FS, srcPath string, dstFS FS, dstPath string, size int64) error {
+	var r io.ReadCloser
+	if y, ok := srcFS.(*ydfs); ok {
+		name := y.fullPath(srcPath)
+		if err := y.checkAntivirusByName(ctx, name); err != nil {
+			return &fs.PathError{Op: "read", Path: srcPath, Err: err}
+		}
+		body, err := y.client.getFileStream(ctx, name)
+		if err != nil {
+			return &fs.PathError{Op: "read", Path: srcPath, Err: err}
+		}
+		r = body
+		// an upload of the contents to the same FS must not wait
+		// for the place taken by the download
+		ctx = y.client.transfers.holdingSlot(ctx)
+	} else {
+		f, err := srcFS.Open(srcPath)
+		if err != nil {
+			return err
+		}
+		r = f
+	}
+	defer r.Close()
+	if y, ok := dstFS.(*ydfs); ok {
+		return y.writeStream(ctx, dstPath, r, size)
+	}
+	return dstFS.WriteFileStream(dstPath, r)
+}
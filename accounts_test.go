@@ -0,0 +1,92 @@
+package ydfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAccounts(t *testing.T) {
+	personal, _ := newMockFS(t)
+	work, _ := newMockFS(t)
+	a := NewAccounts()
+	a.Add("work", work)
+	a.Add("personal", personal)
+	if names := a.Names(); !reflect.DeepEqual(names, []string{"personal", "work"}) {
+		t.Errorf("Names() = %v", names)
+	}
+	if fsys, err := a.Get("work"); err != nil || fsys != work {
+		t.Errorf("Get(work) = %v, %v", fsys, err)
+	}
+	a.Remove("work")
+	if _, err := a.Get("work"); !errors.Is(err, ErrUnknownAccount) {
+		t.Errorf("Get of removed account returns %v", err)
+	}
+	if err := a.Copy(context.Background(), "personal", "/a", "work", "/a"); !errors.Is(err, ErrUnknownAccount) {
+		t.Errorf("Copy to removed account returns %v", err)
+	}
+}
+
+func TestCopyBetween(t *testing.T) {
+	src, srcDisk := newMockFS(t)
+	dst, dstDisk := newMockFS(t)
+	big := bytes.Repeat([]byte("0123456789"), 100000)
+	srcDisk.put("/photos/a.jpg", big)
+	srcDisk.put("/photos/2020/b.jpg", []byte("b"))
+	dstDisk.put("/backup/old.txt", []byte("old"))
+
+	a := NewAccounts()
+	a.Add("src", src)
+	a.Add("dst", dst)
+	if err := a.Copy(context.Background(), "src", "/photos", "dst", "/backup/photos"); err != nil {
+		t.Fatal(err)
+	}
+	for p, want := range map[string][]byte{
+		"/backup/photos/a.jpg":      big,
+		"/backup/photos/2020/b.jpg": []byte("b"),
+		"/backup/old.txt":           []byte("old"),
+	} {
+		e, ok := dstDisk.get(p)
+		if !ok {
+			t.Errorf("%s is not copied", p)
+			continue
+		}
+		if !bytes.Equal(e.data, want) {
+			t.Errorf("%s has %d bytes, want %d", p, len(e.data), len(want))
+		}
+	}
+
+	// single file into a sub FS
+	sub, err := dst.Sub("/backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CopyBetween(context.Background(), src, "/photos/2020/b.jpg", sub, "/b.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := dstDisk.get("/backup/b.jpg"); !ok || string(e.data) != "b" {
+		t.Errorf("file is not copied into sub FS")
+	}
+	if err := CopyBetween(context.Background(), src, "/photos", dst, "/backup/old.txt"); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("copy of directory over file returns %v", err)
+	}
+}
+
+func TestWriteFileStream(t *testing.T) {
+	var records []AuditRecord
+	fsys, md := newMockFS(t, WithAuditFunc(func(r AuditRecord) {
+		records = append(records, r)
+	}))
+	if err := fsys.WriteFileStream("/s.txt", strings.NewReader("streamed")); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := md.get("/s.txt"); !ok || string(e.data) != "streamed" {
+		t.Fatalf("file is not written")
+	}
+	if len(records) != 1 || records[0].Size != 8 {
+		t.Errorf("audit records = %v", records)
+	}
+}
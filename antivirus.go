@@ -0,0 +1,61 @@
+package ydfs
+
+import "context"
+
+// Antivirus statuses of files as reported by the API.
+const (
+	AntivirusClean      = "clean"
+	AntivirusInfected   = "infected"
+	AntivirusNotScanned = "not-scanned"
+)
+
+// WithRefuseInfected makes ReadFile and Open fail with ErrInfected
+// for files which the Disk antivirus has flagged as infected.
+func WithRefuseInfected() Option {
+	return func(o *options) {
+		o.refuseInfected = true
+	}
+}
+
+// WithInfectedWarning makes FS call fn with the path of a file flagged
+// as infected every time such file is opened or read with ReadFile.
+func WithInfectedWarning(fn func(name string)) Option {
+	return func(o *options) {
+		o.warnInfected = fn
+	}
+}
+
+// checksAntivirus reports whether FS has to look at antivirus status
+// of files before reading them.
+func (o *options) checksAntivirus() bool {
+	return o.refuseInfected || o.warnInfected != nil
+}
+
+// checkAntivirus applies configured antivirus policy to the resource
+// about to be read.
+func (y *ydfs) checkAntivirus(res Resource) error {
+	if res.AntivirusStatus != AntivirusInfected {
+		return nil
+	}
+	if y.opts.warnInfected != nil {
+		y.opts.warnInfected(res.Path)
+	}
+	if y.opts.refuseInfected {
+		return ErrInfected
+	}
+	return nil
+}
+
+// checkAntivirusByName fetches metadata of the named file and applies
+// antivirus policy to it. It does nothing if no policy is configured.
+func (y *ydfs) checkAntivirusByName(ctx context.Context, fullname string) error {
+	if !y.opts.checksAntivirus() {
+		return nil
+	}
+	res, err := y.client.getResourceMinTraffic(ctx, fullname)
+	if err != nil {
+		return err
+	}
+	y.client.normalize(&res)
+	return y.checkAntivirus(res)
+}
@@ -0,0 +1,49 @@
+package ydfs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRefuseInfected(t *testing.T) {
+	var warned []string
+	fsys, md := newMockFS(t, WithRefuseInfected(), WithInfectedWarning(func(name string) {
+		warned = append(warned, name)
+	}))
+	md.put("/virus.exe", []byte("evil"))
+	md.put("/clean.txt", []byte("good"))
+	md.update("/virus.exe", func(e *mockEntry) { e.antivirus = AntivirusInfected })
+
+	if _, err := fsys.ReadFile("/virus.exe"); !errors.Is(err, ErrInfected) {
+		t.Errorf("ReadFile of infected file: want ErrInfected, have %v", err)
+	}
+	if _, err := fsys.Open("/virus.exe"); !errors.Is(err, ErrInfected) {
+		t.Errorf("Open of infected file: want ErrInfected, have %v", err)
+	}
+	if _, err := fsys.ReadFile("/clean.txt"); err != nil {
+		t.Errorf("ReadFile of clean file: %v", err)
+	}
+	if len(warned) != 2 || warned[0] != "/virus.exe" {
+		t.Errorf("unexpected warnings: %v", warned)
+	}
+	res, err := fsys.StatExtended("/virus.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.AntivirusStatus != AntivirusInfected {
+		t.Errorf("StatExtended does not surface antivirus status: %q", res.AntivirusStatus)
+	}
+}
+
+func TestWarnInfected(t *testing.T) {
+	var warned int
+	fsys, md := newMockFS(t, WithInfectedWarning(func(string) { warned++ }))
+	md.put("/virus.exe", []byte("evil"))
+	md.update("/virus.exe", func(e *mockEntry) { e.antivirus = AntivirusInfected })
+	if _, err := fsys.ReadFile("/virus.exe"); err != nil {
+		t.Errorf("ReadFile of infected file fails without refuse policy: %v", err)
+	}
+	if warned != 1 {
+		t.Errorf("want 1 warning, have %d", warned)
+	}
+}
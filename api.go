@@ -7,10 +7,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"net/url"
+	"path"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -22,6 +26,7 @@ const (
 	urlResourcesDownload     = urlResources + "/download"      // download resources
 	urlResourcesUpload       = urlResources + "/upload"        // upload resources
 	urlResourcesPublish      = urlResources + "/publish"       // publish resources
+	urlResourcesUnpublish    = urlResources + "/unpublish"     // unpublish resources
 	urlResourcesCopy         = urlResources + "/copy"          // copy resources
 	urlResourcesMove         = urlResources + "/move"          // move resources
 	urlResourcesFiles        = urlResources + "/files"         // list files sorted alphabetically
@@ -46,87 +51,262 @@ var minimalFields = []string{"name", "path", "type", "size", "modified"}
 var (
 	ErrNetwork  = errors.New("network error")
 	ErrAPI      = errors.New("API error")
-	ErrNotFound = errors.New("resource not found")
+	ErrNotFound = newFSError("resource not found", fs.ErrNotExist) // also matches fs.ErrNotExist
 	ErrUnknown  = errors.New("unknown error")
 	ErrInternal = errors.New("internal error")
+	ErrInfected = errors.New("file is infected")
+	ErrNotDir   = errors.New("not a directory")
+	ErrIsDir    = errors.New("is a directory")
+	ErrNotEmpty = errors.New("directory not empty")
+
+	ErrOperationPending = errors.New("operation is still in progress")
 )
 
 type apiclient struct {
-	header http.Header
-	client *http.Client
+	header    http.Header
+	client    *http.Client
+	limiter   *rateLimiter  // throttles transfers if non-nil
+	transfers transferSlots // limits concurrent transfers if non-nil
+	fields    []string      // fields requested for resource metadata
+	policy    []pathRule    // access restrictions of paths
+
+	operations       *operationRegistry // pending async operations
+	operationTimeout time.Duration      // max time to wait for async operation
+	metadataTimeout  time.Duration      // timeout of metadata requests, 0 means none
+	transferTimeout  time.Duration      // timeout of uploads and downloads, 0 means none
+	instantUpload    int64              // min size of uploads offered by hashes, 0 disables
+	scheme           string             // scheme of paths sent to the API, e.g. "app:"
+	appRoot          string             // disk path of app folder trimmed from returned paths
+	requestIDHeader  string             // header to send correlation id in
+
+	init     func(ctx context.Context) error // validates client, called by constructor or before the first request
+	initMu   sync.Mutex                      // held while init runs
+	initDone bool                            // init succeeded
+
+	flights flightGroup // concurrent identical metadata requests
+	cache   *metaCache  // cached metadata, nil if disabled
+	hideDir string      // reserved directory left out of listings, see WithHideReserved
+
+	unicodeForm UnicodeForm // form of paths sent to the API and returned by FS
 }
 
 // newApiClient createst Yandex Disk API client, which uses
-// the provided http.Client.
+// the provided http.Client. Empty token is allowed for the client
+// which only accesses public resources.
 func newApiClient(token string, c *http.Client) *apiclient {
 	h := make(http.Header)
-	h.Add("Authorization", "OAuth "+token)
+	if token != "" {
+		h.Add("Authorization", "OAuth "+token)
+	}
 	h.Add("Accept", "application/json")
 	h.Add("Content-Type", "application/json")
-	return &apiclient{header: h, client: c}
+	return &apiclient{header: h, client: c, fields: minimalFields, operations: &operationRegistry{ops: make(map[string]Operation)}, operationTimeout: defaultOperationTimeout}
 }
 
 // processes request returns response body bytes and error
-// if we're getting status not equal to the requiredcode the method tries to unmarshal
+// if we're getting status not equal to any of the requiredcodes the method tries to unmarshal
 // response to errAPI struct which imlements error interface.
-func (c *apiclient) do(ctx context.Context, r *http.Request, requiredcode int) ([]byte, error) {
-	r.Header = c.header
-	var (
-		resp *http.Response
-		err  error
-		data []byte
-	)
-	if ctx != nil {
-		r = r.WithContext(ctx)
+func (c *apiclient) do(ctx context.Context, r *http.Request, requiredcodes ...int) ([]byte, error) {
+	body, err := c.stream(ctx, r, requiredcodes...)
+	if err != nil {
+		return []byte{}, err
 	}
-	resp, err = c.client.Do(r)
+	defer body.Close()
+	data, err := io.ReadAll(body)
 	if err != nil {
-		return []byte{}, fmt.Errorf("%w: %v", ErrNetwork, err)
+		return []byte{}, fmt.Errorf("%w: %w", ErrNetwork, err)
 	}
-	defer resp.Body.Close()
+	return data, nil
+}
 
-	data, err = io.ReadAll(resp.Body)
+// stream processes request and returns response body for the caller
+// to consume. Caller must close the returned body. Errors are handled
+// the same way as in do method.
+func (c *apiclient) stream(ctx context.Context, r *http.Request, requiredcodes ...int) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, r, requiredcodes...)
 	if err != nil {
-		return []byte{}, fmt.Errorf("%w: %v", ErrNetwork, err)
+		return nil, err
 	}
+	return resp.Body, nil
+}
 
-	// checking if we've got correct result code
-	if resp.StatusCode != requiredcode {
-		var e errAPI
-		if err = json.Unmarshal(data, &e); err != nil {
-			return []byte{}, fmt.Errorf("%w: unknown response with code %d from API: %s", ErrUnknown, resp.StatusCode, string(data))
+// send processes request and returns response if its status code is
+// one of requiredcodes. Otherwise response body is consumed and
+// converted to error. Caller must close body of the returned response.
+func (c *apiclient) send(ctx context.Context, r *http.Request, requiredcodes ...int) (*http.Response, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := c.ensureInit(ctx); err != nil {
+		return nil, err
+	}
+	// headers set by the caller (e.g. Range) are preserved
+	for k, v := range c.header {
+		if _, ok := r.Header[k]; !ok {
+			r.Header[k] = v
 		}
-		if e.NotFound() {
-			err = fmt.Errorf("%w, %v", ErrNotFound, e)
-		} else {
-			err = fmt.Errorf("%w, %v", ErrAPI, e)
+	}
+	if c.requestIDHeader != "" {
+		r.Header.Set(c.requestIDHeader, requestID(ctx))
+	}
+	if ctx != nil {
+		r = r.WithContext(ctx)
+	}
+	resp, err := c.client.Do(r)
+	if err != nil {
+		return nil, c.requestError(r, nil, fmt.Errorf("%w: %w", ErrNetwork, err))
+	}
+	if err := gzipBody(r, resp); err != nil {
+		return nil, c.requestError(r, resp, err)
+	}
+
+	// checking if we've got correct result code
+	for _, code := range requiredcodes {
+		if resp.StatusCode == code {
+			return resp, nil
 		}
-		return []byte{}, err
 	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, c.requestError(r, resp, fmt.Errorf("%w: %w", ErrNetwork, err))
+	}
+	var e errAPI
+	if err = json.Unmarshal(data, &e); err != nil {
+		return nil, c.requestError(r, resp, fmt.Errorf("%w: unknown response with code %d from API: %s", ErrUnknown, resp.StatusCode, string(data)))
+	}
+	if e.NotFound() {
+		err = fmt.Errorf("%w, %v", ErrNotFound, e)
+	} else if e.AlreadyExists() {
+		err = fmt.Errorf("%w, %w, %v", ErrAPI, fs.ErrExist, e)
+	} else if serr := statusError(resp.StatusCode); serr != nil {
+		err = fmt.Errorf("%w, %w, %v", ErrAPI, serr, e)
+	} else {
+		err = fmt.Errorf("%w, %v", ErrAPI, e)
+	}
+	return nil, c.requestError(r, resp, err)
+}
 
+// transfer performs upload or download request. Request and response
+// bodies are throttled if bandwidth limit is set for the client.
+func (c *apiclient) transfer(ctx context.Context, r *http.Request, requiredcode int) ([]byte, error) {
+	body, err := c.transferStream(ctx, r, requiredcode)
+	if err != nil {
+		return []byte{}, err
+	}
+	defer body.Close()
+	data, err := readAll(body, body.(*readCloser).size)
+	if err != nil {
+		return []byte{}, fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
 	return data, nil
 }
 
+// readAll reads r until EOF like io.ReadAll, but allocates buffer for
+// the expected size at once unless size is negative (unknown), so that
+// large bodies are not copied over and over while the buffer grows.
+func readAll(r io.Reader, size int64) ([]byte, error) {
+	if size < 0 {
+		return io.ReadAll(r)
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, size+bytes.MinRead))
+	_, err := buf.ReadFrom(r)
+	return buf.Bytes(), err
+}
+
+// transferStream is like transfer but returns response body for
+// the caller to consume. Caller must close the returned body.
+// The transfer is limited by transfer timeout of the client, which
+// keeps running until the body is closed.
+func (c *apiclient) transferStream(ctx context.Context, r *http.Request, requiredcode int) (io.ReadCloser, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	release, err := c.transfers.acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	ctx, cancel := withTimeout(ctx, c.transferTimeout)
+	if c.limiter != nil && r.Body != nil {
+		// transport closes the original body
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{c.limiter.reader(ctx, r.Body), r.Body}
+	}
+	resp, err := c.send(ctx, r, requiredcode)
+	if err != nil {
+		cancel()
+		release()
+		return nil, err
+	}
+	var rd io.Reader = resp.Body
+	if c.limiter != nil {
+		rd = c.limiter.reader(ctx, resp.Body)
+	}
+	return &readCloser{Reader: rd, size: resp.ContentLength, close: func() error {
+		defer release()
+		defer cancel()
+		return resp.Body.Close()
+	}}, nil
+}
+
+// readCloser combines io.Reader with a function closing it.
+type readCloser struct {
+	io.Reader
+	size  int64 // expected number of bytes, negative if unknown
+	close func() error
+}
+
+func (rc *readCloser) Close() error {
+	return rc.close()
+}
+
+// withTimeout returns a copy of ctx which is cancelled after d.
+// Zero or negative d means no timeout.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
 // requestInterface performs some of the weight lifting with API. If result argument it non-nil
 // then the method tries to unmarshal response into the passed interface.
 // If no body is expected in response or the body needs to be thrown away,
 // result must be nil.
-func (c *apiclient) requestInterface(method string, respcode int, url string, body io.Reader, result interface{}) (err error) {
-	var (
-		r    *http.Request
-		data []byte
-	)
-	r, err = http.NewRequest(method, url, body)
-	if err != nil {
-		return
-	}
+func (c *apiclient) requestInterface(ctx context.Context, method string, respcode int, url string, body io.Reader, result interface{}) (err error) {
+	_, err = c.requestStatus(ctx, method, []int{respcode}, url, body, result)
+	return
+}
 
-	if data, err = c.do(context.TODO(), r, respcode); err != nil {
+// requestStatus is like requestInterface, but accepts any of respcodes
+// as success and returns the actual response code. Empty response body
+// (e.g. with 204 No Content) is not unmarshalled. Concurrent identical
+// GET requests are collapsed into one.
+func (c *apiclient) requestStatus(ctx context.Context, method string, respcodes []int, url string, body io.Reader, result interface{}) (code int, err error) {
+	var data []byte
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	code, data, err = c.fetchShared(ctx, method, respcodes, url, body)
+	if errors.Is(err, ErrNotFound) && body == nil {
+		// the resource may be stored with name in the other form
+		if other, ok := c.otherFormURL(url); ok {
+			if otherCode, otherData, otherErr := c.fetchShared(ctx, method, respcodes, other, body); !errors.Is(otherErr, ErrNotFound) {
+				code, data, err = otherCode, otherData, otherErr
+			}
+		}
+	}
+	if err != nil {
 		return
 	}
 	// If nil result argument is passed, we don't want
 	// the resp body unmarshalled. returning.
-	if result == nil {
+	if result == nil || len(data) == 0 {
 		return
 	}
 	// If non-nil result argument is passed we'll try to
@@ -137,132 +317,729 @@ func (c *apiclient) requestInterface(method string, respcode int, url string, bo
 	return
 }
 
+// fetchShared is fetch sharing concurrent identical GET requests.
+func (c *apiclient) fetchShared(ctx context.Context, method string, respcodes []int, url string, body io.Reader) (int, []byte, error) {
+	fetch := func(ctx context.Context) (int, []byte, error) {
+		return c.fetch(ctx, method, respcodes, url, body)
+	}
+	// requests made by initialization are not shared as requests
+	// waiting for initialization would wait for them
+	if method == http.MethodGet && body == nil && ctx.Value(initKey{}) == nil && ctx.Value(unsharedKey{}) == nil {
+		return c.flights.do(ctx, fmt.Sprint(respcodes, url), fetch)
+	}
+	return fetch(ctx)
+}
+
+// fetch sends metadata request and returns response code and body.
+func (c *apiclient) fetch(ctx context.Context, method string, respcodes []int, url string, body io.Reader) (int, []byte, error) {
+	r, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	acceptGzip(r)
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	ctx, cancel := withTimeout(ctx, c.metadataTimeout)
+	defer cancel()
+	resp, err := c.send(ctx, r, respcodes...)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	return resp.StatusCode, data, nil
+}
+
 // getDiskInfo fetches information about user's Disk.
-func (c *apiclient) getDiskInfo() (info diskInfo, err error) {
-	err = c.requestInterface(http.MethodGet, http.StatusOK, urlBase, nil, &info)
+func (c *apiclient) getDiskInfo(ctx context.Context) (info diskInfo, err error) {
+	err = c.requestInterface(ctx, http.MethodGet, http.StatusOK, urlBase, nil, &info)
 	return
 }
 
-// getFile fetches single file bytes.
-func (c *apiclient) getFile(name string) ([]byte, error) {
-	// first we need to fetch the download url
-
+// getDownloadLink fetches the link to download file contents from.
+func (c *apiclient) getDownloadLink(ctx context.Context, name string) (link, error) {
+	if err := c.checkRead(name); err != nil {
+		return link{}, err
+	}
 	v := make(url.Values)
-	v.Add("path", name)
+	v.Add("path", c.apiPath(name))
 	url, _ := url.Parse(urlResourcesDownload)
 	url.RawQuery = v.Encode()
-	var l = &link{}
-	if err := c.requestInterface(http.MethodGet, http.StatusOK, url.String(), nil, l); err != nil {
-		return []byte{}, err
+	var l link
+	if err := c.requestInterface(ctx, http.MethodGet, http.StatusOK, url.String(), nil, &l); err != nil {
+		return link{}, err
 	}
-	if l.Templated {
-		// TODO: deal with templated links (I haven't seen one yet)
+	if err := l.resolve(map[string]string{"path": v.Get("path")}); err != nil {
+		return link{}, err
 	}
-	// performing the actual download
+	return l, nil
+}
+
+// getFile fetches single file bytes.
+func (c *apiclient) getFile(ctx context.Context, name string) ([]byte, error) {
+	// first we need to fetch the download url
+	l, err := c.getDownloadLink(ctx, name)
+	if err != nil {
+		return []byte{}, err
+	}
+	return c.getFileLink(ctx, l)
+}
+
+// getFileLink fetches file bytes from download link l.
+func (c *apiclient) getFileLink(ctx context.Context, l link) ([]byte, error) {
 	r, err := http.NewRequest(l.Method, l.Href, nil)
 	if err != nil {
 		return []byte{}, fmt.Errorf("%w: %v", ErrInternal, err)
 	}
-	return c.do(context.TODO(), r, http.StatusOK)
+	return c.transfer(ctx, r, http.StatusOK)
 }
 
-func (c *apiclient) putFile(name string, overwrite bool, data []byte) error {
+// getFileStream fetches the download link of the named file and
+// returns its contents as a stream. Caller must close the returned body.
+func (c *apiclient) getFileStream(ctx context.Context, name string) (io.ReadCloser, error) {
+	l, err := c.getDownloadLink(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return c.getFileLinkStream(ctx, l)
+}
+
+// getFileLinkStream returns contents of file at download link l as
+// a stream. Caller must close the returned body.
+func (c *apiclient) getFileLinkStream(ctx context.Context, l link) (io.ReadCloser, error) {
+	r, err := http.NewRequest(l.Method, l.Href, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	return c.transferStream(ctx, r, http.StatusOK)
+}
+
+// getPreview fetches the preview image from the link returned by the API
+// in the preview field of resource. Caller must close the returned body.
+func (c *apiclient) getPreview(ctx context.Context, href string) (io.ReadCloser, error) {
+	r, err := http.NewRequest(http.MethodGet, href, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	return c.transferStream(ctx, r, http.StatusOK)
+}
+
+// getFileRange fetches length bytes of file contents starting at offset
+// from the download link l. Caller must close the returned body.
+func (c *apiclient) getFileRange(ctx context.Context, l link, offset, length int64) (io.ReadCloser, error) {
+	r, err := http.NewRequest(l.Method, l.Href, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	r.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	return c.transferStream(ctx, r, http.StatusPartialContent)
+}
+
+func (c *apiclient) putFile(ctx context.Context, name string, overwrite bool, data []byte) error {
+	var hashes *contentHashes
+	if c.instantUpload > 0 && int64(len(data)) >= c.instantUpload {
+		hashes = hashBytes(data)
+	}
+	return c.putStream(ctx, name, overwrite, bytes.NewReader(data), int64(len(data)), hashes)
+}
+
+// getUploadLink fetches the link to upload file contents to. Path is
+// sent to the API as is. Nothing is created until the upload is made.
+func (c *apiclient) getUploadLink(ctx context.Context, apiPath string, overwrite bool) (link, error) {
 	v := make(url.Values)
-	v.Add("path", name)
+	v.Add("path", apiPath)
 	if overwrite {
 		v.Add("overwrite", "true")
 	}
-
 	url, _ := url.Parse(urlResourcesUpload)
 	url.RawQuery = v.Encode()
-	var l = &link{}
-	if err := c.requestInterface(http.MethodGet, http.StatusOK, url.String(), nil, l); err != nil {
-		return err
+	var l link
+	if err := c.requestInterface(ctx, http.MethodGet, http.StatusOK, url.String(), nil, &l); err != nil {
+		return link{}, err
 	}
-
-	if l.Templated {
-		// TODO: deal with templated links (I haven't seen one yet)
+	if err := l.resolve(map[string]string{"path": apiPath, "overwrite": strconv.FormatBool(overwrite)}); err != nil {
+		return link{}, err
 	}
+	return l, nil
+}
 
+// putStream uploads contents read from data to the named file. If size
+// is negative the length of data is unknown and the body is sent chunked.
+// If hashes of data are known, the uploader is offered to skip
+// transfer of the body (see WithoutInstantUpload).
+func (c *apiclient) putStream(ctx context.Context, name string, overwrite bool, data io.Reader, size int64, hashes *contentHashes) error {
+	if err := c.checkWrite(name, overwrite); err != nil {
+		return err
+	}
+	defer c.cache.invalidate(name)
+	l, err := c.getUploadLink(ctx, c.apiPath(name), overwrite)
+	if err != nil {
+		return err
+	}
 	// performing the actual upload
-	r, err := http.NewRequest(l.Method, l.Href, bytes.NewReader(data))
+	r, err := newUploadRequest(l, name, data, size)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrInternal, err)
+		return err
+	}
+	if hashes != nil {
+		hashes.setHeaders(r.Header)
 	}
-	_, err = c.do(context.TODO(), r, http.StatusCreated)
+	_, err = c.transfer(ctx, r, http.StatusCreated)
 	return err
 }
 
-func (c *apiclient) putFileTruncate(name string, data []byte) error {
-	return c.putFile(name, true, data)
+func (c *apiclient) putFileTruncate(ctx context.Context, name string, data []byte) error {
+	return c.putFile(ctx, name, true, data)
 }
 
-func (c *apiclient) putFileNoTruncate(name string, data []byte) error {
-	return c.putFile(name, false, data)
+func (c *apiclient) putFileNoTruncate(ctx context.Context, name string, data []byte) error {
+	return c.putFile(ctx, name, false, data)
 }
 
-func (c *apiclient) mkdir(name string) error {
+func (c *apiclient) mkdir(ctx context.Context, name string) error {
+	if err := c.checkWrite(name, false); err != nil {
+		return err
+	}
+	defer c.cache.invalidate(name)
 	v := make(url.Values)
-	v.Add("path", name)
+	v.Add("path", c.apiPath(name))
 	url, _ := url.Parse(urlResources)
 	url.RawQuery = v.Encode()
 	var l = link{}
-	return c.requestInterface(http.MethodPut, http.StatusCreated, url.String(), nil, &l)
+	return c.requestInterface(ctx, http.MethodPut, http.StatusCreated, url.String(), nil, &l)
+}
+
+func (c *apiclient) publish(ctx context.Context, name string) error {
+	return c.setPublished(ctx, urlResourcesPublish, name)
+}
+
+func (c *apiclient) unpublish(ctx context.Context, name string) error {
+	return c.setPublished(ctx, urlResourcesUnpublish, name)
+}
+
+func (c *apiclient) setPublished(ctx context.Context, endpoint, name string) error {
+	if err := c.checkWrite(name, true); err != nil {
+		return err
+	}
+	defer c.cache.invalidate(name)
+	v := make(url.Values)
+	v.Add("path", c.apiPath(name))
+	url, _ := url.Parse(endpoint)
+	url.RawQuery = v.Encode()
+	var l = link{}
+	return c.requestInterface(ctx, http.MethodPut, http.StatusOK, url.String(), nil, &l)
+}
+
+// patchProperties updates custom properties of the named resource and
+// returns its metadata. Properties with nil values are removed.
+func (c *apiclient) patchProperties(ctx context.Context, name string, props map[string]*string) (r Resource, err error) {
+	if err = c.checkWrite(name, false); err != nil {
+		return
+	}
+	defer c.cache.invalidate(name)
+	body, err := json.Marshal(map[string]interface{}{"custom_properties": props})
+	if err != nil {
+		return r, fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	v := make(url.Values)
+	v.Add("path", c.apiPath(name))
+	url, _ := url.Parse(urlResources)
+	url.RawQuery = v.Encode()
+	err = c.requestInterface(ctx, http.MethodPatch, http.StatusOK, url.String(), bytes.NewReader(body), &r)
+	return
+}
+
+// saveToDisk saves public resource identified by key to directory
+// saveDir of the disk under the given name. Saving large resources is
+// asynchronous, then saveToDisk waits for the operation to finish.
+func (c *apiclient) saveToDisk(ctx context.Context, key, name, saveDir string) error {
+	if err := c.checkWrite(path.Join(saveDir, name), false); err != nil {
+		return err
+	}
+	defer c.cache.invalidate(path.Join(saveDir, name))
+	v := make(url.Values)
+	v.Add("public_key", key)
+	v.Add("name", name)
+	v.Add("save_path", c.apiPath(saveDir))
+	u, _ := url.Parse(urlPublicResourcesSaveToDisk)
+	u.RawQuery = v.Encode()
+	var l link
+	code, err := c.requestStatus(ctx, http.MethodPost, []int{http.StatusCreated, http.StatusAccepted}, u.String(), nil, &l)
+	if err != nil {
+		return err
+	}
+	if code == http.StatusAccepted {
+		return c.waitOperation(ctx, c.registerOperation(l, "save", path.Join(saveDir, name)).Href)
+	}
+	return nil
+}
+
+// copyResource copies resource from one path to another. Copying large
+// resources is asynchronous, then copyResource waits for the operation
+// to finish.
+func (c *apiclient) copyResource(ctx context.Context, from, to string, overwrite bool) error {
+	if err := c.checkRead(from); err != nil {
+		return err
+	}
+	if restrictedWithin(c.policy, from, PathHidden) {
+		return fmt.Errorf("%w: %s contains paths hidden by path policy", fs.ErrPermission, from)
+	}
+	if err := c.checkWrite(to, overwrite); err != nil {
+		return err
+	}
+	return c.relocate(ctx, urlResourcesCopy, "copy", from, to, overwrite)
+}
+
+// moveResource moves resource from one path to another waiting for
+// asynchronous operation to finish like copyResource.
+func (c *apiclient) moveResource(ctx context.Context, from, to string, overwrite bool) error {
+	if err := c.checkWrite(from, true); err != nil {
+		return err
+	}
+	if err := c.checkWrite(to, overwrite); err != nil {
+		return err
+	}
+	return c.relocate(ctx, urlResourcesMove, "move", from, to, overwrite)
+}
+
+// relocate sends copy or move request to endpoint.
+func (c *apiclient) relocate(ctx context.Context, endpoint, op, from, to string, overwrite bool) error {
+	defer c.cache.invalidate(from)
+	defer c.cache.invalidate(to)
+	v := make(url.Values)
+	v.Add("from", c.apiPath(from))
+	v.Add("path", c.apiPath(to))
+	if overwrite {
+		v.Add("overwrite", "true")
+	}
+	u, _ := url.Parse(endpoint)
+	u.RawQuery = v.Encode()
+	var l link
+	code, err := c.requestStatus(ctx, http.MethodPost, []int{http.StatusCreated, http.StatusAccepted}, u.String(), nil, &l)
+	if err != nil {
+		return err
+	}
+	if code == http.StatusAccepted {
+		return c.waitOperation(ctx, c.registerOperation(l, op, to).Href)
+	}
+	return nil
+}
+
+// apiPath returns path of the named resource as sent to the API.
+func (c *apiclient) apiPath(name string) string {
+	name = c.unicodeForm.normalize(name)
+	if c.scheme == "" {
+		return name
+	}
+	return c.scheme + path.Clean("/"+name)
+}
+
+// normalize converts paths of r and its embedded resources as returned
+// by the API to paths of FS (see normalizeResourcePath).
+func (c *apiclient) normalize(r *Resource) {
+	c.normalizePath(r)
+	for i := range r.Embedded.Items {
+		c.normalizePath(&r.Embedded.Items[i])
+	}
+	c.filterHidden(r)
+}
+
+func (c *apiclient) normalizePath(r *Resource) {
+	if c.appRoot != "" {
+		p := strings.TrimPrefix(r.Path, "disk:")
+		if p == c.appRoot || strings.HasPrefix(p, c.appRoot+"/") {
+			r.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(p, c.appRoot), "/")
+		}
+	}
+	normalizeResourcePath(r)
+	if c.unicodeForm != UnicodeAsIs {
+		r.Path = c.unicodeForm.normalize(r.Path)
+		r.Name = c.unicodeForm.normalize(r.Name)
+	}
 }
 
 // getResource fetches Resource identified by name from the API.
 // if limit == 0 then embedded resources will not be requested not included
 // if limit > 0 then len(Resource.Embedded.Items) will not exceed limit.
-func (c *apiclient) getResource(name string, limit int, fields ...string) (r resource, err error) {
+func (c *apiclient) getResource(ctx context.Context, name string, limit int, fields ...string) (r Resource, err error) {
+	return c.getResourceSorted(ctx, name, limit, "", fields...)
+}
+
+// getResourceSorted is like getResource, but embedded resources are
+// sorted by the API as requested by sort parameter (e.g. "-modified").
+// Empty sort means default order.
+func (c *apiclient) getResourceSorted(ctx context.Context, name string, limit int, sort string, fields ...string) (r Resource, err error) {
 	v := make(url.Values)
-	v.Add("path", name)
 	v.Add("limit", strconv.Itoa(limit))
+	if sort != "" {
+		v.Add("sort", sort)
+	}
 	if len(fields) > 0 {
 		v.Add("fields", strings.Join(fields, ","))
 	}
+	return c.getResourceQuery(ctx, name, v)
+}
+
+// getResourceQuery fetches Resource identified by name passing
+// arbitrary query parameters v to the API.
+func (c *apiclient) getResourceQuery(ctx context.Context, name string, v url.Values) (r Resource, err error) {
+	if err = c.checkRead(name); err != nil {
+		return
+	}
+	v.Set("path", c.apiPath(name))
 	url, _ := url.Parse(urlResources)
 	url.RawQuery = v.Encode()
-	fmt.Printf("URL: %s\n", url)
-	err = c.requestInterface(http.MethodGet, http.StatusOK, url.String(), nil, &r)
+	err = c.requestInterface(ctx, http.MethodGet, http.StatusOK, url.String(), nil, &r)
 	return
 }
 
 // getResourceSingle fetches resource without embedded resources
-func (c *apiclient) getResourceSingle(name string) (resource, error) {
-	return c.getResource(name, 0)
+func (c *apiclient) getResourceSingle(ctx context.Context, name string) (Resource, error) {
+	return c.getResource(ctx, name, 0)
 }
 
 // getResourceMinTraffic fetches resource only requesting minimum
-// required info for FS to function. minimalFields is globally declared.
-func (c *apiclient) getResourceMinTraffic(name string) (resource, error) {
-	return c.getResource(name, 0, minimalFields...)
+// required info for FS to function. The set of fields is minimalFields
+// unless extended with WithFields option.
+func (c *apiclient) getResourceMinTraffic(ctx context.Context, name string) (Resource, error) {
+	c.validateCache(ctx)
+	if res, ok := c.cache.get(name, false); ok {
+		return res, c.checkRead(name)
+	}
+	res, err := c.getResource(ctx, name, 0, c.fields...)
+	if err == nil {
+		c.cache.put(name, res, false)
+	}
+	return res, err
 }
 
-// getResourceWithEmbedded fetches resource with embedded resources
-func (c *apiclient) getResourceWithEmbedded(name string) (resource, error) {
-	return c.getResource(name, (1<<31)-1)
+// getResourceWithEmbedded fetches resource with embedded resources.
+// If fields are provided only these fields are requested both for
+// the resource and for its embedded resources (see listingFields).
+func (c *apiclient) getResourceWithEmbedded(ctx context.Context, name string, fields ...string) (Resource, error) {
+	return c.getResource(ctx, name, (1<<31)-1, fields...)
 }
 
-func (c *apiclient) delResource(name string, permanently bool) error {
-	u, _ := url.Parse(urlResources)
+// getResourceListing fetches directory with embedded resources requesting
+// only the fields FS needs both for the directory and for its items.
+// Items are sorted according to sort parameter (see getResourceSorted).
+func (c *apiclient) getResourceListing(ctx context.Context, name string, sort string) (Resource, error) {
+	if sort != "" {
+		return c.getResourceSorted(ctx, name, (1<<31)-1, sort, listingFields(c.fields...)...)
+	}
+	c.validateCache(ctx)
+	if res, ok := c.cache.get(name, true); ok {
+		return res, c.checkRead(name)
+	}
+	res, err := c.getResourceSorted(ctx, name, (1<<31)-1, sort, listingFields(c.fields...)...)
+	if err == nil && res.IsDir() {
+		c.cache.put(name, res, true)
+	}
+	return res, err
+}
+
+// listingFields extends fields with their counterparts for embedded
+// resources, so that the API does not send full metadata of every item
+// in a directory listing.
+func listingFields(fields ...string) []string {
+	result := make([]string, 0, 2*len(fields)+3)
+	result = append(result, fields...)
+	for _, f := range fields {
+		result = append(result, "_embedded.items."+f)
+	}
+	return append(result, "_embedded.total", "_embedded.limit", "_embedded.offset")
+}
+
+// listDir pages through entries of the named directory sorted by sort
+// (see getResourceSorted) calling fn for every entry until fn returns
+// false. Every page holds up to pageSize entries.
+func (c *apiclient) listDir(ctx context.Context, name string, sort string, pageSize int, fn func(Resource) bool) error {
+	if err := c.checkRead(name); err != nil {
+		return err
+	}
+	fields := listingFields(c.fields...)
+	for offset := 0; ; {
+		v := make(url.Values)
+		v.Add("path", c.apiPath(name))
+		v.Add("limit", strconv.Itoa(pageSize))
+		v.Add("offset", strconv.Itoa(offset))
+		if sort != "" {
+			v.Add("sort", sort)
+		}
+		v.Add("fields", strings.Join(fields, ","))
+		u, _ := url.Parse(urlResources)
+		u.RawQuery = v.Encode()
+		// items are decoded one by one as they arrive
+		var res Resource
+		n, stopped := 0, false
+		err := c.requestItems(ctx, u.String(), []string{"_embedded", "items"}, &res, func(dec *json.Decoder) (bool, error) {
+			var item Resource
+			if err := dec.Decode(&item); err != nil {
+				return false, err
+			}
+			n++
+			c.normalizePath(&item)
+			if c.hidden(item.Path) {
+				return true, nil
+			}
+			stopped = !fn(item)
+			return !stopped, nil
+		})
+		if err != nil || stopped {
+			return err
+		}
+		if !res.IsDir() && n == 0 {
+			return ErrNotDir
+		}
+		offset += n
+		if n == 0 || offset >= res.Embedded.Total {
+			return nil
+		}
+	}
+}
+
+// getPublicResource fetches metadata of the resource at path name within
+// public resource identified by key (public key or public URL).
+// Embedded resources are requested the same way as in getResource.
+func (c *apiclient) getPublicResource(ctx context.Context, key, name string, limit, offset int) (r Resource, err error) {
 	v := make(url.Values)
+	v.Add("public_key", key)
 	v.Add("path", name)
+	v.Add("limit", strconv.Itoa(limit))
+	v.Add("offset", strconv.Itoa(offset))
+	url, _ := url.Parse(urlPublicResources)
+	url.RawQuery = v.Encode()
+	err = c.requestInterface(ctx, http.MethodGet, http.StatusOK, url.String(), nil, &r)
+	return
+}
+
+// getTrashResource fetches trashed resource at path name within the
+// trash with up to limit embedded resources starting at offset.
+func (c *apiclient) getTrashResource(ctx context.Context, name string, limit, offset int) (r Resource, err error) {
+	v := make(url.Values)
+	v.Add("path", "trash:"+name)
+	v.Add("limit", strconv.Itoa(limit))
+	v.Add("offset", strconv.Itoa(offset))
+	url, _ := url.Parse(urlTrashResources)
+	url.RawQuery = v.Encode()
+	err = c.requestInterface(ctx, http.MethodGet, http.StatusOK, url.String(), nil, &r)
+	return
+}
+
+// restoreTrash restores trashed resource at path name within the trash
+// to dest, which is its origin path with the name replaced by newName
+// if it is not empty. Restoring large folders is asynchronous, then
+// restoreTrash waits for the operation to finish.
+func (c *apiclient) restoreTrash(ctx context.Context, name, newName, dest string, overwrite bool) error {
+	if err := c.checkWrite(dest, overwrite); err != nil {
+		return err
+	}
+	defer c.cache.invalidate(dest)
+	v := make(url.Values)
+	v.Add("path", "trash:"+name)
+	if newName != "" {
+		v.Add("name", newName)
+	}
+	if overwrite {
+		v.Add("overwrite", "true")
+	}
+	u, _ := url.Parse(urlTrashResourcesRestore)
+	u.RawQuery = v.Encode()
+	var l link
+	code, err := c.requestStatus(ctx, http.MethodPut, []int{http.StatusCreated, http.StatusAccepted}, u.String(), nil, &l)
+	if err != nil {
+		return err
+	}
+	if code == http.StatusAccepted {
+		return c.waitOperation(ctx, c.registerOperation(l, "restore", dest).Href)
+	}
+	return nil
+}
+
+// delTrashResource permanently deletes trashed resource at path name
+// within the trash waiting for asynchronous deletion to finish.
+func (c *apiclient) delTrashResource(ctx context.Context, name string) error {
+	v := make(url.Values)
+	v.Add("path", "trash:"+name)
+	u, _ := url.Parse(urlTrashResources)
+	u.RawQuery = v.Encode()
+	var l link
+	code, err := c.requestStatus(ctx, http.MethodDelete, []int{http.StatusNoContent, http.StatusAccepted}, u.String(), nil, &l)
+	if err != nil {
+		return err
+	}
+	if code == http.StatusAccepted {
+		return c.waitOperation(ctx, c.registerOperation(l, "purge", name).Href)
+	}
+	return nil
+}
+
+// getPublicDownloadLink fetches the link to download the file at path
+// name within public resource identified by key. Empty name refers to
+// the published resource itself.
+func (c *apiclient) getPublicDownloadLink(ctx context.Context, key, name string) (link, error) {
+	v := make(url.Values)
+	v.Add("public_key", key)
+	if name != "" {
+		v.Add("path", name)
+	}
+	url, _ := url.Parse(urlPublicResourcesDownload)
+	url.RawQuery = v.Encode()
+	var l link
+	if err := c.requestInterface(ctx, http.MethodGet, http.StatusOK, url.String(), nil, &l); err != nil {
+		return link{}, err
+	}
+	if err := l.resolve(map[string]string{"public_key": key, "path": name}); err != nil {
+		return link{}, err
+	}
+	return l, nil
+}
+
+// getPublicFile fetches bytes of the file at path name within
+// public resource identified by key.
+func (c *apiclient) getPublicFile(ctx context.Context, key, name string) ([]byte, error) {
+	l, err := c.getPublicDownloadLink(ctx, key, name)
+	if err != nil {
+		return []byte{}, err
+	}
+	r, err := http.NewRequest(l.Method, l.Href, nil)
+	if err != nil {
+		return []byte{}, fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	return c.transfer(ctx, r, http.StatusOK)
+}
+
+// listFiles pages through the flat list of all files on the disk calling
+// fn for every file until fn returns false or the list is exhausted.
+// Fields are requested for every item.
+func (c *apiclient) listFiles(ctx context.Context, pageSize int, fields []string, fn func(Resource) bool) error {
+	return c.listFilesSorted(ctx, pageSize, "", fields, fn)
+}
+
+// listFilesSorted is listFiles with files sorted by sort (see
+// getResourceSorted), API default order if sort is empty.
+func (c *apiclient) listFilesSorted(ctx context.Context, pageSize int, sort string, fields []string, fn func(Resource) bool) error {
+	return c.listItems(ctx, urlResourcesFiles, pageSize, sort, fields, fn)
+}
+
+// listLastUploaded fetches up to limit files sorted by upload time
+// from the newest, only the ones of the given media types if any.
+// The API does not page the list.
+func (c *apiclient) listLastUploaded(ctx context.Context, limit int, mediaTypes []string, fields []string) ([]Resource, error) {
+	v := make(url.Values)
+	v.Add("limit", strconv.Itoa(limit))
+	if len(mediaTypes) > 0 {
+		v.Add("media_type", strings.Join(mediaTypes, ","))
+	}
+	if len(fields) > 0 {
+		itemFields := make([]string, len(fields))
+		for i := range fields {
+			itemFields[i] = "items." + fields[i]
+		}
+		v.Add("fields", strings.Join(itemFields, ","))
+	}
+	u, _ := url.Parse(urlResourcesLastUploaded)
+	u.RawQuery = v.Encode()
+	var list lastUploadedResourceList
+	if err := c.requestInterface(ctx, http.MethodGet, http.StatusOK, u.String(), nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// listPublic pages through the list of published resources like listFiles.
+func (c *apiclient) listPublic(ctx context.Context, pageSize int, fields []string, fn func(Resource) bool) error {
+	return c.listItems(ctx, urlResourcesPublic, pageSize, "", fields, fn)
+}
+
+// listItems pages through flat list of resources at endpoint.
+func (c *apiclient) listItems(ctx context.Context, endpoint string, pageSize int, sort string, fields []string, fn func(Resource) bool) error {
+	return c.listItemsQuery(ctx, endpoint, nil, pageSize, sort, fields, fn)
+}
+
+// listItemsQuery is listItems sending query parameters of query (e.g.
+// media_type) along with paging ones.
+func (c *apiclient) listItemsQuery(ctx context.Context, endpoint string, query url.Values, pageSize int, sort string, fields []string, fn func(Resource) bool) error {
+	itemFields := make([]string, len(fields))
+	for i := range fields {
+		itemFields[i] = "items." + fields[i]
+	}
+	for offset := 0; ; offset += pageSize {
+		v := make(url.Values)
+		for k, values := range query {
+			v[k] = values
+		}
+		v.Add("limit", strconv.Itoa(pageSize))
+		v.Add("offset", strconv.Itoa(offset))
+		if sort != "" {
+			v.Add("sort", sort)
+		}
+		if len(itemFields) > 0 {
+			v.Add("fields", strings.Join(itemFields, ","))
+		}
+		url, _ := url.Parse(endpoint)
+		url.RawQuery = v.Encode()
+		var list filesResourceList
+		n, stopped := 0, false
+		err := c.requestItems(ctx, url.String(), []string{"items"}, &list, func(dec *json.Decoder) (bool, error) {
+			var item Resource
+			if err := dec.Decode(&item); err != nil {
+				return false, err
+			}
+			n++
+			if len(c.policy) > 0 || c.hideDir != "" {
+				normalized := item
+				c.normalizePath(&normalized)
+				if c.unlisted(normalized.Path, true) {
+					return true, nil
+				}
+			}
+			stopped = !fn(item)
+			return !stopped, nil
+		})
+		if err != nil || stopped {
+			return err
+		}
+		if n < pageSize {
+			return nil
+		}
+	}
+}
+
+// delResource deletes resource. Deletion of large directories is
+// performed by the API asynchronously; in such case delResource waits
+// for the operation to finish.
+func (c *apiclient) delResource(ctx context.Context, name string, permanently bool) error {
+	if err := c.checkWrite(name, true); err != nil {
+		return err
+	}
+	defer c.cache.invalidate(name)
+	u, _ := url.Parse(urlResources)
+	v := make(url.Values)
+	v.Add("path", c.apiPath(name))
 	if permanently {
 		v.Add("permanently", "true")
 	}
 	u.RawQuery = v.Encode()
-	r, err := http.NewRequest(http.MethodDelete, u.String(), nil)
+	var l link
+	code, err := c.requestStatus(ctx, http.MethodDelete, []int{http.StatusNoContent, http.StatusAccepted}, u.String(), nil, &l)
 	if err != nil {
 		return err
 	}
-	_, err = c.do(context.TODO(), r, http.StatusNoContent)
-	return err
+	if code == http.StatusAccepted {
+		return c.waitOperation(ctx, c.registerOperation(l, "remove", name).Href)
+	}
+	return nil
 }
 
-func (c *apiclient) delResourcePermanently(name string) error {
-	return c.delResource(name, true)
+func (c *apiclient) delResourcePermanently(ctx context.Context, name string) error {
+	return c.delResource(ctx, name, true)
 }
 
-func (c *apiclient) delResourceTrash(name string) error {
-	return c.delResource(name, false)
+func (c *apiclient) delResourceTrash(ctx context.Context, name string) error {
+	return c.delResource(ctx, name, false)
 }
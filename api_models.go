@@ -35,11 +35,40 @@ type link struct {
 	Templated   bool   `json:"templated,omitempty"`
 }
 
+// Types of resources as reported in Resource.Type.
+const (
+	TypeDir  = "dir"
+	TypeFile = "file"
+)
+
+// Media types of files as reported in Resource.MediaType.
+const (
+	MediaTypeAudio       = "audio"
+	MediaTypeBackup      = "backup"
+	MediaTypeBook        = "book"
+	MediaTypeCompressed  = "compressed"
+	MediaTypeData        = "data"
+	MediaTypeDevelopment = "development"
+	MediaTypeDiskImage   = "diskimage"
+	MediaTypeDocument    = "document"
+	MediaTypeEncoded     = "encoded"
+	MediaTypeExecutable  = "executable"
+	MediaTypeFlash       = "flash"
+	MediaTypeFont        = "font"
+	MediaTypeImage       = "image"
+	MediaTypeSettings    = "settings"
+	MediaTypeSpreadsheet = "spreadsheet"
+	MediaTypeText        = "text"
+	MediaTypeUnknown     = "unknown"
+	MediaTypeVideo       = "video"
+	MediaTypeWeb         = "web"
+)
+
 // Resource holds information about the resource (either directory or file)
-type resource struct {
-	PubLicKey        string            `json:"public_key,omitempty"`
+type Resource struct {
+	PublicKey        string            `json:"public_key,omitempty"`
 	PublicURL        string            `json:"public_url,omitempty"`
-	Embedded         resourceList      `json:"_embedded,omitempty"`
+	Embedded         ResourceList      `json:"_embedded,omitempty"`
 	Name             string            `json:"name,omitempty"`
 	Exif             map[string]string `json:"exif,omitempty"`            // seems to only appear in photos
 	PhotosliceTime   time.Time         `json:"photoslice_time,omitempty"` // seems to only appear in photos
@@ -50,24 +79,34 @@ type resource struct {
 	Created          time.Time         `json:"created,omitempty"`
 	Modified         time.Time         `json:"modified,omitempty"`
 	CustomProperties map[string]string `json:"custom_properties,omitempty"`
-	OriginPath       string            `json:"origin_path,omitempty"`
+	OriginPath       string            `json:"origin_path,omitempty"` // path trashed resource was deleted from
+	Deleted          time.Time         `json:"deleted,omitempty"`     // time trashed resource was deleted at
 	Path             string            `json:"path,omitempty"`
 	MD5              string            `json:"md5,omitempty"`
-	SHA256           string            `json:"sha26,omitempty"`
+	SHA256           string            `json:"sha256,omitempty"`
 	CommentIDs       CommentIDs        `json:"comment_ids,omitempty"`      // undocumented :)
-	Type             string            `json:"type,omitempty"`             // "dir" or "file"
+	Type             string            `json:"type,omitempty"`             // TypeDir or TypeFile
 	MimeType         string            `json:"mime_type,omitempty"`        // "image/jpeg", "video/mp4" etc.
 	Size             int64             `json:"size,omitempty"`             // size in bytes (?)
 	Revision         int64             `json:"revision,omitempty"`         // dunno?
 	AntivirusStatus  string            `json:"antivirus_status,omitempty"` // "clean", "not-scanned" etc.
+	Share            *ShareInfo        `json:"share,omitempty"`            // set for resources in shared folders
+	ViewsCount       int               `json:"views_count,omitempty"`      // views of public resource, public metadata only
+	Owner            *PublicOwner      `json:"owner,omitempty"`            // owner of public resource, public metadata only
 
 }
 
+// PublicOwner is the owner of public resource as shown to visitors.
+type PublicOwner struct {
+	Login       string `json:"login,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
 // ResourceList represents a list of resources
-type resourceList struct {
+type ResourceList struct {
 	Sort      string     `json:"sort,omitempty"` // list is sorted by this field
-	PubLicKey string     `json:"public_key,omitempty"`
-	Items     []resource `json:"items,omitempty"`
+	PublicKey string     `json:"public_key,omitempty"`
+	Items     []Resource `json:"items,omitempty"`
 	Path      string     `json:"path,omitempty"`
 	Limit     int        `json:"limit,omitempty"`  // this max elements are in Items above
 	Offset    int        `json:"offset,omitempty"` // offset from first resource in directory
@@ -81,7 +120,7 @@ type CommentIDs struct {
 
 // FileResourceList is a flat list of all files on disk sorted alphabetically
 type filesResourceList struct {
-	Items  []resource `json:"items,omitempty"`
+	Items  []Resource `json:"items,omitempty"`
 	Limit  int        `json:"limit,omitempty"`  // this max elements are in Items above
 	Offset int        `json:"offset,omitempty"` // offset from first resource in directory
 }
@@ -89,13 +128,13 @@ type filesResourceList struct {
 // LastUploadedResourceList is a list of uploaded files sorted by
 // upload time from oldest to newest
 type lastUploadedResourceList struct {
-	Items []resource `json:"items,omitempty"`
+	Items []Resource `json:"items,omitempty"`
 	Limit int        `json:"limit,omitempty"`
 }
 
 // PublicResourcesList represents a list of publicly available resources
 type publicResourcesList struct {
-	Items  []resource `json:"items,omitempty"`
+	Items  []Resource `json:"items,omitempty"`
 	Type   string     `json:"type,omitempty"`
 	Limit  int        `json:"limit,omitempty"`
 	Offset int        `json:"offset,omitempty"`
@@ -122,6 +161,26 @@ type errAPI struct {
 	Err         string `json:"error,omitempty"`
 }
 
+// IsDir reports whether r is a directory.
+func (r *Resource) IsDir() bool {
+	return r.Type == TypeDir
+}
+
+// IsFile reports whether r is a file.
+func (r *Resource) IsFile() bool {
+	return r.Type == TypeFile
+}
+
+// IsImage reports whether r is an image.
+func (r *Resource) IsImage() bool {
+	return r.MediaType == MediaTypeImage
+}
+
+// IsVideo reports whether r is a video.
+func (r *Resource) IsVideo() bool {
+	return r.MediaType == MediaTypeVideo
+}
+
 func (e *errAPI) Error() string {
 	return strings.Join([]string{e.Message, e.Description}, " ")
 }
@@ -129,3 +188,7 @@ func (e *errAPI) Error() string {
 func (e *errAPI) NotFound() bool {
 	return e.Err == "DiskNotFoundError"
 }
+
+func (e *errAPI) AlreadyExists() bool {
+	return e.Err == "DiskResourceAlreadyExistsError"
+}
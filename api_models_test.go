@@ -0,0 +1,31 @@
+package ydfs
+
+import (
+	"testing"
+)
+
+func TestResourcePredicates(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/photos/a.jpg", []byte("a"))
+	md.put("/photos/b.mp4", []byte("b"))
+	res, err := fsys.ReadDirExtended("/photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("got %d resources, want 2", len(res))
+	}
+	if image := res[0]; !image.IsFile() || image.IsDir() || !image.IsImage() || image.IsVideo() {
+		t.Errorf("predicates of image %+v are wrong", image)
+	}
+	if video := res[1]; !video.IsVideo() || video.IsImage() {
+		t.Errorf("predicates of video %+v are wrong", video)
+	}
+	dir, err := fsys.StatExtended("/photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dir.IsDir() || dir.IsFile() {
+		t.Errorf("predicates of directory %+v are wrong", dir)
+	}
+}
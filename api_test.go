@@ -12,16 +12,24 @@ import (
 var client *apiclient
 
 func TestMain(m *testing.M) {
-	token, ok := os.LookupEnv("YD")
-	if !ok {
+	if token, ok := os.LookupEnv("YD"); ok {
+		client = newApiClient(token, http.DefaultClient)
+	} else {
 		fmt.Println("environment variable YD not set. skipping integration tests")
-		os.Exit(0)
 	}
-	client = newApiClient(token, http.DefaultClient)
 	os.Exit(m.Run())
 }
 
+// integration skips the test if no token for real API is provided.
+func integration(t *testing.T) {
+	t.Helper()
+	if client == nil {
+		t.Skip("environment variable YD not set")
+	}
+}
+
 func Test_doRequest(t *testing.T) {
+	integration(t)
 	r, err := http.NewRequest(http.MethodGet, urlBase, nil)
 	if err != nil {
 		t.Errorf("error creating request %v", err)
@@ -33,15 +41,17 @@ func Test_doRequest(t *testing.T) {
 }
 
 func Test_requestInterface(t *testing.T) {
+	integration(t)
 	var d = &diskInfo{}
-	err := client.requestInterface(http.MethodGet, http.StatusOK, urlBase, nil, d)
+	err := client.requestInterface(context.TODO(), http.MethodGet, http.StatusOK, urlBase, nil, d)
 	if err != nil {
 		t.Errorf("client.requestInterface returned: %v", err)
 	}
 }
 
 func Test_getResourceMinTraffic(t *testing.T) {
-	res, err := client.getResourceMinTraffic("/")
+	integration(t)
+	res, err := client.getResourceMinTraffic(context.TODO(), "/")
 	if err != nil {
 		t.Errorf("client.getResourceMinTraffic returned: %v", err)
 	}
@@ -51,14 +61,16 @@ func Test_getResourceMinTraffic(t *testing.T) {
 }
 
 func Test_putFile(t *testing.T) {
-	err := client.putFileTruncate(testFileName, testFileBody)
+	integration(t)
+	err := client.putFileTruncate(context.TODO(), testFileName, testFileBody)
 	if err != nil {
 		t.Logf("upload test file failed: %v", err)
 	}
 }
 
 func Test_getFile(t *testing.T) {
-	b, err := client.getFile(testFileName)
+	integration(t)
+	b, err := client.getFile(context.TODO(), testFileName)
 	if err != nil {
 		t.Errorf("getting test file failed: %v", err)
 	}
@@ -0,0 +1,32 @@
+package ydfs
+
+import (
+	"context"
+	"net/http"
+)
+
+// NewAppFolder is like New, but returned FS is rooted at the folder
+// of the application on the disk (app:/). Paths of FS are sent to the
+// API as app:/ paths, so tokens which only grant access to the
+// application folder work with it.
+func NewAppFolder(token string, client *http.Client, opts ...Option) (FS, error) {
+	return NewAppFolderContext(context.TODO(), token, client, opts...)
+}
+
+// NewAppFolderContext is like NewAppFolder, but the request validating
+// the token is made with ctx, so that it can be cancelled or time out.
+func NewAppFolderContext(ctx context.Context, token string, client *http.Client, opts ...Option) (FS, error) {
+	o := newOptions(opts...)
+	c, err := o.newClient(token, client)
+	if err != nil {
+		return nil, err
+	}
+	c.scheme = "app:"
+	c.init = c.initAppFolder
+	if !o.lazyInit {
+		if err := c.validate(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return &ydfs{client: c, opts: o, path: "/", issub: false}, nil
+}
@@ -0,0 +1,60 @@
+package ydfs
+
+import (
+	"path"
+	"testing"
+)
+
+func TestNewAppFolder(t *testing.T) {
+	md := newMockDisk()
+	md.put(mockAppRoot+"/a.txt", []byte("a"))
+	md.put("/private.txt", []byte("p"))
+	fsys, err := NewAppFolder("mocktoken", newMockClient(t, md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := md.lastQuery(); q.Get("path") != "app:/" {
+		t.Errorf("API is requested with path %q", q.Get("path"))
+	}
+
+	info, err := fsys.Stat("/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name() != "a.txt" || info.Size() != 1 {
+		t.Errorf("Stat() returns %q of size %d", info.Name(), info.Size())
+	}
+	if err := fsys.MkdirAll("/dir/sub"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.WriteFile("/dir/sub/b.txt", []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := md.get(mockAppRoot + "/dir/sub/b.txt"); !ok || string(e.data) != "b" {
+		t.Error("file is not written into app folder")
+	}
+	if data, err := fsys.ReadFile("/dir/sub/b.txt"); err != nil || string(data) != "b" {
+		t.Errorf("ReadFile returns %q, %v", data, err)
+	}
+	entries, err := fsys.ReadDir("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if len(names) != 2 || names[0] != "a.txt" || names[1] != "dir" {
+		t.Errorf("ReadDir returns %v", names)
+	}
+	sub, err := fsys.Sub("/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := sub.Stat("/sub/b.txt"); err != nil || path.Base(info.Name()) != "b.txt" {
+		t.Errorf("Stat in sub FS returns %v, %v", info, err)
+	}
+	if _, err := fsys.Stat("/private.txt"); err == nil {
+		t.Error("files outside of app folder are accessible")
+	}
+}
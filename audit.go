@@ -0,0 +1,72 @@
+package ydfs
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Operations reported in AuditRecord.Op.
+const (
+	AuditWrite     = "write"     // file is uploaded
+	AuditMkdir     = "mkdir"     // directory is created
+	AuditRemove    = "remove"    // resource is removed
+	AuditRename    = "rename"    // resource is moved, Path is the new path
+	AuditPublish   = "publish"   // resource is published
+	AuditUnpublish = "unpublish" // resource is unpublished
+	AuditRestore   = "restore"   // resource is restored from trash or from a version
+	AuditPurge     = "purge"     // resource is removed from trash
+	AuditVersion   = "version"   // copy of file is kept as a version before it is changed
+	AuditSave      = "save"      // public resource is saved to the disk
+	AuditLock      = "lock"      // lock of resource is written by TryLock
+	AuditUnlock    = "unlock"    // lock of resource is removed by Unlock
+)
+
+// AuditRecord describes a single mutating call performed through FS.
+type AuditRecord struct {
+	Time time.Time // when the call has finished
+	Op   string    // operation name, one of Audit constants
+	Path string    // full path of the resource on the disk
+	Size int64     // size of file written, kept, saved or purged (zero for other operations)
+	Err  error     // result of the call, nil on success
+}
+
+// String formats record as a single tab separated line.
+func (r AuditRecord) String() string {
+	result := "ok"
+	if r.Err != nil {
+		result = r.Err.Error()
+	}
+	return fmt.Sprintf("%s\t%s\t%s\t%d\t%s", r.Time.Format(time.RFC3339), r.Op, r.Path, r.Size, result)
+}
+
+// WithAuditLog makes FS write a line to w for every mutating call.
+// Writes to w are serialized. Errors writing to w are ignored.
+func WithAuditLog(w io.Writer) Option {
+	var mu sync.Mutex
+	return WithAuditFunc(func(r AuditRecord) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintln(w, r.String())
+	})
+}
+
+// WithAuditFunc makes FS call fn for every mutating call.
+// fn may be called concurrently if FS is used from several goroutines.
+func WithAuditFunc(fn func(AuditRecord)) Option {
+	return func(o *options) {
+		o.audit = append(o.audit, fn)
+	}
+}
+
+// auditRecord passes the record of a finished call to the configured handlers.
+func (o *options) auditRecord(op, name string, size int64, err error) {
+	if len(o.audit) == 0 {
+		return
+	}
+	r := AuditRecord{Time: time.Now(), Op: op, Path: name, Size: size, Err: err}
+	for _, fn := range o.audit {
+		fn(r)
+	}
+}
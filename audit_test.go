@@ -0,0 +1,56 @@
+package ydfs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	var (
+		buf     bytes.Buffer
+		records []AuditRecord
+	)
+	fsys, _ := newMockFS(t, WithAuditLog(&buf), WithAuditFunc(func(r AuditRecord) {
+		records = append(records, r)
+	}))
+	if err := fsys.WriteFile("/a.txt", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Mkdir("/dir"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Mkdir("/dir"); err == nil {
+		t.Fatal("second Mkdir of the same dir succeeds")
+	}
+	if err := fsys.Remove("/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		op   string
+		path string
+		size int64
+		ok   bool
+	}{
+		{"write", "/a.txt", 5, true},
+		{"mkdir", "/dir", 0, true},
+		{"mkdir", "/dir", 0, false},
+		{"remove", "/a.txt", 0, true},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("want %d records, have %d: %v", len(want), len(records), records)
+	}
+	for i, w := range want {
+		r := records[i]
+		if r.Op != w.op || r.Path != w.path || r.Size != w.size || (r.Err == nil) != w.ok || r.Time.IsZero() {
+			t.Errorf("record %d: want %+v, have %+v", i, w, r)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("want %d lines in audit log, have %d", len(want), len(lines))
+	}
+	if !strings.HasSuffix(lines[0], "\twrite\t/a.txt\t5\tok") {
+		t.Errorf("unexpected audit log line: %q", lines[0])
+	}
+}
@@ -0,0 +1,149 @@
+package ydfs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"testing"
+)
+
+// Benchmarks run against the mock server, so they measure overhead of
+// the library (requests, JSON decoding, allocations) rather than the
+// network: go test -run '^$' -bench . -benchmem
+
+func BenchmarkReadDir10k(b *testing.B) {
+	fsys, md := newMockFS(b)
+	for i := range 10000 {
+		md.put(fmt.Sprintf("/big/file%05d.txt", i), nil)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		entries, err := fsys.ReadDir("/big")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(entries) != 10000 {
+			b.Fatalf("have %d entries", len(entries))
+		}
+	}
+}
+
+func BenchmarkReadFile100MB(b *testing.B) {
+	fsys, md := newMockFS(b)
+	data := bytes.Repeat([]byte("0123456789abcdef"), 100<<20/16)
+	md.put("/large.bin", data)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		got, err := fsys.ReadFile("/large.bin")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(got) != len(data) {
+			b.Fatalf("have %d bytes", len(got))
+		}
+	}
+}
+
+func BenchmarkWalkDirDeep(b *testing.B) {
+	fsys, md := newMockFS(b)
+	dir, files := "/deep", 0
+	for i := range 50 {
+		dir = path.Join(dir, fmt.Sprintf("level%02d", i))
+		for j := range 5 {
+			md.put(path.Join(dir, fmt.Sprintf("file%d", j)), nil)
+			files++
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		n := 0
+		err := fs.WalkDir(fsys, "/deep", func(p string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				n++
+			}
+			return err
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if n != files {
+			b.Fatalf("have %d files, want %d", n, files)
+		}
+	}
+}
+
+func BenchmarkStatParallel(b *testing.B) {
+	fsys, md := newMockFS(b)
+	for i := range 100 {
+		md.put(fmt.Sprintf("/stat/file%02d", i), []byte("data"))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if _, err := fsys.Stat(fmt.Sprintf("/stat/file%02d", i%100)); err != nil {
+				b.Error(err)
+				return
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkFileRead(b *testing.B) {
+	fsys, md := newMockFS(b)
+	data := bytes.Repeat([]byte("0123456789abcdef"), 16<<20/16)
+	md.put("/medium.bin", data)
+	buf := make([]byte, 32<<10)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		f, err := fsys.Open("/medium.bin")
+		if err != nil {
+			b.Fatal(err)
+		}
+		n := 0
+		for {
+			m, err := f.Read(buf)
+			n += m
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		f.Close()
+		if n != len(data) {
+			b.Fatalf("have %d bytes", n)
+		}
+	}
+}
+
+func BenchmarkFileCopy(b *testing.B) {
+	fsys, md := newMockFS(b)
+	data := bytes.Repeat([]byte("0123456789abcdef"), 16<<20/16)
+	md.put("/medium.bin", data)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		f, err := fsys.Open("/medium.bin")
+		if err != nil {
+			b.Fatal(err)
+		}
+		n, err := io.Copy(io.Discard, f)
+		f.Close()
+		if err != nil || n != int64(len(data)) {
+			b.Fatalf("copied %d bytes: %v", n, err)
+		}
+	}
+}
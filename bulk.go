@@ -0,0 +1,54 @@
+package ydfs
+
+import (
+	"fmt"
+	"sort"
+)
+
+// WithContinueOnError makes RemoveAll continue past resources which can
+// not be removed and return PathErrors listing all of them instead of
+// stopping at the first one. Directories with children which are not
+// removed are kept.
+func WithContinueOnError() Option {
+	return func(o *options) {
+		o.continueOnError = true
+	}
+}
+
+// PathErrors is returned by operations on many paths (StatMany, RemoveAll
+// made WithContinueOnError) if some of the paths fail. It maps the
+// paths to their errors. errors.Is reports whether any of the errors
+// matches the target.
+type PathErrors map[string]error
+
+// Error implements error
+func (e PathErrors) Error() string {
+	if len(e) == 1 {
+		for _, err := range e {
+			return err.Error()
+		}
+	}
+	return fmt.Sprintf("%d paths failed, first: %v", len(e), e.Unwrap()[0])
+}
+
+// Unwrap returns errors of all paths sorted by path.
+func (e PathErrors) Unwrap() []error {
+	paths := make([]string, 0, len(e))
+	for p := range e {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	errs := make([]error, len(paths))
+	for i, p := range paths {
+		errs[i] = e[p]
+	}
+	return errs
+}
+
+// add records err of path unless e is nil and returns err.
+func (e PathErrors) add(path string, err error) error {
+	if e != nil {
+		e[path] = err
+	}
+	return err
+}
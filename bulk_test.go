@@ -0,0 +1,48 @@
+package ydfs
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRemoveAllContinueOnError(t *testing.T) {
+	guard := func(name string, info fs.FileInfo) bool {
+		return !strings.HasSuffix(name, ".keep")
+	}
+	fsys, md := newMockFS(t, WithDeleteGuard(guard), WithContinueOnError())
+	md.put("/tree/a.keep", []byte("a"))
+	md.put("/tree/b.txt", []byte("b"))
+	md.put("/tree/sub/c.keep", []byte("c"))
+	md.put("/tree/sub/d.txt", []byte("d"))
+	md.put("/tree/other/e.txt", []byte("e"))
+
+	err := fsys.RemoveAll("/tree")
+	var errs PathErrors
+	if !errors.As(err, &errs) || !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("want PathErrors, have %v", err)
+	}
+	var failed []string
+	for p := range errs {
+		failed = append(failed, p)
+	}
+	if want := []string{"/tree/a.keep", "/tree/sub/c.keep"}; len(failed) != 2 || !reflect.DeepEqual(errs.Unwrap(), []error{errs[want[0]], errs[want[1]]}) {
+		t.Errorf("want failures of %v, have %v", want, failed)
+	}
+	for p, kept := range map[string]bool{
+		"/tree":             true,
+		"/tree/a.keep":      true,
+		"/tree/b.txt":       false,
+		"/tree/sub":         true,
+		"/tree/sub/c.keep":  true,
+		"/tree/sub/d.txt":   false,
+		"/tree/other":       false,
+		"/tree/other/e.txt": false,
+	} {
+		if _, ok := md.get(p); ok != kept {
+			t.Errorf("%s: want kept %v, have %v", p, kept, ok)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package ydfs
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithMetadataCache makes FS keep metadata fetched by Stat and ReadDir
+// for ttl, so that repeated calls for the same paths do not reach the
+// API. Changes made through FS (or other FS sharing its Client)
+// invalidate cached metadata of the affected paths, but changes made by
+// others are not seen until cached metadata expires unless
+// WithRevisionCheck is used. See also Prefetch.
+func WithMetadataCache(ttl time.Duration) Option {
+	return func(o *options) {
+		o.cacheTTL = ttl
+	}
+}
+
+// WithRevisionCheck makes FS validate metadata cached according to
+// WithMetadataCache by revision of the disk, which changes with every
+// change on the disk. When the cache is consulted more than interval
+// after the last check, the revision is fetched with a single light
+// request and all cached metadata is dropped if it has changed. This
+// lets read-mostly workloads use long cache TTL and still see changes
+// made by others within interval.
+func WithRevisionCheck(interval time.Duration) Option {
+	return func(o *options) {
+		o.revisionCheck = interval
+	}
+}
+
+// cacheSweepSize is the number of cached entries after which
+// expired entries are removed on insertion.
+const cacheSweepSize = 10000
+
+// metaCache keeps metadata of resources by full path.
+type metaCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+
+	checkEvery time.Duration // validate entries by disk revision this often, never if 0
+	checked    time.Time     // time of the last revision check
+	revision   int64         // revision of the disk at the last check
+}
+
+// cacheEntry is cached metadata of a resource.
+type cacheEntry struct {
+	res     Resource  // resource as returned by the API
+	listed  bool      // res holds all directory entries in default order
+	expires time.Time // entry is stale after this time
+}
+
+// newMetaCache returns cache keeping entries for ttl
+// or nil if ttl is not positive.
+func newMetaCache(ttl time.Duration) *metaCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &metaCache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+// get returns cached metadata of the named resource. If listing is
+// set, only metadata holding directory entries is returned. Nil cache
+// has no entries.
+func (mc *metaCache) get(name string, listing bool) (Resource, bool) {
+	if mc == nil {
+		return Resource{}, false
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	e, ok := mc.entries[path.Clean(name)]
+	if !ok || time.Now().After(e.expires) || (listing && !e.listed) {
+		return Resource{}, false
+	}
+	res := e.res
+	// callers normalize returned resources in place
+	if listing {
+		res.Embedded.Items = append([]Resource(nil), res.Embedded.Items...)
+	} else {
+		res.Embedded = ResourceList{}
+	}
+	return res, true
+}
+
+// put caches metadata of the named resource. If listed is set,
+// res holds all its entries which are cached too.
+func (mc *metaCache) put(name string, res Resource, listed bool) {
+	if mc == nil {
+		return
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	now := time.Now()
+	if len(mc.entries) > cacheSweepSize {
+		for k, e := range mc.entries {
+			if now.After(e.expires) {
+				delete(mc.entries, k)
+			}
+		}
+	}
+	name = path.Clean(name)
+	expires := now.Add(mc.ttl)
+	if !listed {
+		// metadata without entries must not replace listing
+		if e, ok := mc.entries[name]; ok && e.listed && now.Before(e.expires) {
+			return
+		}
+		res.Embedded = ResourceList{}
+	}
+	res.Embedded.Items = append([]Resource(nil), res.Embedded.Items...)
+	mc.entries[name] = cacheEntry{res: res, listed: listed, expires: expires}
+	for _, item := range res.Embedded.Items {
+		child := path.Join(name, item.Name)
+		if e, ok := mc.entries[child]; !ok || !e.listed || now.After(e.expires) {
+			mc.entries[child] = cacheEntry{res: item, expires: expires}
+		}
+	}
+}
+
+// needsCheck reports whether it is time to check revision of the disk.
+// The time is reset, so that concurrent callers do not check it too.
+func (mc *metaCache) needsCheck() bool {
+	if mc == nil || mc.checkEvery <= 0 {
+		return false
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	now := time.Now()
+	if now.Sub(mc.checked) < mc.checkEvery {
+		return false
+	}
+	mc.checked = now
+	return true
+}
+
+// setRevision drops all cached metadata if revision of the disk
+// differs from the one seen by the previous check.
+func (mc *metaCache) setRevision(revision int64) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if revision != mc.revision {
+		clear(mc.entries)
+		mc.revision = revision
+	}
+}
+
+// validateCache checks revision of the disk if it is time to and
+// drops cached metadata if anything changed. Failed checks leave cached
+// metadata as is and are retried after the interval.
+func (c *apiclient) validateCache(ctx context.Context) {
+	if !c.cache.needsCheck() {
+		return
+	}
+	if revision, err := c.diskRevision(ctx); err == nil {
+		c.cache.setRevision(revision)
+	}
+}
+
+// invalidate removes cached metadata of the named resource, its
+// children and of the directory containing it.
+func (mc *metaCache) invalidate(name string) {
+	if mc == nil {
+		return
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	name = path.Clean(name)
+	prefix := strings.TrimSuffix(name, "/") + "/"
+	for k := range mc.entries {
+		if k == name || strings.HasPrefix(k, prefix) {
+			delete(mc.entries, k)
+		}
+	}
+	delete(mc.entries, path.Dir(name))
+}
+
+// Prefetch implements FS
+func (y *ydfs) Prefetch(ctx context.Context, root string, depth int) error {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	if y.client.cache == nil {
+		return nil
+	}
+	level := []string{y.fullPath(root)}
+	for d := 0; len(level) > 0 && (depth < 0 || d <= depth); d++ {
+		var next []string
+		for _, dir := range level {
+			res, err := y.client.getResourceListing(ctx, dir, "")
+			if err != nil {
+				return &fs.PathError{Op: "prefetch", Path: root, Err: err}
+			}
+			for _, item := range res.Embedded.Items {
+				if item.IsDir() {
+					next = append(next, path.Join(dir, item.Name))
+				}
+			}
+		}
+		level = next
+	}
+	return nil
+}
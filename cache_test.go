@@ -0,0 +1,98 @@
+package ydfs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMetadataCache(t *testing.T) {
+	fsys, md := newMockFS(t, WithMetadataCache(time.Minute))
+	md.put("/docs/a.txt", []byte("a"))
+	md.put("/docs/b.txt", []byte("b"))
+
+	before := md.requests()
+	if _, err := fsys.ReadDir("/docs"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"/docs", "/docs/a.txt", "/docs/b.txt"} {
+		if _, err := fsys.Stat(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := fsys.ReadDir("/docs"); err != nil {
+		t.Fatal(err)
+	}
+	if n := md.requests() - before; n != 1 {
+		t.Errorf("want 1 request with cached metadata, have %d", n)
+	}
+
+	if err := fsys.WriteFile("/docs/a.txt", []byte("changed")); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := fsys.Stat("/docs/a.txt"); err != nil || info.Size() != 7 {
+		t.Errorf("stale metadata after write: %v %v", info, err)
+	}
+	if err := fsys.Remove("/docs/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := fsys.ReadDir("/docs"); err != nil || len(entries) != 1 {
+		t.Errorf("stale listing after removal: %v %v", entries, err)
+	}
+}
+
+func TestRevisionCheck(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	fsys, md := newMockFS(t, WithMetadataCache(time.Hour), WithRevisionCheck(interval))
+	md.put("/docs/a.txt", []byte("a"))
+
+	if info, err := fsys.Stat("/docs/a.txt"); err != nil || info.Size() != 1 {
+		t.Fatalf("unexpected stat: %v %v", info, err)
+	}
+	// changed by others
+	md.put("/docs/a.txt", []byte("changed"))
+	if info, _ := fsys.Stat("/docs/a.txt"); info.Size() != 1 {
+		t.Error("cached metadata is not used before the next check")
+	}
+	time.Sleep(interval)
+	if info, _ := fsys.Stat("/docs/a.txt"); info.Size() != 7 {
+		t.Error("cached metadata is not dropped after revision has changed")
+	}
+
+	time.Sleep(interval)
+	before := md.requests()
+	if info, _ := fsys.Stat("/docs/a.txt"); info.Size() != 7 {
+		t.Error("unexpected size")
+	}
+	if n := md.requests() - before; n != 1 {
+		t.Errorf("want 1 request checking unchanged revision, have %d", n)
+	}
+}
+
+func TestPrefetch(t *testing.T) {
+	fsys, md := newMockFS(t, WithMetadataCache(time.Minute))
+	md.put("/site/index.html", []byte("i"))
+	md.put("/site/inc/header.html", []byte("h"))
+	md.put("/site/inc/deep/footer.html", []byte("f"))
+	if err := fsys.Prefetch(context.Background(), "/site", 1); err != nil {
+		t.Fatal(err)
+	}
+	before := md.requests()
+	for _, name := range []string{"/site/index.html", "/site/inc", "/site/inc/header.html", "/site/inc/deep"} {
+		if _, err := fsys.Stat(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := fsys.ReadDir("/site/inc"); err != nil {
+		t.Fatal(err)
+	}
+	if n := md.requests() - before; n != 0 {
+		t.Errorf("want prefetched metadata to be cached, %d requests made", n)
+	}
+	if _, err := fsys.ReadDir("/site/inc/deep"); err != nil {
+		t.Fatal(err)
+	}
+	if n := md.requests() - before; n != 1 {
+		t.Errorf("want directory below depth to be fetched, %d requests made", n)
+	}
+}
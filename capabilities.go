@@ -0,0 +1,73 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+)
+
+// Capabilities is effective access granted by the token of FS
+// as found by probing the API (see Capabilities method of FS).
+type Capabilities struct {
+	DiskInfo  bool // disk info (space, user, system folders) is available
+	FullDisk  bool // the whole disk is accessible, not only the app folder
+	AppFolder bool // the app folder is accessible (see NewAppFolder)
+	Read      bool // resources of FS can be listed and read
+	Write     bool // resources of FS can be created, changed and removed
+}
+
+// Capabilities implements FS
+func (y *ydfs) Capabilities(ctx context.Context) (Capabilities, error) {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	var (
+		caps Capabilities
+		err  error
+	)
+	if caps.DiskInfo, err = probe(y.client.ping(ctx)); err != nil {
+		return caps, err
+	}
+	if caps.FullDisk, err = probe(y.client.probeResource(ctx, "disk:/")); err != nil {
+		return caps, err
+	}
+	if caps.AppFolder, err = probe(y.client.probeResource(ctx, "app:/")); err != nil {
+		return caps, err
+	}
+	root := y.client.apiPath(y.fullPath("/"))
+	if caps.Read, err = probe(y.client.probeResource(ctx, root)); err != nil {
+		return caps, err
+	}
+	// upload link does not create anything, but is only given
+	// to tokens allowed to write
+	name := path.Join(y.fullPath("/"), ".ydfs-probe-"+strconv.FormatInt(time.Now().UnixNano(), 36))
+	_, err = y.client.getUploadLink(ctx, y.client.apiPath(name), false)
+	if caps.Write, err = probe(err); err != nil {
+		return caps, err
+	}
+	return caps, nil
+}
+
+// probeResource fetches minimal metadata of resource by path sent
+// to the API as is.
+func (c *apiclient) probeResource(ctx context.Context, apiPath string) error {
+	u, _ := url.Parse(urlResources)
+	u.RawQuery = url.Values{"path": {apiPath}, "fields": {"path"}}.Encode()
+	var res Resource
+	return c.requestInterface(ctx, http.MethodGet, http.StatusOK, u.String(), nil, &res)
+}
+
+// probe converts result of probing request to capability: denied
+// access means the capability is missing, other errors are returned.
+func probe(err error) (bool, error) {
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrUnauthorized), errors.Is(err, ErrNotFound):
+		return false, nil
+	}
+	return false, err
+}
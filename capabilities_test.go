@@ -0,0 +1,58 @@
+package ydfs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put(mockAppRoot+"/a.txt", []byte("a"))
+	requests := md.requests()
+
+	caps, err := fsys.Capabilities(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Capabilities{DiskInfo: true, FullDisk: true, AppFolder: true, Read: true, Write: true}); caps != want {
+		t.Errorf("full access token has capabilities %+v", caps)
+	}
+
+	md.setScope("read")
+	caps, err = fsys.Capabilities(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !caps.Read || caps.Write || !caps.FullDisk {
+		t.Errorf("read-only token has capabilities %+v", caps)
+	}
+
+	md.setScope("app")
+	caps, err = fsys.Capabilities(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.FullDisk || !caps.AppFolder || caps.Read || caps.Write || !caps.DiskInfo {
+		t.Errorf("app folder token has capabilities %+v for disk FS", caps)
+	}
+	app, err := NewAppFolder("mocktoken", newMockClient(t, md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	caps, err = app.Capabilities(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !caps.Read || !caps.Write {
+		t.Errorf("app folder token has capabilities %+v for app folder FS", caps)
+	}
+	if md.requests() == requests {
+		t.Error("capabilities are not probed")
+	}
+	md.mu.Lock()
+	n := len(md.entries)
+	md.mu.Unlock()
+	if n != 4 {
+		t.Errorf("probing changes the disk: %d entries, want 4", n)
+	}
+}
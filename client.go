@@ -0,0 +1,52 @@
+package ydfs
+
+import (
+	"context"
+	"net/http"
+	"path"
+)
+
+// Client is an authenticated connection to the API. It holds the
+// options it was created with, including rate limiter and caches, and
+// can be shared by any number of FS created with NewFromClient.
+type Client struct {
+	api  *apiclient
+	opts *options
+}
+
+// NewClient returns Client using token and client to send requests.
+// If client is nil then a client with transport tuned for parallel
+// transfers is used and metadata requests time out after 30 seconds
+// (see WithMetadataTimeout), as with New. Unless WithLazyInit is given
+// the token is validated with a request to the API as New does.
+func NewClient(token string, client *http.Client, opts ...Option) (*Client, error) {
+	return newClientContext(context.TODO(), token, client, opts...)
+}
+
+// newClientContext is NewClient validating the token with ctx.
+func newClientContext(ctx context.Context, token string, client *http.Client, opts ...Option) (*Client, error) {
+	o := newOptions(opts...)
+	c, err := o.newClient(token, client)
+	if err != nil {
+		return nil, err
+	}
+	// checking whether we can fetch disk metadata to
+	// make sure that token is valid and we we can send
+	// requests to the API.
+	c.init = c.ping
+	if !o.lazyInit {
+		if err := c.validate(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return &Client{api: c, opts: o}, nil
+}
+
+// NewFromClient returns FS rooted at root directory of the disk which
+// sends requests with c. Like os.DirFS it makes no requests, so it is
+// cheap to create, and missing root is only reported by operations
+// on the returned FS.
+func NewFromClient(c *Client, root string) FS {
+	root = path.Clean("/" + root)
+	return &ydfs{client: c.api, opts: c.opts, path: root, issub: root != "/"}
+}
@@ -0,0 +1,33 @@
+package ydfs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewFromClient(t *testing.T) {
+	md := newMockDisk()
+	md.put("/a/x.txt", []byte("x"))
+	md.put("/b/y.txt", []byte("y"))
+	c, err := NewClient("mocktoken", newMockClient(t, md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := md.requests()
+	a, b := NewFromClient(c, "/a"), NewFromClient(c, "b/")
+	if n := md.requests(); n != before {
+		t.Errorf("NewFromClient sent %d requests", n-before)
+	}
+	if data, err := a.ReadFile("x.txt"); err != nil || string(data) != "x" {
+		t.Errorf("unexpected contents via /a: %q %v", data, err)
+	}
+	if data, err := b.ReadFile("/y.txt"); err != nil || string(data) != "y" {
+		t.Errorf("unexpected contents via /b: %q %v", data, err)
+	}
+	if _, err := NewFromClient(c, "/missing").Stat("."); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for missing root, have %v", err)
+	}
+	if _, err := NewClient("badtoken", newMockClient(t, md)); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("want ErrUnauthorized for bad token, have %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+// Package basicauth provides HTTP basic authentication shared by
+// the commands serving the disk.
+package basicauth
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// Handler requires requests to h to be authenticated with user and password.
+func Handler(h http.Handler, user, password string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(u), []byte(user)) != 1 || subtle.ConstantTimeCompare([]byte(p), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="ydfs", charset="UTF-8"`)
+			http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
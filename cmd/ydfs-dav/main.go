@@ -0,0 +1,65 @@
+// Command ydfs-dav serves a directory of Yandex Disk over WebDAV,
+// so that it can be mounted by file managers and operating systems.
+//
+// Usage:
+//
+//	ydfs-dav [-addr :8080] [-dir /] [-user name -password secret]
+//
+// OAuth token is read from YD environment variable. Basic
+// authentication is required if -user is set, password may be given
+// in YD_PASSWORD environment variable instead of the flag. Locks are
+// kept in memory of the process.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/dmfed/ydfs"
+	"github.com/dmfed/ydfs/cmd/internal/basicauth"
+	"github.com/dmfed/ydfs/davfs"
+	"golang.org/x/net/webdav"
+)
+
+func main() {
+	var (
+		addr     = flag.String("addr", ":8080", "address to listen on")
+		dir      = flag.String("dir", "/", "directory of the disk to serve")
+		user     = flag.String("user", "", "user name for basic authentication, no authentication if empty")
+		password = flag.String("password", "", "password for basic authentication, YD_PASSWORD environment variable if empty")
+	)
+	flag.Parse()
+	// the password is not the default of the flag, which usage prints
+	if *password == "" {
+		*password = os.Getenv("YD_PASSWORD")
+	}
+	token, ok := os.LookupEnv("YD")
+	if !ok {
+		log.Fatal("environment variable YD with OAuth token is not set")
+	}
+	fsys, err := ydfs.New(token, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *dir != "/" {
+		if fsys, err = fsys.Sub(*dir); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var h http.Handler = &webdav.Handler{
+		FileSystem: davfs.New(fsys),
+		LockSystem: webdav.NewMemLS(),
+		Logger: func(r *http.Request, err error) {
+			if err != nil {
+				log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+	if *user != "" {
+		h = basicauth.Handler(h, *user, *password)
+	}
+	log.Printf("serving %s over WebDAV on %s", *dir, *addr)
+	log.Fatal(http.ListenAndServe(*addr, h))
+}
@@ -0,0 +1,93 @@
+// Command ydfs-serve serves a directory of Yandex Disk over HTTP
+// read-only, e.g. to host a simple static website.
+//
+// Usage:
+//
+//	ydfs-serve [-addr :8080] [-dir /site] [-index=false] [-user name -password secret]
+//
+// OAuth token is read from YD environment variable. Directories are
+// served with index.html if there is one, otherwise with generated
+// listing unless -index=false is given. Basic authentication is
+// required if -user is set, password may be given in YD_PASSWORD
+// environment variable instead of the flag. Files are sent as
+// attachments if requested with ?download and preview images of photos
+// and videos are sent instead of contents if requested with ?preview=M
+// (or other size, see ydfs.WithPreviewSize).
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/dmfed/ydfs"
+	"github.com/dmfed/ydfs/cmd/internal/basicauth"
+)
+
+func main() {
+	var (
+		addr     = flag.String("addr", ":8080", "address to listen on")
+		dir      = flag.String("dir", "/", "directory of the disk to serve")
+		index    = flag.Bool("index", true, "generate listings of directories without index.html")
+		user     = flag.String("user", "", "user name for basic authentication, no authentication if empty")
+		password = flag.String("password", "", "password for basic authentication, YD_PASSWORD environment variable if empty")
+	)
+	flag.Parse()
+	// the password is not the default of the flag, which usage prints
+	if *password == "" {
+		*password = os.Getenv("YD_PASSWORD")
+	}
+	token, ok := os.LookupEnv("YD")
+	if !ok {
+		log.Fatal("environment variable YD with OAuth token is not set")
+	}
+	fsys, err := ydfs.New(token, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *dir != "/" {
+		if fsys, err = fsys.Sub(*dir); err != nil {
+			log.Fatal(err)
+		}
+	}
+	files := ydfs.FileServer(fsys,
+		ydfs.WithAttachment(func(r *http.Request) bool { return r.URL.Query().Has("download") }),
+		ydfs.WithPreview(func(r *http.Request) string { return r.URL.Query().Get("preview") }),
+	)
+	var h http.Handler = &siteHandler{fsys: fsys, files: files, index: *index}
+	if *user != "" {
+		h = basicauth.Handler(h, *user, *password)
+	}
+	log.Printf("serving %s on %s", *dir, *addr)
+	log.Fatal(http.ListenAndServe(*addr, h))
+}
+
+// siteHandler serves index.html of directories and optionally
+// generated listings of directories without one.
+type siteHandler struct {
+	fsys  ydfs.FS
+	files http.Handler
+	index bool
+}
+
+func (h *siteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		h.files.ServeHTTP(w, r)
+		return
+	}
+	name := path.Join(path.Clean("/"+r.URL.Path), "index.html")
+	if info, err := h.fsys.Stat(name); err == nil && !info.IsDir() {
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = name
+		h.files.ServeHTTP(w, r2)
+		return
+	}
+	if !h.index {
+		http.Error(w, "403 Forbidden", http.StatusForbidden)
+		return
+	}
+	h.files.ServeHTTP(w, r)
+}
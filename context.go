@@ -0,0 +1,32 @@
+package ydfs
+
+import "context"
+
+// WithContext implements FS
+func (y *ydfs) WithContext(ctx context.Context) FS {
+	derived := *y
+	derived.ctx = ctx
+	return &derived
+}
+
+// context returns base context of requests made by y.
+func (y *ydfs) context() context.Context {
+	if y.ctx != nil {
+		return y.ctx
+	}
+	return context.Background()
+}
+
+// bind returns ctx which is also cancelled when base context of y is
+// done. The returned function releases resources and must be called.
+func (y *ydfs) bind(ctx context.Context) (context.Context, context.CancelFunc) {
+	if y.ctx == nil {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(y.ctx, func() { cancel(context.Cause(y.ctx)) })
+	return ctx, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
@@ -0,0 +1,33 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithContext(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	ctx, cancel := context.WithCancel(context.Background())
+	bound := fsys.WithContext(ctx)
+	file, err := bound.Open("/docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	cancel()
+
+	if _, err := bound.Stat("/docs/a.txt"); !errors.Is(err, context.Canceled) || IsTemporary(err) {
+		t.Errorf("want permanent context.Canceled from Stat, have %v", err)
+	}
+	if _, err := file.Read(make([]byte, 1)); !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled reading opened file, have %v", err)
+	}
+	if err := bound.Ping(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled from Ping, have %v", err)
+	}
+	if _, err := fsys.Stat("/docs/a.txt"); err != nil {
+		t.Errorf("cancelling derived FS affected the original one: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package ydfs
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// defaultBackoffFactor limits delay between retries of a failing job to
+// this many intervals unless SyncJob.MaxBackoff is set.
+const defaultBackoffFactor = 16
+
+// SyncJob is a sync run periodically by SyncDaemon.
+type SyncJob struct {
+	Syncer   *Syncer
+	Interval time.Duration // delay between runs, must be positive
+	Jitter   time.Duration // random delay up to Jitter added to every delay
+
+	// MaxBackoff limits delay between runs after failures. The delay is
+	// doubled with every consecutive failure starting with Interval.
+	// Zero means 16 intervals.
+	MaxBackoff time.Duration
+
+	// OnResult, if not nil, is called with results of every run.
+	OnResult func(report *SyncReport, err error)
+}
+
+// delay returns time to wait before the next run after failures
+// consecutive failed runs.
+func (j *SyncJob) delay(failures int) time.Duration {
+	d := j.Interval
+	limit := j.MaxBackoff
+	if limit <= 0 {
+		limit = defaultBackoffFactor * j.Interval
+	}
+	for i := 0; i < failures && d < limit; i++ {
+		d *= 2
+	}
+	d = min(d, max(limit, j.Interval))
+	if j.Jitter > 0 {
+		d += rand.N(j.Jitter)
+	}
+	return d
+}
+
+// SyncDaemon runs sync jobs in background, each job in its own
+// goroutine, until it is stopped.
+type SyncDaemon struct {
+	jobs   []SyncJob
+	mu     sync.Mutex
+	cancel context.CancelFunc // nil unless running
+	wg     sync.WaitGroup
+}
+
+// NewSyncDaemon returns SyncDaemon running jobs. It does nothing until
+// started. Jobs with non-positive interval are rejected as they would
+// run the sync in a busy loop.
+func NewSyncDaemon(jobs ...SyncJob) (*SyncDaemon, error) {
+	for i, j := range jobs {
+		if j.Interval <= 0 {
+			return nil, fmt.Errorf("%w: job %d: interval %v is not positive", fs.ErrInvalid, i, j.Interval)
+		}
+	}
+	return &SyncDaemon{jobs: jobs}, nil
+}
+
+// Start starts jobs. Every job runs after random delay up to its jitter
+// and then after its interval passes since the end of the previous run.
+// Jobs are stopped by Stop or when ctx is done. Start does nothing if
+// the daemon is already running.
+func (d *SyncDaemon) Start(ctx context.Context) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		return
+	}
+	ctx, d.cancel = context.WithCancel(ctx)
+	for i := range d.jobs {
+		d.wg.Add(1)
+		go func(job *SyncJob) {
+			defer d.wg.Done()
+			d.run(ctx, job)
+		}(&d.jobs[i])
+	}
+}
+
+// Stop cancels running syncs and waits for jobs to stop. The daemon
+// may be started again.
+func (d *SyncDaemon) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel == nil {
+		return
+	}
+	d.cancel()
+	d.wg.Wait()
+	d.cancel = nil
+}
+
+// run runs job until ctx is done.
+func (d *SyncDaemon) run(ctx context.Context, job *SyncJob) {
+	var wait time.Duration
+	if job.Jitter > 0 {
+		wait = rand.N(job.Jitter)
+	}
+	failures := 0
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		report, err := job.Syncer.Run(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if job.OnResult != nil {
+			job.OnResult(report, err)
+		}
+		if err != nil {
+			failures++
+		} else {
+			failures = 0
+		}
+		wait = job.delay(failures)
+	}
+}
@@ -0,0 +1,89 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSyncJobDelay(t *testing.T) {
+	job := SyncJob{Interval: time.Second, MaxBackoff: 5 * time.Second}
+	for failures, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if d := job.delay(failures); d != want {
+			t.Errorf("%d failures: want %v, have %v", failures, want, d)
+		}
+	}
+	job = SyncJob{Interval: time.Second}
+	if d := job.delay(100); d != defaultBackoffFactor*time.Second {
+		t.Errorf("want default backoff limit, have %v", d)
+	}
+	job.Jitter = time.Second
+	for i := 0; i < 10; i++ {
+		if d := job.delay(0); d < time.Second || d >= 2*time.Second {
+			t.Errorf("delay %v is out of jitter range", d)
+		}
+	}
+}
+
+func TestSyncDaemon(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/backup/a.txt", []byte("a"))
+	local := t.TempDir()
+
+	var (
+		mu   sync.Mutex
+		runs int
+		errs int
+		done = make(chan struct{})
+	)
+	d, err := NewSyncDaemon(SyncJob{
+		Syncer:   fsys.Syncer("/backup", local, SyncDown),
+		Interval: time.Millisecond,
+		Jitter:   time.Millisecond,
+		OnResult: func(report *SyncReport, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if runs++; runs == 3 {
+				close(done)
+			}
+			if err != nil {
+				errs++
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Start(context.Background())
+	d.Start(context.Background())
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("jobs do not run")
+	}
+	d.Stop()
+	mu.Lock()
+	stopped := runs
+	mu.Unlock()
+	if errs != 0 {
+		t.Errorf("%d runs failed", errs)
+	}
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if runs != stopped {
+		t.Error("jobs run after Stop")
+	}
+	d.Stop()
+}
+
+func TestSyncDaemonInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if _, err := NewSyncDaemon(SyncJob{Interval: time.Second}, SyncJob{Interval: interval}); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("interval %v: want fs.ErrInvalid, have %v", interval, err)
+		}
+	}
+}
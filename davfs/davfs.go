@@ -0,0 +1,130 @@
+// Package davfs adapts ydfs.FS to webdav.FileSystem, so that the disk
+// can be served over WebDAV. It is kept apart from package ydfs, so
+// that users of the latter do not depend on golang.org/x/net.
+package davfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/dmfed/ydfs"
+	"golang.org/x/net/webdav"
+)
+
+// New returns webdav.FileSystem backed by fsys, so that the disk can
+// be served with webdav.Handler. Contents of files are kept in memory
+// while they are open (see ydfs.File).
+func New(fsys ydfs.FS) webdav.FileSystem {
+	return &davFS{fsys: fsys}
+}
+
+// davFS implements webdav.FileSystem
+type davFS struct {
+	fsys ydfs.FS
+}
+
+// Mkdir implements webdav.FileSystem
+func (d *davFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	return davError(d.fsys.Mkdir(name))
+}
+
+// OpenFile implements webdav.FileSystem
+func (d *davFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	file, err := d.fsys.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, davError(err)
+	}
+	return &davFile{File: file}, nil
+}
+
+// RemoveAll implements webdav.FileSystem
+func (d *davFS) RemoveAll(ctx context.Context, name string) error {
+	return davError(d.fsys.RemoveAll(name))
+}
+
+// Rename implements webdav.FileSystem
+func (d *davFS) Rename(ctx context.Context, oldName, newName string) error {
+	return davError(d.fsys.Rename(oldName, newName))
+}
+
+// Stat implements webdav.FileSystem
+func (d *davFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	info, err := d.fsys.Stat(name)
+	if err != nil {
+		return nil, davError(err)
+	}
+	return etagInfo{info}, nil
+}
+
+// davFile implements webdav.File
+type davFile struct {
+	ydfs.File
+}
+
+// Readdir implements http.File
+func (f *davFile) Readdir(count int) ([]fs.FileInfo, error) {
+	rd, ok := f.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Err: ydfs.ErrNotDir}
+	}
+	entries, err := rd.ReadDir(count)
+	infos := make([]fs.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		info, ierr := e.Info()
+		if ierr != nil {
+			return infos, davError(ierr)
+		}
+		infos = append(infos, etagInfo{info})
+	}
+	return infos, davError(err)
+}
+
+// Stat implements http.File
+func (f *davFile) Stat() (fs.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, davError(err)
+	}
+	return etagInfo{info}, nil
+}
+
+// etagInfo adds entity tags of ydfs.FS to fs.FileInfo.
+type etagInfo struct {
+	fs.FileInfo
+}
+
+// ETag implements webdav.ETager. Tags are derived from MD5 or revision
+// of files if FS is created with ydfs.WithFields requesting them,
+// otherwise webdav derives tags from modification time and size.
+func (b etagInfo) ETag(ctx context.Context) (string, error) {
+	if etag := ydfs.ETag(b.FileInfo); etag != "" {
+		return etag, nil
+	}
+	return "", webdav.ErrNotImplemented
+}
+
+// davError translates errors of ydfs.FS to the errors of package os
+// which webdav.Handler maps to HTTP statuses.
+func davError(err error) error {
+	var target error
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, fs.ErrNotExist):
+		target = fs.ErrNotExist
+	case errors.Is(err, fs.ErrExist):
+		target = fs.ErrExist
+	case errors.Is(err, fs.ErrPermission):
+		target = fs.ErrPermission
+	default:
+		return err
+	}
+	pe := &fs.PathError{Op: "webdav", Err: target}
+	var orig *fs.PathError
+	if errors.As(err, &orig) {
+		pe.Op, pe.Path = orig.Op, orig.Path
+	}
+	return pe
+}
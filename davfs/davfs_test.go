@@ -0,0 +1,152 @@
+package davfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dmfed/ydfs"
+	"golang.org/x/net/webdav"
+)
+
+// dirFS is ydfs.FS backed by a local directory implementing the
+// methods used by davFS. Other methods panic.
+type dirFS struct {
+	ydfs.FS
+	root string
+}
+
+func newDirFS(t *testing.T) *dirFS {
+	return &dirFS{root: t.TempDir()}
+}
+
+func (d *dirFS) local(name string) string {
+	return filepath.Join(d.root, filepath.FromSlash(path.Clean("/"+name)))
+}
+
+func (d *dirFS) Mkdir(name string) error {
+	return os.Mkdir(d.local(name), 0o755)
+}
+
+func (d *dirFS) OpenFile(name string, flag int, perm fs.FileMode) (ydfs.File, error) {
+	f, err := os.OpenFile(d.local(name), flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return osFile{f}, nil
+}
+
+func (d *dirFS) RemoveAll(name string) error {
+	if _, err := os.Stat(d.local(name)); err != nil {
+		return err
+	}
+	return os.RemoveAll(d.local(name))
+}
+
+func (d *dirFS) Rename(oldname, newname string) error {
+	return os.Rename(d.local(oldname), d.local(newname))
+}
+
+func (d *dirFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(d.local(name))
+}
+
+// osFile implements ydfs.File
+type osFile struct {
+	*os.File
+}
+
+func (osFile) Refresh() error {
+	return nil
+}
+
+func TestDavFS(t *testing.T) {
+	fsys := newDirFS(t)
+	if err := os.MkdirAll(filepath.Join(fsys.root, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(fsys.root, "docs", "a.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(&webdav.Handler{FileSystem: New(fsys), LockSystem: webdav.NewMemLS()})
+	defer srv.Close()
+
+	do := func(method, p string, body string, header ...string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+p, strings.NewReader(body))
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	if code, body := do(http.MethodGet, "/docs/a.txt", ""); code != http.StatusOK || body != "hello" {
+		t.Errorf("GET: %d %q", code, body)
+	}
+	if code, _ := do("MKCOL", "/new", ""); code != http.StatusCreated {
+		t.Errorf("MKCOL: %d", code)
+	}
+	if code, _ := do(http.MethodPut, "/new/b.txt", "world"); code != http.StatusCreated {
+		t.Errorf("PUT: %d", code)
+	}
+	if data, err := os.ReadFile(filepath.Join(fsys.root, "new", "b.txt")); err != nil || string(data) != "world" {
+		t.Error("PUT did not upload file")
+	}
+	if code, _ := do("MOVE", "/new/b.txt", "", "Destination", srv.URL+"/docs/b.txt"); code != http.StatusCreated {
+		t.Errorf("MOVE: %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(fsys.root, "new", "b.txt")); err == nil {
+		t.Error("MOVE left source in place")
+	}
+	code, body := do("PROPFIND", "/docs/", "", "Depth", "1")
+	if code != http.StatusMultiStatus || !strings.Contains(body, "/docs/a.txt") || !strings.Contains(body, "/docs/b.txt") {
+		t.Errorf("PROPFIND: %d %s", code, body)
+	}
+	if code, _ := do(http.MethodDelete, "/docs/a.txt", ""); code != http.StatusNoContent {
+		t.Errorf("DELETE: %d", code)
+	}
+	if code, _ := do(http.MethodGet, "/docs/a.txt", ""); code != http.StatusNotFound {
+		t.Errorf("GET of deleted file: %d", code)
+	}
+}
+
+func TestDavFSETag(t *testing.T) {
+	fsys := newDirFS(t)
+	info, err := New(fsys).Stat(context.Background(), "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := info.(webdav.ETager).ETag(context.Background()); err != webdav.ErrNotImplemented {
+		t.Errorf("want webdav.ErrNotImplemented without MD5 and revision, have %v", err)
+	}
+}
+
+func TestDavError(t *testing.T) {
+	for _, tc := range []struct {
+		err, want error
+	}{
+		{&fs.PathError{Op: "open", Path: "/a", Err: ydfs.ErrNotFound}, fs.ErrNotExist},
+		{&fs.PathError{Op: "write", Path: "/a", Err: ydfs.ErrForbidden}, fs.ErrPermission},
+		{&fs.PathError{Op: "write", Path: "/a", Err: fs.ErrExist}, fs.ErrExist},
+	} {
+		err := davError(tc.err)
+		var pe *fs.PathError
+		if !errors.As(err, &pe) || pe.Err != tc.want || pe.Path != "/a" {
+			t.Errorf("davError(%v) = %v, want %v", tc.err, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package ydfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// minRangeSize is the smallest part of a file worth fetching
+// in a separate request.
+const minRangeSize = 1 << 20
+
+// DownloadFile implements FS
+func (y *ydfs) DownloadFile(ctx context.Context, name string, w io.WriterAt, parallel int) error {
+	ctx, stop := y.bind(ctx)
+	defer stop()
+	fullname := y.fullPath(name)
+	res, err := y.client.getResourceMinTraffic(ctx, fullname)
+	if err != nil {
+		return &fs.PathError{Op: "download", Path: name, Err: err}
+	}
+	if res.IsDir() {
+		return &fs.PathError{Op: "download", Path: name, Err: ErrIsDir}
+	}
+	l, err := y.client.getDownloadLink(ctx, fullname)
+	if err != nil {
+		return &fs.PathError{Op: "download", Path: name, Err: err}
+	}
+	if res.Size == 0 {
+		return nil
+	}
+	// no point in splitting small files into many parts
+	if max := int((res.Size + minRangeSize - 1) / minRangeSize); parallel > max {
+		parallel = max
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+	partSize := (res.Size + int64(parallel) - 1) / int64(parallel)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for offset := int64(0); offset < res.Size; offset += partSize {
+		length := partSize
+		if offset+length > res.Size {
+			length = res.Size - offset
+		}
+		wg.Add(1)
+		go func(offset, length int64) {
+			defer wg.Done()
+			if err := y.downloadRange(ctx, l, w, offset, length); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(offset, length)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return &fs.PathError{Op: "download", Path: name, Err: firstErr}
+	}
+	return nil
+}
+
+// localDownloadParallel is the number of ranges fetched concurrently when
+// files are downloaded to local disk.
+const localDownloadParallel = 4
+
+// mtimeProperty is the custom property holding modification time of
+// a file set by uploader (e.g. time of the local file the contents
+// came from) in RFC 3339 format. It takes precedence over the time the
+// file was modified on the disk when files are downloaded.
+const mtimeProperty = "ydfs_mtime"
+
+// downloadFields are fields of resource needed to download it to local
+// file.
+var downloadFields = []string{"type", "size", "md5", "modified", "custom_properties"}
+
+// modTime returns modification time of the file described by res to be
+// set on local copies.
+func modTime(res Resource) time.Time {
+	if t, err := time.Parse(time.RFC3339Nano, res.CustomProperties[mtimeProperty]); err == nil {
+		return t
+	}
+	return res.Modified
+}
+
+// setModTime sets modification time of localPath to modification time
+// of the remote file unless it is unknown.
+func setModTime(localPath string, res Resource) error {
+	t := modTime(res)
+	if t.IsZero() {
+		return nil
+	}
+	return os.Chtimes(localPath, time.Time{}, t)
+}
+
+// DownloadIfChanged implements FS
+func (y *ydfs) DownloadIfChanged(ctx context.Context, name, localPath string) (bool, error) {
+	ctx, stop := y.bind(ctx)
+	defer stop()
+	res, err := y.client.getResource(ctx, y.fullPath(name), 0, downloadFields...)
+	if err != nil {
+		return false, &fs.PathError{Op: "download", Path: name, Err: err}
+	}
+	if res.IsDir() {
+		return false, &fs.PathError{Op: "download", Path: name, Err: ErrIsDir}
+	}
+	if info, err := os.Stat(localPath); err == nil && info.Mode().IsRegular() && info.Size() == res.Size && res.MD5 != "" {
+		if sum, err := md5File(localPath); err == nil && sum == res.MD5 {
+			// timestamps of the same contents are aligned, so that
+			// tools comparing them do not copy the file again
+			if t := modTime(res); !t.IsZero() && !info.ModTime().Equal(t) {
+				if err := setModTime(localPath, res); err != nil {
+					return false, err
+				}
+			}
+			return false, nil
+		}
+	}
+	if err := y.downloadTo(ctx, name, localPath, res); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// downloadTo downloads the named file described by res next to
+// localPath and replaces localPath with it when done, so that a failed
+// transfer does not leave the local file truncated.
+func (y *ydfs) downloadTo(ctx context.Context, name, localPath string, res Resource) error {
+	tmp, err := os.CreateTemp(filepath.Dir(localPath), "."+filepath.Base(localPath)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	err = y.DownloadFile(ctx, name, tmp, localDownloadParallel)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), y.opts.fileMode)
+	}
+	if err == nil {
+		err = setModTime(tmp.Name(), res)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), localPath)
+	}
+	return err
+}
+
+// resumeCheckSize is the size of the end of partially downloaded file
+// compared with the remote file before the download is resumed.
+const resumeCheckSize = 64 << 10
+
+// DownloadToFile implements FS
+func (y *ydfs) DownloadToFile(ctx context.Context, name, localPath string, resume bool) error {
+	ctx, stop := y.bind(ctx)
+	defer stop()
+	res, err := y.client.getResource(ctx, y.fullPath(name), 0, downloadFields...)
+	if err != nil {
+		return &fs.PathError{Op: "download", Path: name, Err: err}
+	}
+	if res.IsDir() {
+		return &fs.PathError{Op: "download", Path: name, Err: ErrIsDir}
+	}
+	if !resume {
+		return y.downloadTo(ctx, name, localPath, res)
+	}
+	err = y.resumeDownload(ctx, name, localPath, res)
+	if errors.Is(err, ErrChecksumMismatch) {
+		// the local file was corrupted before the part checked
+		return y.downloadTo(ctx, name, localPath, res)
+	}
+	return err
+}
+
+// resumeDownload downloads the rest of the named file described by res
+// appending it to localPath. The end of the local file is compared with the remote file
+// first and the download starts over if they differ. The whole file is
+// checked against MD5 of the remote file when done.
+func (y *ydfs) resumeDownload(ctx context.Context, name, localPath string, res Resource) error {
+	fullname := y.fullPath(name)
+	f, err := os.OpenFile(localPath, os.O_RDWR|os.O_CREATE, y.opts.fileMode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	l, err := y.client.getDownloadLink(ctx, fullname)
+	if err != nil {
+		return &fs.PathError{Op: "download", Path: name, Err: err}
+	}
+	offset := info.Size()
+	if offset > res.Size {
+		offset = 0
+	} else if offset > 0 {
+		same, err := y.sameTail(ctx, l, f, offset)
+		if err != nil {
+			return &fs.PathError{Op: "download", Path: name, Err: err}
+		}
+		if !same {
+			offset = 0
+		}
+	}
+	if err := f.Truncate(offset); err != nil {
+		return err
+	}
+	if offset < res.Size {
+		if err := y.downloadRange(ctx, l, f, offset, res.Size-offset); err != nil {
+			return &fs.PathError{Op: "download", Path: name, Err: err}
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if res.MD5 != "" {
+		if sum, err := md5File(localPath); err != nil {
+			return err
+		} else if sum != res.MD5 {
+			return &fs.PathError{Op: "download", Path: name, Err: ErrChecksumMismatch}
+		}
+	}
+	return setModTime(localPath, res)
+}
+
+// sameTail reports whether the last bytes of local file of the given
+// size are the same as bytes of the remote file at the same offset.
+func (y *ydfs) sameTail(ctx context.Context, l link, f *os.File, size int64) (bool, error) {
+	n := min(size, resumeCheckSize)
+	local := make([]byte, n)
+	if _, err := f.ReadAt(local, size-n); err != nil {
+		return false, err
+	}
+	body, err := y.client.getFileRange(ctx, l, size-n, n)
+	if err != nil {
+		return false, err
+	}
+	defer body.Close()
+	remote, err := io.ReadAll(io.LimitReader(body, n))
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	return bytes.Equal(local, remote), nil
+}
+
+// downloadRange fetches a part of file and writes it to w at offset.
+func (y *ydfs) downloadRange(ctx context.Context, l link, w io.WriterAt, offset, length int64) error {
+	body, err := y.client.getFileRange(ctx, l, offset, length)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	n, err := io.Copy(io.NewOffsetWriter(w, offset), io.LimitReader(body, length))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	if n != length {
+		return fmt.Errorf("%w: got %d bytes of range at offset %d, want %d", ErrNetwork, n, offset, length)
+	}
+	return nil
+}
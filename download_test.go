@@ -0,0 +1,207 @@
+package ydfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dmfed/ydfs/ydfstest"
+)
+
+// memWriterAt is io.WriterAt backed by a byte slice.
+type memWriterAt struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (m *memWriterAt) WriteAt(b []byte, off int64) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if end := int(off) + len(b); end > len(m.buf) {
+		m.buf = append(m.buf, make([]byte, end-len(m.buf))...)
+	}
+	return copy(m.buf[off:], b), nil
+}
+
+func TestDownloadFile(t *testing.T) {
+	fsys, md := newMockFS(t)
+	body := make([]byte, 3*minRangeSize+123)
+	for i := range body {
+		body[i] = byte(i % 251)
+	}
+	md.put("/big.bin", body)
+	for _, parallel := range []int{0, 1, 4, 100} {
+		var w memWriterAt
+		if err := fsys.DownloadFile(context.Background(), "/big.bin", &w, parallel); err != nil {
+			t.Fatalf("parallel %d: %v", parallel, err)
+		}
+		if !bytes.Equal(w.buf, body) {
+			t.Errorf("parallel %d: downloaded data differs", parallel)
+		}
+	}
+	if err := fsys.DownloadFile(context.Background(), "/", &memWriterAt{}, 2); err == nil {
+		t.Error("DownloadFile of a directory succeeds")
+	}
+}
+
+func TestDownloadIfChanged(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/a.txt", []byte("remote"))
+	local := filepath.Join(t.TempDir(), "a.txt")
+
+	for i, want := range []bool{true, false} {
+		downloaded, err := fsys.DownloadIfChanged(context.Background(), "/a.txt", local)
+		if err != nil || downloaded != want {
+			t.Fatalf("call %d: want %v, have %v %v", i, want, downloaded, err)
+		}
+	}
+	if data, _ := os.ReadFile(local); string(data) != "remote" {
+		t.Errorf("unexpected local contents %q", data)
+	}
+
+	// same size, different contents
+	os.WriteFile(local, []byte("local!"), 0o644)
+	if downloaded, err := fsys.DownloadIfChanged(context.Background(), "a.txt", local); err != nil || !downloaded {
+		t.Errorf("changed file is not downloaded: %v", err)
+	}
+	if data, _ := os.ReadFile(local); string(data) != "remote" {
+		t.Errorf("unexpected local contents %q", data)
+	}
+
+	os.WriteFile(local, []byte("keep"), 0o644)
+	if _, err := fsys.DownloadIfChanged(context.Background(), "/missing", local); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound, have %v", err)
+	}
+	if data, _ := os.ReadFile(local); string(data) != "keep" {
+		t.Errorf("failed download changes local file: %q", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(local)); len(entries) != 1 {
+		t.Errorf("temporary files are left: %v", entries)
+	}
+}
+
+func TestDownloadToFileResume(t *testing.T) {
+	fsys, md := newMockFS(t)
+	body := make([]byte, 3*resumeCheckSize)
+	for i := range body {
+		body[i] = byte(i % 253)
+	}
+	md.put("/big.bin", body)
+	local := filepath.Join(t.TempDir(), "big.bin")
+
+	corrupt := func(data []byte, at int) []byte {
+		data = bytes.Clone(data)
+		data[at] ^= 0xff
+		return data
+	}
+	for name, partial := range map[string][]byte{
+		"prefix":          body[:2*resumeCheckSize+100],
+		"short prefix":    body[:10],
+		"complete":        body,
+		"corrupted tail":  corrupt(body[:2*resumeCheckSize], 2*resumeCheckSize-1),
+		"corrupted start": corrupt(body[:2*resumeCheckSize], 0),
+		"longer":          append(bytes.Clone(body), 1, 2, 3),
+	} {
+		if err := os.WriteFile(local, partial, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := fsys.DownloadToFile(context.Background(), "/big.bin", local, true); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if data, _ := os.ReadFile(local); !bytes.Equal(data, body) {
+			t.Errorf("%s: downloaded data differs", name)
+		}
+	}
+
+	os.Remove(local)
+	for _, resume := range []bool{true, false} {
+		if err := fsys.DownloadToFile(context.Background(), "/big.bin", local, resume); err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := os.ReadFile(local); !bytes.Equal(data, body) {
+			t.Errorf("resume %v: downloaded data differs", resume)
+		}
+	}
+	if err := fsys.DownloadToFile(context.Background(), "/", local, true); !errors.Is(err, ErrIsDir) {
+		t.Errorf("want ErrIsDir, have %v", err)
+	}
+}
+
+func TestDownloadToFileResumeAfterFault(t *testing.T) {
+	faults := ydfstest.NewTransport(nil,
+		ydfstest.Step{Match: ydfstest.MatchRange, Fault: ydfstest.Truncate(resumeCheckSize)},
+		ydfstest.Step{Fault: ydfstest.Status(http.StatusTooManyRequests, "3")},
+	)
+	fsys, md := newMockFS(t, WithMiddleware(faults.Wrap))
+	body := make([]byte, 3*resumeCheckSize)
+	for i := range body {
+		body[i] = byte(i % 251)
+	}
+	md.put("/big.bin", body)
+	local := filepath.Join(t.TempDir(), "big.bin")
+
+	err := fsys.DownloadToFile(context.Background(), "/big.bin", local, true)
+	if !errors.Is(err, ErrNetwork) {
+		t.Fatalf("want ErrNetwork of broken download, have %v", err)
+	}
+	if info, err := os.Stat(local); err != nil || info.Size() != resumeCheckSize {
+		t.Fatalf("partial download is not kept: %v, %v", info, err)
+	}
+	err = fsys.DownloadToFile(context.Background(), "/big.bin", local, true)
+	if !errors.Is(err, ErrTooManyRequests) || retryAfter(err) != 3*time.Second {
+		t.Fatalf("want throttling with delay, have %v", err)
+	}
+	if err := fsys.DownloadToFile(context.Background(), "/big.bin", local, true); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(local); !bytes.Equal(data, body) {
+		t.Error("resumed download differs")
+	}
+	if faults.Pending() != 0 {
+		t.Errorf("%d steps of scenario are not reached", faults.Pending())
+	}
+}
+
+func TestDownloadPreservesModTime(t *testing.T) {
+	fsys, md := newMockFS(t)
+	modified := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	md.put("/a.txt", []byte("contents"))
+	md.update("/a.txt", func(e *mockEntry) { e.modified = modified })
+	local := filepath.Join(t.TempDir(), "a.txt")
+	checkModTime := func(what string, want time.Time) {
+		t.Helper()
+		if info, err := os.Stat(local); err != nil || !info.ModTime().Equal(want) {
+			t.Errorf("%s: local file has modification time %v, want %v (%v)", what, info.ModTime(), want, err)
+		}
+	}
+
+	for _, resume := range []bool{false, true} {
+		os.Remove(local)
+		if err := fsys.DownloadToFile(context.Background(), "/a.txt", local, resume); err != nil {
+			t.Fatal(err)
+		}
+		checkModTime(fmt.Sprintf("resume %v", resume), modified)
+	}
+
+	// modification time stored by uploader takes precedence
+	stored := time.Date(2020, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	md.update("/a.txt", func(e *mockEntry) { e.props = map[string]string{mtimeProperty: stored.Format(time.RFC3339Nano)} })
+	os.Chtimes(local, time.Time{}, time.Now())
+	if changed, err := fsys.DownloadIfChanged(context.Background(), "/a.txt", local); err != nil || changed {
+		t.Fatalf("DownloadIfChanged returned %v, %v", changed, err)
+	}
+	checkModTime("unchanged file", stored)
+	md.update("/a.txt", func(e *mockEntry) { e.props[mtimeProperty] = "not a time" })
+	if err := fsys.DownloadToFile(context.Background(), "/a.txt", local, false); err != nil {
+		t.Fatal(err)
+	}
+	checkModTime("malformed property", modified)
+}
@@ -0,0 +1,19 @@
+package ydfs
+
+// MustSub is like FS.Sub but panics if dir can not be opened. It simplifies
+// initialization of package level variables, e.g. templates or static
+// assets, so that FS can replace embed.FS:
+//
+//	var templates = template.Must(template.ParseFS(ydfs.MustSub(fsys, "site/templates"), "*.html"))
+//
+// Names within FS are accepted both unrooted ("layouts/base.html") as
+// io/fs functions pass them and rooted ("/layouts/base.html"). Names
+// with ".." elements are rejected with fs.ErrInvalid, so they never
+// refer to files outside dir.
+func MustSub(fsys FS, dir string) FS {
+	sub, err := fsys.Sub(dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
@@ -0,0 +1,71 @@
+package ydfs
+
+import (
+	"errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMustSub(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/site/templates/page.html", []byte(`{{define "page"}}<p>{{.}}</p>{{end}}`))
+	md.put("/site/templates/partials/nav.html", []byte(`{{define "nav"}}<nav></nav>{{end}}`))
+	md.put("/site/static/style.css", []byte("body{}"))
+
+	tmpl, err := template.ParseFS(MustSub(fsys, "site/templates"), "*.html", "partials/*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := tmpl.ExecuteTemplate(&b, "page", "hello"); err != nil || b.String() != "<p>hello</p>" {
+		t.Errorf("unexpected template output: %q %v", b.String(), err)
+	}
+	if tmpl.Lookup("nav") == nil {
+		t.Error("template from subdirectory is not parsed")
+	}
+
+	srv := httptest.NewServer(http.StripPrefix("/static/", http.FileServer(http.FS(MustSub(fsys, "/site/static")))))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/static/style.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(data) != "body{}" {
+		t.Errorf("unexpected response: %d %q", resp.StatusCode, data)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustSub does not panic for missing directory")
+		}
+	}()
+	MustSub(fsys, "missing")
+}
+
+func TestSubConfinement(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/site/index.html", []byte("index"))
+	md.put("/secret", []byte("secret"))
+	sub := MustSub(fsys, "site")
+	for _, name := range []string{"../secret", "/../secret", "a/../../secret"} {
+		if _, err := sub.Open(name); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("Open(%q): got %v, want fs.ErrInvalid", name, err)
+		}
+		if _, err := sub.ReadFile(name); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("ReadFile(%q): got %v, want fs.ErrInvalid", name, err)
+		}
+		if err := sub.WriteFile(name, nil); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("WriteFile(%q): got %v, want fs.ErrInvalid", name, err)
+		}
+	}
+	if e, _ := md.get("/secret"); string(e.data) != "secret" {
+		t.Error("file outside sub FS is changed")
+	}
+}
@@ -0,0 +1,73 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+)
+
+// Errors reported by the API with specific status codes. They wrap
+// ErrAPI errors, see IsTemporary to tell errors worth retrying.
+// ErrForbidden also matches fs.ErrPermission.
+var (
+	ErrUnauthorized    = errors.New("token is invalid or expired")
+	ErrQuotaExceeded   = errors.New("not enough free space on the disk")
+	ErrLocked          = errors.New("resource is locked")
+	ErrForbidden       = newFSError("access forbidden", fs.ErrPermission)
+	ErrPaymentRequired = errors.New("payment required")
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// fsError is a package error also matching the error of package io/fs
+// with the same meaning, so that callers may check for either one.
+type fsError struct {
+	msg  string
+	base error
+}
+
+// newFSError returns error with message msg matching base.
+func newFSError(msg string, base error) error {
+	return &fsError{msg: msg, base: base}
+}
+
+func (e *fsError) Error() string { return e.msg }
+
+func (e *fsError) Is(target error) bool { return target == e.base }
+
+// statusError returns sentinel error for API response status code,
+// nil if there is none.
+func statusError(code int) error {
+	switch code {
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusInsufficientStorage:
+		return ErrQuotaExceeded
+	case http.StatusLocked:
+		return ErrLocked
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusPaymentRequired:
+		return ErrPaymentRequired
+	case http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	}
+	return nil
+}
+
+// IsTemporary reports whether operation failed with err may succeed
+// if retried later without user action: on network errors, locked
+// resources, rate limiting and unfinished asynchronous operations.
+// Errors like ErrQuotaExceeded or ErrForbidden require user action,
+// and cancelled requests are not retried either.
+func IsTemporary(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	for _, target := range []error{ErrNetwork, ErrLocked, ErrTooManyRequests, ErrOperationPending} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
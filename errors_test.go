@@ -0,0 +1,165 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestPathErrors(t *testing.T) {
+	root, md := newMockFS(t)
+	md.put("/sub/file.txt", []byte("data"))
+	md.put("/sub/dir/x", []byte("x"))
+	fsys, err := root.Sub("/sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		op   string
+		path string
+		err  error
+		fn   func() error
+	}{
+		{"Open", "open", "/missing", ErrNotFound, func() error { _, err := fsys.Open("/missing"); return err }},
+		{"Stat", "stat", "/missing", ErrNotFound, func() error { _, err := fsys.Stat("/missing"); return err }},
+		{"StatExtended", "stat", "/missing", ErrNotFound, func() error { _, err := fsys.StatExtended("/missing"); return err }},
+		{"Open not exist", "open", "/missing", fs.ErrNotExist, func() error { _, err := fsys.Open("/missing"); return err }},
+		{"Stat not exist", "stat", "/missing", fs.ErrNotExist, func() error { _, err := fsys.Stat("/missing"); return err }},
+		{"Sub missing", "sub", "/missing", ErrNotFound, func() error { _, err := fsys.Sub("/missing"); return err }},
+		{"Sub file", "sub", "/file.txt", ErrNotDir, func() error { _, err := fsys.Sub("/file.txt"); return err }},
+		{"ReadFile", "read", "/missing", ErrNotFound, func() error { _, err := fsys.ReadFile("/missing"); return err }},
+		{"ReadDir missing", "readdirent", "/missing", ErrNotFound, func() error { _, err := fsys.ReadDir("/missing"); return err }},
+		{"ReadDir not exist", "readdirent", "/missing", fs.ErrNotExist, func() error { _, err := fsys.ReadDir("/missing"); return err }},
+		{"ReadDir file", "readdirent", "/file.txt", ErrNotDir, func() error { _, err := fsys.ReadDir("/file.txt"); return err }},
+		{"ReadDirExtended", "readdirent", "/file.txt", ErrNotDir, func() error { _, err := fsys.ReadDirExtended("/file.txt"); return err }},
+		{"ReadDirSorted", "readdirent", "/missing", ErrNotFound, func() error {
+			_, err := fsys.ReadDirSorted("/missing", SortByName, false)
+			return err
+		}},
+		{"ReadDirSorted invalid", "readdirent", "/dir", fs.ErrInvalid, func() error {
+			_, err := fsys.ReadDirSorted("/dir", "color", false)
+			return err
+		}},
+		{"ReadDirIter", "readdirent", "/file.txt", ErrNotDir, func() error {
+			for _, err := range fsys.ReadDirIter(context.Background(), "/file.txt") {
+				return err
+			}
+			return nil
+		}},
+		{"WriteFile", "write", "/missing/a.txt", ErrAPI, func() error { return fsys.WriteFile("/missing/a.txt", nil) }},
+		{"Mkdir", "mkdir", "/dir", ErrAPI, func() error { return fsys.Mkdir("/dir") }},
+		{"MkdirAll", "mkdir", "/file.txt", ErrNotDir, func() error { return fsys.MkdirAll("/file.txt/a") }},
+		{"Remove missing", "remove", "/missing", ErrNotFound, func() error { return fsys.Remove("/missing") }},
+		{"Remove not exist", "remove", "/missing", fs.ErrNotExist, func() error { return fsys.Remove("/missing") }},
+		{"Remove non-empty", "remove", "/dir", ErrNotEmpty, func() error { return fsys.Remove("/dir") }},
+		{"OpenFile excl", "open", "/file.txt", fs.ErrExist, func() error {
+			_, err := fsys.OpenFile("/file.txt", os.O_RDWR|os.O_CREATE|os.O_EXCL, 0)
+			return err
+		}},
+		{"OpenFile dir", "open", "/dir", ErrIsDir, func() error { _, err := fsys.OpenFile("/dir", os.O_RDWR, 0); return err }},
+		{"Read dir", "read", "/dir", ErrIsDir, func() error {
+			f, err := fsys.Open("/dir")
+			if err != nil {
+				return err
+			}
+			_, err = f.Read(make([]byte, 1))
+			return err
+		}},
+		{"ReadDir of file", "readdirent", "/file.txt", ErrNotDir, func() error {
+			f, err := fsys.Open("/file.txt")
+			if err != nil {
+				return err
+			}
+			_, err = f.(fs.ReadDirFile).ReadDir(-1)
+			return err
+		}},
+		{"Write read-only", "write", "/file.txt", fs.ErrPermission, func() error {
+			f, err := fsys.Open("/file.txt")
+			if err != nil {
+				return err
+			}
+			_, err = f.(io.Writer).Write([]byte("x"))
+			return err
+		}},
+		{"DownloadFile", "download", "/dir", ErrIsDir, func() error {
+			return fsys.DownloadFile(context.Background(), "/dir", &memWriterAt{}, 1)
+		}},
+		{"Publish", "publish", "/missing", ErrNotFound, func() error { _, err := fsys.Publish("/missing"); return err }},
+	} {
+		err := tc.fn()
+		var pe *fs.PathError
+		if !errors.As(err, &pe) {
+			t.Errorf("%s: error %v is not *fs.PathError", tc.name, err)
+			continue
+		}
+		if pe.Op != tc.op || pe.Path != tc.path {
+			t.Errorf("%s: got op %q path %q, want op %q path %q", tc.name, pe.Op, pe.Path, tc.op, tc.path)
+		}
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: error %v does not wrap %v", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestSubMkdirAllRemoveAll(t *testing.T) {
+	root, md := newMockFS(t)
+	md.put("/sub/x", nil)
+	fsys, err := root.Sub("/sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.MkdirAll("/a/b/c"); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := md.get("/sub/a/b/c"); !ok || !e.dir {
+		t.Fatal("MkdirAll in sub FS does not create directories")
+	}
+	if err := fsys.WriteFile("/a/b/file", []byte("f")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.RemoveAll("/a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := md.get("/sub/a"); ok {
+		t.Error("RemoveAll in sub FS leaves directory")
+	}
+	if _, ok := md.get("/sub/x"); !ok {
+		t.Error("RemoveAll removes too much")
+	}
+}
+
+func TestStatusErrors(t *testing.T) {
+	fsys, md := newMockFS(t)
+	for _, tc := range []struct {
+		code      int
+		name      string
+		want      error
+		temporary bool
+	}{
+		{http.StatusInsufficientStorage, "DiskInsufficientStorageError", ErrQuotaExceeded, false},
+		{http.StatusLocked, "DiskResourceLockedError", ErrLocked, true},
+		{http.StatusForbidden, "ForbiddenError", ErrForbidden, false},
+		{http.StatusPaymentRequired, "PaymentRequired", ErrPaymentRequired, false},
+		{http.StatusTooManyRequests, "TooManyRequestsError", ErrTooManyRequests, true},
+	} {
+		md.failNext(tc.code, tc.name)
+		err := fsys.WriteFile("/a.txt", []byte("a"))
+		if !errors.Is(err, tc.want) || !errors.Is(err, ErrAPI) {
+			t.Errorf("status %d: got %v, want %v", tc.code, err, tc.want)
+		}
+		if tc.want == ErrForbidden && !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("status %d: %v does not match fs.ErrPermission", tc.code, err)
+		}
+		if IsTemporary(err) != tc.temporary {
+			t.Errorf("status %d: IsTemporary is %v", tc.code, !tc.temporary)
+		}
+	}
+	if !IsTemporary(&fs.PathError{Op: "read", Path: "/a", Err: ErrNetwork}) {
+		t.Error("network error is not temporary")
+	}
+}
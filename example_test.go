@@ -0,0 +1,24 @@
+package ydfs_test
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/dmfed/ydfs"
+)
+
+// FS can replace embed.FS for templates and static assets kept on the disk.
+func ExampleMustSub() {
+	fsys, err := ydfs.New(os.Getenv("YD"), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tmpl := template.Must(template.ParseFS(ydfs.MustSub(fsys, "site/templates"), "*.html"))
+	http.Handle("/static/", http.StripPrefix("/static/", ydfs.FileServer(ydfs.MustSub(fsys, "site/static"))))
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		tmpl.ExecuteTemplate(w, "index.html", nil)
+	})
+	log.Fatal(http.ListenAndServe(":8080", nil))
+}
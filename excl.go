@@ -0,0 +1,16 @@
+package ydfs
+
+// WithoutOverwrite makes WriteFile and WriteFileStream fail with
+// fs.ErrExist instead of replacing existing files, as if every write
+// were made by WriteFileExcl. Files opened for writing with OpenFile
+// are still replaced when synced.
+func WithoutOverwrite() Option {
+	return func(o *options) {
+		o.noOverwrite = true
+	}
+}
+
+// WriteFileExcl implements FS
+func (y *ydfs) WriteFileExcl(name string, data []byte) error {
+	return y.writeFile(name, data, false)
+}
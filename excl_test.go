@@ -0,0 +1,39 @@
+package ydfs
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestWriteFileExcl(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	if err := fsys.WriteFileExcl("/docs/a.txt", []byte("b")); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("want fs.ErrExist writing existing file, have %v", err)
+	}
+	if e, _ := md.get("/docs/a.txt"); string(e.data) != "a" {
+		t.Errorf("existing file replaced: %q", e.data)
+	}
+	if err := fsys.WriteFileExcl("/docs/b.txt", []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := md.get("/docs/b.txt"); !ok || string(e.data) != "b" {
+		t.Error("new file is not written")
+	}
+}
+
+func TestWithoutOverwrite(t *testing.T) {
+	fsys, md := newMockFS(t, WithoutOverwrite())
+	md.put("/docs/a.txt", []byte("a"))
+	if err := fsys.WriteFile("/docs/a.txt", []byte("b")); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("want fs.ErrExist from WriteFile, have %v", err)
+	}
+	if err := fsys.WriteFileStream("/docs/a.txt", strings.NewReader("b")); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("want fs.ErrExist from WriteFileStream, have %v", err)
+	}
+	if err := fsys.WriteFile("/docs/c.txt", []byte("c")); err != nil {
+		t.Errorf("writing new file: %v", err)
+	}
+}
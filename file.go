@@ -0,0 +1,209 @@
+package ydfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+)
+
+// File is a file of FS. Files returned by Create and OpenFile implement
+// it, files returned by Open can be converted to File with a type
+// assertion and are read-only.
+//
+// Contents of a file are kept in memory once read or written.
+// Changes made by Write, WriteAt and Truncate are uploaded to the disk
+// by Sync and Close.
+type File interface {
+	fs.File
+	io.Writer
+	io.WriterAt
+	io.Seeker
+
+	// Truncate changes the size of the file.
+	Truncate(size int64) error
+
+	// Sync uploads changed contents of the file.
+	Sync() error
+
+	// Refresh fetches metadata of the file reported by Stat again.
+	Refresh() error
+}
+
+// Create implements FS
+func (y *ydfs) Create(name string) (File, error) {
+	return y.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// OpenFile implements FS
+func (y *ydfs) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	file, _, err := y.open(y.context(), name, flag, y.client.fields)
+	switch {
+	case err == nil && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	case err == nil && file.isdir && writable:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: ErrIsDir}
+	case errors.Is(err, ErrNotFound) && flag&os.O_CREATE != 0:
+		fullname := y.fullPath(name)
+		file = y.newFile(name, Resource{Name: path.Base(fullname), Path: fullname, Type: TypeFile})
+		file.data = []byte{}
+		file.dirty = true
+		// the file may be created by someone else before it is uploaded
+		file.excl = flag&os.O_EXCL != 0
+	case err != nil:
+		return nil, err
+	}
+	file.flag = flag
+	if writable && flag&os.O_TRUNC != 0 && !file.dirty {
+		file.data = []byte{}
+		file.size = 0
+		file.dirty = true
+	}
+	return file, nil
+}
+
+// checkWritable returns error if file is not opened for writing.
+func (file *ydfile) checkWritable(op string) error {
+	if file.isdir {
+		return &fs.PathError{Op: op, Path: file.name, Err: ErrIsDir}
+	}
+	if file.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return &fs.PathError{Op: op, Path: file.name, Err: fs.ErrPermission}
+	}
+	return nil
+}
+
+// Write implements io.Writer
+func (file *ydfile) Write(b []byte) (int, error) {
+	if err := file.checkWritable("write"); err != nil {
+		return 0, err
+	}
+	if err := file.load("write"); err != nil {
+		return 0, err
+	}
+	if file.flag&os.O_APPEND != 0 {
+		file.roffset = len(file.data)
+	}
+	n := file.writeAt(b, int64(file.roffset))
+	file.roffset += n
+	return n, nil
+}
+
+// WriteAt implements io.WriterAt
+func (file *ydfile) WriteAt(b []byte, off int64) (int, error) {
+	if err := file.checkWritable("write"); err != nil {
+		return 0, err
+	}
+	if file.flag&os.O_APPEND != 0 {
+		return 0, &fs.PathError{Op: "writeat", Path: file.name, Err: fmt.Errorf("invalid use of WriteAt on file opened with O_APPEND")}
+	}
+	if off < 0 {
+		return 0, &fs.PathError{Op: "writeat", Path: file.name, Err: fs.ErrInvalid}
+	}
+	if err := file.load("write"); err != nil {
+		return 0, err
+	}
+	return file.writeAt(b, off), nil
+}
+
+// writeAt writes b to contents loaded into memory.
+func (file *ydfile) writeAt(b []byte, off int64) int {
+	if end := off + int64(len(b)); end > int64(len(file.data)) {
+		file.resize(end)
+	}
+	n := copy(file.data[off:], b)
+	file.dirty = true
+	return n
+}
+
+// resize grows or shrinks contents loaded into memory to size.
+func (file *ydfile) resize(size int64) {
+	if size <= int64(len(file.data)) {
+		file.data = file.data[:size]
+	} else {
+		file.data = append(file.data, make([]byte, size-int64(len(file.data)))...)
+	}
+	file.size = size
+}
+
+// Truncate changes the size of the file.
+func (file *ydfile) Truncate(size int64) error {
+	if err := file.checkWritable("truncate"); err != nil {
+		return err
+	}
+	if size < 0 {
+		return &fs.PathError{Op: "truncate", Path: file.name, Err: fs.ErrInvalid}
+	}
+	if err := file.load("truncate"); err != nil {
+		return err
+	}
+	file.resize(size)
+	file.dirty = true
+	return nil
+}
+
+// Seek implements io.Seeker
+func (file *ydfile) Seek(offset int64, whence int) (int64, error) {
+	if file.isdir {
+		return 0, &fs.PathError{Op: "seek", Path: file.name, Err: ErrIsDir}
+	}
+	size := file.size
+	if file.data != nil {
+		size = int64(len(file.data))
+	}
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = int64(file.roffset) + offset
+	case io.SeekEnd:
+		pos = size + offset
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: file.name, Err: fs.ErrInvalid}
+	}
+	if pos < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: file.name, Err: fs.ErrInvalid}
+	}
+	if file.stream != nil && !file.window.contains(pos) {
+		// streaming restarts at the new offset with the next Read
+		file.stream.Close()
+		file.stream = nil
+	}
+	file.roffset = int(pos)
+	return pos, nil
+}
+
+// Sync uploads changed contents of the file.
+func (file *ydfile) Sync() error {
+	if !file.dirty {
+		return nil
+	}
+	var err error
+	if file.excl {
+		// fails with fs.ErrExist if the file was created after open
+		err = file.client.putFileNoTruncate(file.ctx, file.path, file.data)
+	} else {
+		if file.opts.skipSame && unchanged(file.ctx, file.client, file.path, file.data) {
+			file.dirty = false
+			return nil
+		}
+		if err := file.opts.keepVersion(file.ctx, file.client, file.path); err != nil {
+			return &fs.PathError{Op: "sync", Path: file.name, Err: err}
+		}
+		err = file.client.putFileTruncate(file.ctx, file.path, file.data)
+	}
+	file.opts.auditRecord(AuditWrite, file.path, int64(len(file.data)), err)
+	if err != nil {
+		return &fs.PathError{Op: "sync", Path: file.name, Err: err}
+	}
+	file.dirty = false
+	file.excl = false
+	file.link = nil
+	file.size = int64(len(file.data))
+	file.res.Size = file.size
+	return nil
+}
@@ -0,0 +1,263 @@
+package ydfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+func TestFileStatCached(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/a.txt", []byte("hello"))
+	f, err := fsys.Open("/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	n := md.requests()
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 5 || info.IsDir() {
+		t.Errorf("Stat() returns size %d, dir %v", info.Size(), info.IsDir())
+	}
+	if md.requests() != n {
+		t.Errorf("Stat() of open file sends %d requests", md.requests()-n)
+	}
+
+	md.put("/a.txt", []byte("hello, world"))
+	if info, _ := f.Stat(); info.Size() != 5 {
+		t.Errorf("Stat() is not cached, size %d", info.Size())
+	}
+	r, ok := f.(interface{ Refresh() error })
+	if !ok {
+		t.Fatal("file has no Refresh method")
+	}
+	if err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := f.Stat(); info.Size() != 12 {
+		t.Errorf("Stat() after Refresh returns size %d", info.Size())
+	}
+}
+
+func TestFileWrite(t *testing.T) {
+	fsys, md := newMockFS(t)
+	f, err := fsys.Create("/new.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("hello world")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt([]byte("W"), 6); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := md.get("/new.txt"); ok {
+		t.Error("file is uploaded before Sync")
+	}
+	if err := f.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := md.get("/new.txt"); !ok || string(e.data) != "hello World" {
+		t.Fatalf("synced file is %v", e)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := io.ReadAll(f); err != nil || string(data) != "hello World" {
+		t.Errorf("read after seek returns %q, %v", data, err)
+	}
+	if err := f.Truncate(5); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := md.get("/new.txt"); string(e.data) != "hello" {
+		t.Errorf("Close does not upload truncated file, have %q", e.data)
+	}
+
+	f, err = fsys.OpenFile("/new.txt", os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte(", again")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt([]byte("x"), 0); err == nil {
+		t.Error("WriteAt on file opened with O_APPEND succeeds")
+	}
+	if _, err := f.Read(make([]byte, 1)); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Read of write-only file returns %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := md.get("/new.txt"); string(e.data) != "hello, again" {
+		t.Errorf("appended file is %q", e.data)
+	}
+
+	if _, err := fsys.OpenFile("/new.txt", os.O_RDWR|os.O_CREATE|os.O_EXCL, 0); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("exclusive create of existing file returns %v", err)
+	}
+	if _, err := fsys.OpenFile("/missing", os.O_RDWR, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("open of missing file without O_CREATE returns %v", err)
+	}
+	md.put("/dir/x", nil)
+	if _, err := fsys.OpenFile("/dir", os.O_RDWR, 0); err == nil {
+		t.Error("open of directory for writing succeeds")
+	}
+
+	ro, err := fsys.Open("/new.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ro.(File).Write([]byte("x")); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Write to file opened by Open returns %v", err)
+	}
+}
+
+func TestFileSeekStreaming(t *testing.T) {
+	fsys, md := newMockFS(t, WithReadAhead(4, 1))
+	md.put("/a.txt", []byte("0123456789"))
+	f, err := fsys.Open("/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := f.(File)
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(file, buf); err != nil || string(buf) != "01" {
+		t.Fatalf("read %q, %v", buf, err)
+	}
+	if _, err := file.Seek(7, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := io.ReadAll(file); err != nil || string(data) != "789" {
+		t.Errorf("read after seek returns %q, %v", data, err)
+	}
+	if pos, err := file.Seek(-3, io.SeekEnd); err != nil || pos != 7 {
+		t.Errorf("seek from end returns %d, %v", pos, err)
+	}
+}
+
+func TestFileInfoMode(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/dir/a.txt", []byte("a"))
+	for _, tc := range []struct {
+		name string
+		mode fs.FileMode
+	}{
+		{"/dir", fs.ModeDir | 0755},
+		{"/dir/a.txt", 0644},
+	} {
+		info, err := fsys.Stat(tc.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode() != tc.mode {
+			t.Errorf("%s: Mode() = %v, want %v", tc.name, info.Mode(), tc.mode)
+		}
+	}
+	entries, err := fsys.ReadDir("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fs.FileInfoToDirEntry(info).Type(); e.Type() != want || e.Type() != info.Mode().Type() {
+			t.Errorf("%s: Type() = %v, want %v", e.Name(), e.Type(), want)
+		}
+		if e.Type().Perm() != 0 {
+			t.Errorf("%s: Type() has permission bits %v", e.Name(), e.Type())
+		}
+	}
+}
+
+func TestFileReadAndWriteTo(t *testing.T) {
+	fsys, md := newMockFS(t)
+	body := bytes.Repeat([]byte("0123456789"), 1000)
+	md.put("/a.txt", body)
+
+	f, err := fsys.Open("/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 3)
+	if n, err := f.Read(buf); n != 3 || err != nil || string(buf) != "012" {
+		t.Errorf("Read returned %d, %v, %q", n, err, buf)
+	}
+	var rest bytes.Buffer
+	if n, err := io.Copy(&rest, f); err != nil || n != int64(len(body)-3) || !bytes.Equal(rest.Bytes(), body[3:]) {
+		t.Errorf("Copy after Read returned %d, %v", n, err)
+	}
+	if n, err := f.Read(buf); n != 0 || err != io.EOF {
+		t.Errorf("Read at the end returned %d, %v", n, err)
+	}
+	f.Close()
+
+	// contents are streamed without loading into memory
+	f, err = fsys.Open("/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var all bytes.Buffer
+	if n, err := io.Copy(&all, f); err != nil || n != int64(len(body)) || !bytes.Equal(all.Bytes(), body) {
+		t.Errorf("Copy returned %d, %v", n, err)
+	}
+	if f.(*ydfile).data != nil {
+		t.Error("streamed contents are loaded into memory")
+	}
+	if n, err := f.Read(buf); n != 0 || err != io.EOF {
+		t.Errorf("Read after Copy returned %d, %v", n, err)
+	}
+	if _, err := f.(io.Seeker).Seek(-4, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := io.ReadAll(f); err != nil || string(data) != "6789" {
+		t.Errorf("ReadAll after Seek returned %q, %v", data, err)
+	}
+}
+
+func TestOpenFileExclRace(t *testing.T) {
+	fsys, md := newMockFS(t)
+	f, err := fsys.OpenFile("/new.txt", os.O_RDWR|os.O_CREATE|os.O_EXCL, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("mine")); err != nil {
+		t.Fatal(err)
+	}
+	// another writer creates the file before it is uploaded
+	md.put("/new.txt", []byte("theirs"))
+	if err := f.Sync(); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Sync of exclusively created file returns %v", err)
+	}
+	if e, _ := md.get("/new.txt"); string(e.data) != "theirs" {
+		t.Errorf("file created by another writer is overwritten with %q", e.data)
+	}
+
+	f, err = fsys.OpenFile("/other.txt", os.O_RDWR|os.O_CREATE|os.O_EXCL, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"a", "b"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Sync(); err != nil {
+			t.Fatalf("Sync after the file is created returns %v", err)
+		}
+	}
+	if e, _ := md.get("/other.txt"); string(e.data) != "ab" {
+		t.Errorf("file is %q", e.data)
+	}
+}
@@ -0,0 +1,181 @@
+package ydfs
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Filter selects files by gitignore-style patterns, sizes and ages.
+// It is used by Syncer (see WithSyncFilter) and WalkIter.
+type Filter struct {
+	rules            []filterRule
+	minSize, maxSize int64
+	minAge, maxAge   time.Duration
+	now              func() time.Time
+}
+
+// filterRule is a compiled pattern.
+type filterRule struct {
+	re      *regexp.Regexp
+	negate  bool // pattern starts with "!" and includes matching files
+	dirOnly bool // pattern ends with "/" and only matches directories
+}
+
+// FilterOption configures Filter.
+type FilterOption func(*Filter)
+
+// WithSizeRange makes Filter exclude files smaller than min bytes or
+// larger than max bytes. Zero max means no upper limit.
+func WithSizeRange(min, max int64) FilterOption {
+	return func(f *Filter) {
+		f.minSize, f.maxSize = min, max
+	}
+}
+
+// WithAgeRange makes Filter exclude files modified less than min or
+// more than max ago. Zero max means no upper limit.
+func WithAgeRange(min, max time.Duration) FilterOption {
+	return func(f *Filter) {
+		f.minAge, f.maxAge = min, max
+	}
+}
+
+// NewFilter returns Filter excluding files matched by patterns which
+// follow the syntax of .gitignore files: blank lines and lines starting
+// with "#" are skipped, "!" re-includes files excluded by preceding
+// patterns, trailing "/" matches directories only, patterns with "/"
+// at the beginning or in the middle are relative to the root of the
+// filtered tree while others match names at any level, "*", "?" and
+// "[...]" match within a path element and "**" matches any number of
+// directories. The last matching pattern decides, and contents of
+// excluded directories are excluded too.
+func NewFilter(patterns []string, opts ...FilterOption) (*Filter, error) {
+	f := &Filter{now: time.Now}
+	for _, p := range patterns {
+		rule, ok, err := compilePattern(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: pattern %q: %v", fs.ErrInvalid, p, err)
+		}
+		if ok {
+			f.rules = append(f.rules, rule)
+		}
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f, nil
+}
+
+// Match reports whether the file passes the filter. Name is a
+// slash-separated path relative to the root of the filtered tree. Sizes
+// and ages are only checked for files, patterns are also checked for
+// every parent directory of name.
+func (f *Filter) Match(name string, info fs.FileInfo) bool {
+	if f == nil {
+		return true
+	}
+	name = strings.Trim(name, "/")
+	if name == "" || name == "." {
+		return true
+	}
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if f.excluded(dir, true) {
+			return false
+		}
+	}
+	if f.excluded(name, info.IsDir()) {
+		return false
+	}
+	if info.IsDir() {
+		return true
+	}
+	if info.Size() < f.minSize || f.maxSize > 0 && info.Size() > f.maxSize {
+		return false
+	}
+	age := f.now().Sub(info.ModTime())
+	return age >= f.minAge && (f.maxAge == 0 || age <= f.maxAge)
+}
+
+// excluded reports whether patterns exclude name itself.
+func (f *Filter) excluded(name string, isDir bool) bool {
+	excluded := false
+	for _, rule := range f.rules {
+		if (!rule.dirOnly || isDir) && rule.re.MatchString(name) {
+			excluded = !rule.negate
+		}
+	}
+	return excluded
+}
+
+// compilePattern converts gitignore pattern to filterRule. It returns
+// false for blank lines and comments.
+func compilePattern(p string) (filterRule, bool, error) {
+	var rule filterRule
+	if !strings.HasSuffix(p, "\\ ") {
+		p = strings.TrimRight(p, " ")
+	}
+	switch {
+	case p == "" || strings.HasPrefix(p, "#"):
+		return rule, false, nil
+	case strings.HasPrefix(p, "!"):
+		rule.negate = true
+		p = p[1:]
+	case strings.HasPrefix(p, `\#`), strings.HasPrefix(p, `\!`):
+		p = p[1:]
+	}
+	if strings.HasSuffix(p, "/") {
+		rule.dirOnly = true
+		p = strings.TrimRight(p, "/")
+	}
+	prefix := "^(?:.*/)?"
+	if strings.Contains(p, "/") {
+		prefix = "^"
+		p = strings.TrimPrefix(p, "/")
+	}
+	if p == "" {
+		return rule, false, nil
+	}
+	re, err := regexp.Compile(prefix + globRegexp(p) + "$")
+	if err != nil {
+		return rule, false, err
+	}
+	rule.re = re
+	return rule, true, nil
+}
+
+// globRegexp converts glob with gitignore "**" semantics to regexp.
+func globRegexp(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '\\' && i+1 < len(p):
+			i++
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		case c == '[' && strings.IndexByte(p[i+1:], ']') > 0:
+			end := i + 1 + strings.IndexByte(p[i+1:], ']')
+			class := p[i+1 : end]
+			if class[0] == '!' {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	return b.String()
+}
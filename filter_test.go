@@ -0,0 +1,129 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFilterPatterns(t *testing.T) {
+	f, err := NewFilter([]string{
+		"# comment",
+		"",
+		"*.log",
+		"!keep.log",
+		"build/",
+		"/root.txt",
+		"docs/**/draft-?.md",
+		"cache/**",
+		"[0-9]*.tmp",
+		`\#hash`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := &ydinfo{res: Resource{Type: TypeFile}}
+	dir := &ydinfo{res: Resource{Type: TypeDir}, mode: fs.ModeDir}
+	for _, tc := range []struct {
+		name string
+		info fs.FileInfo
+		want bool
+	}{
+		{"a.txt", file, true},
+		{"a.log", file, false},
+		{"x/y/a.log", file, false},
+		{"x/keep.log", file, true},
+		{"build", dir, false},
+		{"build", file, true},
+		{"src/build/out.o", file, false},
+		{"root.txt", file, false},
+		{"sub/root.txt", file, true},
+		{"docs/draft-1.md", file, false},
+		{"docs/a/b/draft-2.md", file, false},
+		{"docs/draft-10.md", file, true},
+		{"cache", dir, true},
+		{"cache/x/y", file, false},
+		{"1.tmp", file, false},
+		{"a1.tmp", file, true},
+		{"#hash", file, false},
+		{"", dir, true},
+	} {
+		if got := f.Match(tc.name, tc.info); got != tc.want {
+			t.Errorf("%s (dir %v): want %v, have %v", tc.name, tc.info.IsDir(), tc.want, got)
+		}
+	}
+
+	if _, err := NewFilter([]string{"[!]"}); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("want fs.ErrInvalid for malformed pattern, have %v", err)
+	}
+	if !(*Filter)(nil).Match("a.log", file) {
+		t.Error("nil filter excludes files")
+	}
+}
+
+func TestFilterPredicates(t *testing.T) {
+	now := time.Now()
+	f, _ := NewFilter(nil, WithSizeRange(10, 100), WithAgeRange(time.Hour, 24*time.Hour))
+	f.now = func() time.Time { return now }
+	info := func(size int64, age time.Duration) fs.FileInfo {
+		return &ydinfo{res: Resource{Type: TypeFile, Size: size, Modified: now.Add(-age)}}
+	}
+	for _, tc := range []struct {
+		info fs.FileInfo
+		want bool
+	}{
+		{info(50, 2*time.Hour), true},
+		{info(5, 2*time.Hour), false},
+		{info(500, 2*time.Hour), false},
+		{info(50, time.Minute), false},
+		{info(50, 48*time.Hour), false},
+		{&ydinfo{res: Resource{Type: TypeDir}, mode: fs.ModeDir}, true},
+	} {
+		if got := f.Match("f", tc.info); got != tc.want {
+			t.Errorf("size %d, modified %v: want %v, have %v", tc.info.Size(), tc.info.ModTime(), tc.want, got)
+		}
+	}
+}
+
+func TestFilterWalkAndSync(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/src/main.go", []byte("main"))
+	md.put("/src/debug.log", []byte("log"))
+	md.put("/src/build/out.bin", []byte("bin"))
+	md.put("/src/pkg/lib.go", []byte("lib"))
+	f, err := NewFilter([]string{"*.log", "build/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seq, walkErr := fsys.WalkIter(context.Background(), "/src", f)
+	var got []string
+	for p := range seq {
+		got = append(got, p)
+	}
+	if err := walkErr(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/src", "/src/main.go", "/src/pkg", "/src/pkg/lib.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, have %v", want, got)
+	}
+
+	local := t.TempDir()
+	writeLocalFiles(t, local, map[string]string{
+		"main.go":       "main",
+		"local.log":     "local",
+		"build/new.bin": "new",
+	})
+	plan, err := fsys.Syncer("/src", local, SyncUp, WithSyncDelete(), WithSyncFilter(f)).Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPlan := []Action{{Kind: ActionDeleteRemote, Path: "pkg/lib.go"}}
+	if !reflect.DeepEqual(plan, wantPlan) {
+		t.Errorf("want plan %v, have %v", wantPlan, plan)
+	}
+}
@@ -1,3 +1,8 @@
 module github.com/dmfed/ydfs
 
-go 1.17
+go 1.23.0
+
+require (
+	golang.org/x/net v0.42.0
+	golang.org/x/text v0.27.0
+)
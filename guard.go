@@ -0,0 +1,31 @@
+package ydfs
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// WithDeleteGuard sets function consulted before each permanent deletion
+// made by Remove and RemoveAll. It is called with the name of resource
+// as passed to the FS (children of directories removed by RemoveAll are
+// joined to it) and its metadata. Deletion is refused with
+// fs.ErrPermission error unless fn returns true. RemoveAll stops at the
+// first refused resource.
+func WithDeleteGuard(fn func(path string, info fs.FileInfo) bool) Option {
+	return func(o *options) {
+		o.deleteGuard = fn
+	}
+}
+
+// guardDelete returns error if deletion of the named resource
+// is refused by the guard.
+func (y *ydfs) guardDelete(name string, res Resource) error {
+	if y.opts.deleteGuard == nil {
+		return nil
+	}
+	y.client.normalize(&res)
+	if !y.opts.deleteGuard(name, y.opts.info(res)) {
+		return &fs.PathError{Op: "remove", Path: name, Err: fmt.Errorf("%w: deletion refused by guard", fs.ErrPermission)}
+	}
+	return nil
+}
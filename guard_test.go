@@ -0,0 +1,53 @@
+package ydfs
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestDeleteGuard(t *testing.T) {
+	var asked []string
+	guard := func(name string, info fs.FileInfo) bool {
+		asked = append(asked, name)
+		return !strings.HasSuffix(name, ".keep") && !(info.IsDir() && path.Base(info.Name()) == "protected")
+	}
+	fsys, md := newMockFS(t, WithDeleteGuard(guard))
+	md.put("/a.txt", []byte("a"))
+	md.put("/b.keep", []byte("b"))
+	md.put("/protected/c.txt", []byte("c"))
+	md.put("/tree/d.txt", []byte("d"))
+	md.put("/tree/sub/e.keep", []byte("e"))
+
+	if err := fsys.Remove("/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Remove("/b.keep"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Remove of guarded file returns %v", err)
+	}
+	if err := fsys.RemoveAll("/protected"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("RemoveAll of guarded directory returns %v", err)
+	}
+	if _, ok := md.get("/protected/c.txt"); !ok {
+		t.Error("children of guarded directory are removed")
+	}
+
+	sub, err := fsys.Sub("/tree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	asked = nil
+	err = sub.RemoveAll("/")
+	var pe *fs.PathError
+	if !errors.As(err, &pe) || pe.Path != "/sub/e.keep" || !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("RemoveAll of tree with guarded file returns %v", err)
+	}
+	if _, ok := md.get("/tree/sub/e.keep"); !ok {
+		t.Error("guarded file is removed")
+	}
+	if len(asked) == 0 || asked[0] != "/" {
+		t.Errorf("guard is asked about %v", asked)
+	}
+}
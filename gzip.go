@@ -0,0 +1,48 @@
+package ydfs
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// acceptGzip asks for compressed response. Transports decompress
+// responses transparently only if they add Accept-Encoding themselves,
+// which custom transports (or DisableCompression) may not do, so the
+// header is set explicitly for metadata requests and responses are
+// decompressed by send.
+func acceptGzip(r *http.Request) {
+	r.Header.Set("Accept-Encoding", "gzip")
+}
+
+// gzipBody replaces compressed body of response to request asked
+// for compression with acceptGzip by decompressing reader.
+func gzipBody(r *http.Request, resp *http.Response) error {
+	if r.Header.Get("Accept-Encoding") != "gzip" || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		return fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	resp.Body = &gzipReadCloser{Reader: zr, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// gzipReadCloser closes both decompressing reader and compressed body.
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (z *gzipReadCloser) Close() error {
+	z.Reader.Close()
+	return z.body.Close()
+}
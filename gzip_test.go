@@ -0,0 +1,72 @@
+package ydfs
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// gzipResponseWriter compresses everything written to the response.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	zw *gzip.Writer
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	return w.zw.Write(b)
+}
+
+func TestGzipMetadata(t *testing.T) {
+	md := newMockDisk()
+	var compressed atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v1/disk") || r.Header.Get("Accept-Encoding") != "gzip" {
+			md.ServeHTTP(w, r)
+			return
+		}
+		compressed.Add(1)
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		defer zw.Close()
+		md.ServeHTTP(&gzipResponseWriter{ResponseWriter: w, zw: zw}, r)
+	}))
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+	fsys, err := New("mocktoken", &http.Client{Transport: &rewriteTransport{target: target}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	md.put("/docs/a.txt", []byte("a"))
+	md.put("/docs/b.txt", []byte("b"))
+
+	entries, err := fsys.ReadDir("/docs")
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("ReadDir of compressed listing returns %v, %v", entries, err)
+	}
+	n := 0
+	for _, err := range fsys.ReadDirIter(context.Background(), "/docs") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("ReadDirIter of compressed listing yields %d entries", n)
+	}
+	if data, err := fsys.ReadFile("/docs/a.txt"); err != nil || string(data) != "a" {
+		t.Errorf("ReadFile returns %q, %v", data, err)
+	}
+	// compressed error responses are decoded as well
+	if _, err := fsys.Stat("/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat of missing file returns %v", err)
+	}
+	if compressed.Load() == 0 {
+		t.Error("metadata requests do not accept gzip")
+	}
+}
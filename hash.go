@@ -0,0 +1,50 @@
+package ydfs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Names of hash algorithms accepted by ContentHash. They are the same as
+// names of the respective hash types of rclone.
+const (
+	HashMD5    = "md5"
+	HashSHA256 = "sha256"
+)
+
+// Hasher is implemented by file systems able to report hashes of
+// contents of files without reading them. It follows hashing of rclone
+// backends: Hashes lists supported algorithms, and ContentHash returns
+// lowercase hex encoded sum or empty string if the sum is not known,
+// failing with errors.ErrUnsupported for other algorithms.
+type Hasher interface {
+	Hashes() []string
+	ContentHash(name, algo string) (string, error)
+}
+
+// Hashes implements Hasher
+func (y *ydfs) Hashes() []string {
+	return []string{HashMD5, HashSHA256}
+}
+
+// ContentHash implements Hasher. Sums are calculated by Yandex Disk when
+// files are uploaded, so asking for them costs a metadata request only.
+func (y *ydfs) ContentHash(name, algo string) (string, error) {
+	algo = strings.ToLower(algo)
+	if algo != HashMD5 && algo != HashSHA256 {
+		return "", &fs.PathError{Op: "hash", Path: name, Err: fmt.Errorf("%w: hash %q", errors.ErrUnsupported, algo)}
+	}
+	res, err := y.client.getResource(y.context(), y.fullPath(name), 0, "type", algo)
+	if err != nil {
+		return "", &fs.PathError{Op: "hash", Path: name, Err: err}
+	}
+	if res.IsDir() {
+		return "", &fs.PathError{Op: "hash", Path: name, Err: ErrIsDir}
+	}
+	if algo == HashSHA256 {
+		return res.SHA256, nil
+	}
+	return res.MD5, nil
+}
@@ -0,0 +1,38 @@
+package ydfs
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+var _ Hasher = FS(nil)
+
+func TestContentHash(t *testing.T) {
+	fsys, md := newMockFS(t)
+	data := []byte("hello")
+	md.put("/dir/a.txt", data)
+	m, s := md5.Sum(data), sha256.Sum256(data)
+
+	before := md.requests()
+	if sum, err := fsys.ContentHash("/dir/a.txt", HashMD5); err != nil || sum != hex.EncodeToString(m[:]) {
+		t.Errorf("unexpected md5: %q %v", sum, err)
+	}
+	if sum, err := fsys.ContentHash("dir/a.txt", "SHA256"); err != nil || sum != hex.EncodeToString(s[:]) {
+		t.Errorf("unexpected sha256: %q %v", sum, err)
+	}
+	if n := md.requests() - before; n != 2 {
+		t.Errorf("want 2 requests, have %d", n)
+	}
+	if _, err := fsys.ContentHash("/dir/a.txt", "crc32"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("want errors.ErrUnsupported, have %v", err)
+	}
+	if _, err := fsys.ContentHash("/dir", HashMD5); !errors.Is(err, ErrIsDir) {
+		t.Errorf("want ErrIsDir, have %v", err)
+	}
+	if _, err := fsys.ContentHash("/missing", HashMD5); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound, have %v", err)
+	}
+}
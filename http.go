@@ -0,0 +1,221 @@
+package ydfs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileServer implements http.Handler serving files of FS.
+type fileServer struct {
+	fsys       FS
+	attachment func(r *http.Request) bool   // serve as attachment if true
+	preview    func(r *http.Request) string // preview size, empty for contents
+}
+
+// FileServerOption configures handler returned by FileServer.
+type FileServerOption func(*fileServer)
+
+// WithAttachment makes FileServer send Content-Disposition with the
+// original name of file, so that browsers save the file under its name
+// if fn reports true for the request and display it inline otherwise,
+// e.g. func(r *http.Request) bool { return r.URL.Query().Has("dl") }.
+func WithAttachment(fn func(r *http.Request) bool) FileServerOption {
+	return func(s *fileServer) {
+		s.attachment = fn
+	}
+}
+
+// WithPreview makes FileServer serve preview images of media files
+// instead of their contents, which saves downloading whole photos and
+// videos for thumbnails. Fn returns preview size for the request (see
+// WithPreviewSize), empty size means contents. Files without previews
+// are served as is.
+func WithPreview(fn func(r *http.Request) string) FileServerOption {
+	return func(s *fileServer) {
+		s.preview = fn
+	}
+}
+
+// FileServer returns a handler that serves HTTP requests with the
+// contents of fsys. Content-Type of files is taken from the metadata
+// stored by Yandex Disk, so served files are never sniffed.
+// Requests for directories are answered with a simple HTML listing.
+// Files are served with ETag derived from their MD5 and Last-Modified,
+// and conditional requests are answered with 304 Not Modified without
+// downloading files. HEAD requests are answered from metadata, and
+// range requests download only the requested ranges.
+func FileServer(fsys FS, opts ...FileServerOption) http.Handler {
+	s := &fileServer{fsys: fsys}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := path.Clean("/" + r.URL.Path)
+	res, err := s.fsys.StatExtended(name)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	if res.IsDir() {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			// "./" keeps names like "a:b" from being parsed as schemes
+			target := url.URL{Path: "./" + path.Base(r.URL.Path) + "/"}
+			http.Redirect(w, r, target.String(), http.StatusMovedPermanently)
+			return
+		}
+		s.serveDir(w, name)
+		return
+	}
+	if s.preview != nil {
+		if size := s.preview(r); size != "" && s.servePreview(w, r, name, size, res.Modified) {
+			return
+		}
+	}
+	// conditional requests are answered before the file is downloaded
+	etag := resourceETag(res)
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if notModified(r, etag, res.Modified) {
+		w.Header().Set("Last-Modified", res.Modified.UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	content, err := openContent(r.Context(), s.fsys, name, res)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	defer content.Close()
+	// Content-Type is always set, as sniffing it would start a download
+	ctype := res.MimeType
+	if ctype == "" {
+		ctype = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", ctype)
+	if s.attachment != nil {
+		disposition := "inline"
+		if s.attachment(r) {
+			disposition = "attachment"
+		}
+		w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": path.Base(name)}))
+	}
+	http.ServeContent(w, r, name, res.Modified, content)
+}
+
+// servePreview replies with the preview image of the named file of the
+// given size. It reports false if the file has no preview and nothing
+// is written.
+func (s *fileServer) servePreview(w http.ResponseWriter, r *http.Request, name, size string, modtime time.Time) bool {
+	body, err := s.fsys.OpenPreview(r.Context(), name, size)
+	if errors.Is(err, ErrNoPreview) {
+		return false
+	}
+	if err != nil {
+		httpError(w, err)
+		return true
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		httpError(w, err)
+		return true
+	}
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	http.ServeContent(w, r, name, modtime, bytes.NewReader(data))
+	return true
+}
+
+// resourceETag returns entity tag of the resource derived from MD5 of
+// its contents or its revision, empty if neither is known.
+func resourceETag(res Resource) string {
+	switch {
+	case res.MD5 != "":
+		return `"` + res.MD5 + `"`
+	case res.Revision != 0:
+		return `"r` + strconv.FormatInt(res.Revision, 10) + `"`
+	}
+	return ""
+}
+
+// ETag returns entity tag of the file described by info as served by
+// FileServer, derived from MD5 of its contents or its revision. It is
+// empty unless info is returned by FS created WithFields requesting
+// MD5 or revision.
+func ETag(info fs.FileInfo) string {
+	if y, ok := info.(*ydinfo); ok {
+		return resourceETag(y.res)
+	}
+	return ""
+}
+
+// notModified reports whether conditional GET or HEAD request may be
+// answered with 304 Not Modified for resource with the given entity
+// tag and modification time. If-None-Match takes precedence over
+// If-Modified-Since as RFC 9110 requires.
+func notModified(r *http.Request, etag string, modtime time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || etag != "" && tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modtime.IsZero() && !modtime.Truncate(time.Second).After(since)
+}
+
+// serveDir writes HTML listing of the named directory.
+func (s *fileServer) serveDir(w http.ResponseWriter, name string) {
+	entries, err := s.fsys.ReadDir(name)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<!doctype html>\n<title>%s</title>\n<pre>\n", html.EscapeString(name))
+	for _, e := range entries {
+		base := path.Base(e.Name())
+		if e.IsDir() {
+			base += "/"
+		}
+		// "./" keeps names like "a:b" from being parsed as schemes
+		link := url.URL{Path: "./" + base}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", html.EscapeString(link.String()), html.EscapeString(base))
+	}
+	fmt.Fprintf(w, "</pre>\n")
+}
+
+// httpError replies with HTTP status matching err.
+func httpError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		http.Error(w, "404 page not found", http.StatusNotFound)
+	case errors.Is(err, fs.ErrPermission), errors.Is(err, ErrInfected):
+		http.Error(w, "403 Forbidden", http.StatusForbidden)
+	default:
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+	}
+}
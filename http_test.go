@@ -0,0 +1,231 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFileServer(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/site/style.css", []byte("body{}"))
+	md.put("/site/sub/page.html", []byte("<p>page</p>"))
+	srv := httptest.NewServer(FileServer(fsys))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/site/style.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/css; charset=utf-8" {
+		t.Errorf("unexpected response for css file: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	resp, err = http.Get(srv.URL + "/site/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body strings.Builder
+	_, _ = io.Copy(&body, resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(body.String(), `<a href="./sub/">sub/</a>`) {
+		t.Errorf("directory listing lacks subdirectory: %s", body.String())
+	}
+
+	resp, err = http.Get(srv.URL + "/nonexistent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("want 404 for nonexistent file, have %d", resp.StatusCode)
+	}
+}
+
+func TestFileServerConditional(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/a.txt", []byte("hello"))
+	srv := httptest.NewServer(FileServer(fsys))
+	defer srv.Close()
+
+	get := func(header, value string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/a.txt", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	resp := get("", "")
+	etag, modified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if etag != `"5d41402abc4b2a76b9719d911017c592"` || modified == "" {
+		t.Fatalf("unexpected validators: %q %q", etag, modified)
+	}
+
+	n := md.requests()
+	for _, h := range [][2]string{
+		{"If-None-Match", etag},
+		{"If-None-Match", `"other", W/` + etag},
+		{"If-Modified-Since", modified},
+	} {
+		if resp := get(h[0], h[1]); resp.StatusCode != http.StatusNotModified {
+			t.Errorf("%s: %s: want 304, have %d", h[0], h[1], resp.StatusCode)
+		}
+	}
+	if md.requests()-n != 3 {
+		t.Errorf("conditional requests make %d API requests, want 3", md.requests()-n)
+	}
+	for _, h := range [][2]string{
+		{"If-None-Match", `"other"`},
+		{"If-Modified-Since", "Mon, 02 Jan 2006 15:04:05 GMT"},
+	} {
+		if resp := get(h[0], h[1]); resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: %s: want 200, have %d", h[0], h[1], resp.StatusCode)
+		}
+	}
+}
+
+func TestFileServerDisposition(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/report 2024.pdf", []byte("%PDF-"))
+	srv := httptest.NewServer(FileServer(fsys, WithAttachment(func(r *http.Request) bool {
+		return r.URL.Query().Has("dl")
+	})))
+	defer srv.Close()
+
+	for query, want := range map[string]string{
+		"":    `inline; filename="report 2024.pdf"`,
+		"?dl": `attachment; filename="report 2024.pdf"`,
+	} {
+		resp, err := http.Get(srv.URL + "/docs/report%202024.pdf" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if have := resp.Header.Get("Content-Disposition"); have != want {
+			t.Errorf("query %q: want disposition %s, have %s", query, want, have)
+		}
+	}
+}
+
+func TestFileServerPreview(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/photos/cat.jpg", []byte("full photo"))
+	md.put("/photos/notes.txt", []byte("notes"))
+	srv := httptest.NewServer(FileServer(fsys, WithPreview(func(r *http.Request) string {
+		return r.URL.Query().Get("preview")
+	})))
+	defer srv.Close()
+
+	get := func(p string) (*http.Response, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp, string(data)
+	}
+	resp, body := get("/photos/cat.jpg?preview=M")
+	if resp.StatusCode != http.StatusOK || body != mockPreviewHeader+"M" {
+		t.Errorf("unexpected preview: %d %q", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "image/png" {
+		t.Errorf("want preview of type image/png, have %s", ct)
+	}
+	if _, body := get("/photos/cat.jpg"); body != "full photo" {
+		t.Errorf("want contents without preview size, have %q", body)
+	}
+	if _, body := get("/photos/notes.txt?preview=M"); body != "notes" {
+		t.Errorf("want contents of file without preview, have %q", body)
+	}
+
+	if _, err := fsys.OpenPreview(context.Background(), "/photos/notes.txt", "M"); !errors.Is(err, ErrNoPreview) {
+		t.Errorf("want ErrNoPreview, have %v", err)
+	}
+}
+
+func TestFileServerHeadAndRange(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/a.txt", []byte("hello, world"))
+	srv := httptest.NewServer(FileServer(fsys))
+	defer srv.Close()
+
+	n := md.requests()
+	resp, err := http.Head(srv.URL + "/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ContentLength != 12 {
+		t.Errorf("unexpected response to HEAD: %d, length %d", resp.StatusCode, resp.ContentLength)
+	}
+	if got := md.requests() - n; got != 1 {
+		t.Errorf("HEAD made %d requests, want metadata request only", got)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/a.txt", nil)
+	req.Header.Set("Range", "bytes=7-11")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent || string(data) != "world" {
+		t.Errorf("unexpected response to range request: %d %q", resp.StatusCode, data)
+	}
+}
+
+func TestFileServerColonNames(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/a:b/c:d.txt", []byte("x"))
+	srv := httptest.NewServer(FileServer(fsys))
+	defer srv.Close()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	resp, err := client.Get(srv.URL + "/a:b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if loc := resp.Header.Get("Location"); loc != "/a:b/" {
+		t.Errorf("redirect to %q", loc)
+	}
+	resp, err = client.Get(srv.URL + "/a:b/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body strings.Builder
+	_, _ = io.Copy(&body, resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(body.String(), `<a href="./c:d.txt">c:d.txt</a>`) {
+		t.Errorf("listing has link parsed as scheme: %s", body.String())
+	}
+}
+
+func TestETag(t *testing.T) {
+	fsys, md := newMockFS(t, WithFields("md5"))
+	md.put("/a.txt", []byte("hello"))
+	info, err := fsys.Stat("/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if etag := ETag(info); etag != `"5d41402abc4b2a76b9719d911017c592"` {
+		t.Errorf("unexpected etag %q", etag)
+	}
+	if info, err = fsys.Stat("/"); err != nil || ETag(info) != "" {
+		t.Errorf("want empty etag of directory, have %q %v", ETag(info), err)
+	}
+}
@@ -0,0 +1,66 @@
+package ydfs
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// instantUploadMinSize is the size starting from which uploads
+// are offered to the uploader by hashes of their contents.
+const instantUploadMinSize = 4 << 20
+
+// WithoutInstantUpload turns off instant uploads. By default uploads of
+// 4MiB and more (by WriteFile, Sync of files and WriteFileStream of
+// io.ReadSeeker) carry MD5 and SHA256 of their contents in Etag and
+// Sha256 headers and expect 100-continue: if the uploader already has
+// the contents it creates the file without the body being sent,
+// otherwise the body is uploaded as usual in the same request. Streams
+// have to be read twice for that, once to compute the hashes.
+func WithoutInstantUpload() Option {
+	return func(o *options) {
+		o.noInstant = true
+	}
+}
+
+// contentHashes are hashes of upload contents.
+type contentHashes struct {
+	size   int64
+	md5    string
+	sha256 string
+}
+
+func (h *contentHashes) setHeaders(header http.Header) {
+	header.Set("Etag", h.md5)
+	header.Set("Sha256", h.sha256)
+	header.Set("Size", strconv.FormatInt(h.size, 10))
+	header.Set("Expect", "100-continue")
+}
+
+// hashBytes computes hashes of data.
+func hashBytes(data []byte) *contentHashes {
+	m, s := md5.Sum(data), sha256.Sum256(data)
+	return &contentHashes{size: int64(len(data)), md5: hex.EncodeToString(m[:]), sha256: hex.EncodeToString(s[:])}
+}
+
+// hashReadSeeker computes hashes of contents of rs from its current
+// offset to the end and rewinds it back. It returns size of contents.
+func hashReadSeeker(rs io.ReadSeeker) (int64, *contentHashes, error) {
+	start, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	m, s := md5.New(), sha256.New()
+	n, err := io.Copy(io.MultiWriter(m, s), rs)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	if _, err := rs.Seek(start, io.SeekStart); err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	return n, &contentHashes{size: n, md5: hex.EncodeToString(m.Sum(nil)), sha256: hex.EncodeToString(s.Sum(nil))}, nil
+}
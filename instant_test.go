@@ -0,0 +1,70 @@
+package ydfs
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// rewindCounter counts bytes read after the last Seek.
+type rewindCounter struct {
+	*bytes.Reader
+	read int
+}
+
+func (r *rewindCounter) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += n
+	return n, err
+}
+
+func (r *rewindCounter) Seek(offset int64, whence int) (int64, error) {
+	r.read = 0
+	return r.Reader.Seek(offset, whence)
+}
+
+func TestInstantUpload(t *testing.T) {
+	fsys, md := newMockFS(t)
+	data := bytes.Repeat([]byte("0123456789abcdef"), instantUploadMinSize/16)
+	md.put("/known.bin", data)
+
+	r := &rewindCounter{Reader: bytes.NewReader(data)}
+	if err := fsys.WriteFileStream("/copy.bin", r); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := md.get("/copy.bin"); !ok || !bytes.Equal(e.data, data) {
+		t.Fatal("known contents are not uploaded")
+	}
+	if r.read != 0 {
+		t.Errorf("%d bytes of known contents are sent", r.read)
+	}
+	if err := fsys.WriteFile("/copy2.bin", data); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := md.get("/copy2.bin"); !ok || !bytes.Equal(e.data, data) {
+		t.Fatal("known contents are not written by WriteFile")
+	}
+
+	// unknown contents fall back to the usual upload
+	other := append([]byte("x"), data...)
+	r = &rewindCounter{Reader: bytes.NewReader(other)}
+	if err := fsys.WriteFileStream("/other.bin", r); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := md.get("/other.bin"); !ok || !bytes.Equal(e.data, other) {
+		t.Fatal("unknown contents are not uploaded")
+	}
+	if r.read != len(other) {
+		t.Errorf("%d bytes of %d are sent", r.read, len(other))
+	}
+
+	plain, md := newMockFS(t, WithoutInstantUpload())
+	md.put("/known.bin", data)
+	r = &rewindCounter{Reader: bytes.NewReader(data)}
+	if err := plain.WriteFileStream("/copy.bin", io.Reader(r)); err != nil {
+		t.Fatal(err)
+	}
+	if r.read != len(data) {
+		t.Errorf("instant upload is used when turned off")
+	}
+}
@@ -0,0 +1,91 @@
+package ydfs
+
+import (
+	"context"
+	"io/fs"
+	"iter"
+	"path"
+	"strings"
+)
+
+// dirPageSize is number of directory entries requested by ReadDirIter at once.
+var dirPageSize = 1000
+
+// ReadDirIter implements FS
+func (y *ydfs) ReadDirIter(ctx context.Context, name string) iter.Seq2[fs.DirEntry, error] {
+	fullname := y.fullPath(name)
+	return func(yield func(fs.DirEntry, error) bool) {
+		ctx, cancel := y.bind(ctx)
+		defer cancel()
+		stopped := false
+		err := y.client.listDir(ctx, fullname, y.sort, dirPageSize, func(res Resource) bool {
+			if !yield(y.opts.info(res), nil) {
+				stopped = true
+			}
+			return !stopped
+		})
+		if err != nil && !stopped {
+			yield(nil, &fs.PathError{Op: "readdirent", Path: name, Err: err})
+		}
+	}
+}
+
+// WalkIter implements FS
+func (y *ydfs) WalkIter(ctx context.Context, root string, filters ...*Filter) (iter.Seq2[string, fs.DirEntry], func() error) {
+	var walkErr error
+	seq := func(yield func(string, fs.DirEntry) bool) {
+		walkErr = nil
+		ctx, cancel := y.bind(ctx)
+		defer cancel()
+		res, err := y.client.getResourceMinTraffic(ctx, y.fullPath(root))
+		if err != nil {
+			walkErr = &fs.PathError{Op: "walk", Path: root, Err: err}
+			return
+		}
+		y.client.normalize(&res)
+		if !yield(root, y.opts.info(res)) || !res.IsDir() {
+			return
+		}
+		prefix := strings.TrimSuffix(res.Path, "/") + "/"
+		rel := func(p string) string {
+			return path.Join(root, strings.TrimPrefix(p, prefix))
+		}
+		match := func(file Resource) bool {
+			for _, f := range filters {
+				if !f.Match(strings.TrimPrefix(file.Path, prefix), y.opts.info(file)) {
+					return false
+				}
+			}
+			return true
+		}
+		// directories already yielded
+		seen := map[string]bool{res.Path: true}
+		stopped := false
+		err = y.client.listFiles(ctx, filesPageSize, y.client.fields, func(file Resource) bool {
+			y.client.normalize(&file)
+			if !strings.HasPrefix(file.Path, prefix) || !match(file) {
+				return true
+			}
+			var dirs []string
+			for d := path.Dir(file.Path); !seen[d]; d = path.Dir(d) {
+				seen[d] = true
+				dirs = append(dirs, d)
+			}
+			for i := len(dirs) - 1; i >= 0; i-- {
+				dir := Resource{Name: path.Base(dirs[i]), Path: dirs[i], Type: TypeDir}
+				if !yield(rel(dirs[i]), y.opts.info(dir)) {
+					stopped = true
+					return false
+				}
+			}
+			if !yield(rel(file.Path), y.opts.info(file)) {
+				stopped = true
+			}
+			return !stopped
+		})
+		if err != nil && !stopped {
+			walkErr = &fs.PathError{Op: "walk", Path: root, Err: err}
+		}
+	}
+	return seq, func() error { return walkErr }
+}
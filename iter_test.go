@@ -0,0 +1,104 @@
+package ydfs
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"testing"
+)
+
+func TestReadDirIter(t *testing.T) {
+	defer func(n int) { dirPageSize = n }(dirPageSize)
+	dirPageSize = 3
+	fsys, md := newMockFS(t)
+	for i := 0; i < 10; i++ {
+		md.put(fmt.Sprintf("/dir/f%02d", i), []byte("x"))
+	}
+
+	n := md.requests()
+	var names []string
+	for e, err := range fsys.ReadDirIter(context.Background(), "/dir") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, path.Base(e.Name()))
+	}
+	if len(names) != 10 || names[0] != "f00" || names[9] != "f09" {
+		t.Errorf("iterated over %v", names)
+	}
+	if md.requests()-n != 4 {
+		t.Errorf("iteration sends %d requests, want 4 pages", md.requests()-n)
+	}
+
+	n = md.requests()
+	count := 0
+	for range fsys.ReadDirIter(context.Background(), "/dir") {
+		if count++; count == 2 {
+			break
+		}
+	}
+	if md.requests()-n != 1 {
+		t.Errorf("stopped iteration sends %d requests, want 1", md.requests()-n)
+	}
+
+	for _, name := range []string{"/missing", "/dir/f00"} {
+		var errs int
+		for e, err := range fsys.ReadDirIter(context.Background(), name) {
+			if err == nil || e != nil {
+				t.Errorf("%s: iteration yields %v, %v", name, e, err)
+			}
+			errs++
+		}
+		if errs != 1 {
+			t.Errorf("%s: iteration yields %d errors", name, errs)
+		}
+	}
+}
+
+func TestWalkIter(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/other.txt", []byte("o"))
+	md.put("/photos/a.jpg", []byte("a"))
+	md.put("/photos/2020/01/b.jpg", []byte("b"))
+	md.put("/photos/2020/c.jpg", []byte("c"))
+	md.put("/photos/empty/x", nil)
+	md.update("/photos/empty/x", func(e *mockEntry) { e.dir = true })
+
+	sub, err := fsys.Sub("/photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq, walkErr := sub.WalkIter(context.Background(), "/")
+	var got []string
+	for p, e := range seq {
+		if e.IsDir() {
+			p += "/"
+		}
+		got = append(got, p)
+	}
+	if err := walkErr(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"//", "/2020/", "/2020/01/", "/2020/01/b.jpg", "/2020/c.jpg", "/a.jpg"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("walk yields %v, want %v", got, want)
+	}
+
+	count := 0
+	for range seq {
+		if count++; count == 3 {
+			break
+		}
+	}
+	if count != 3 || walkErr() != nil {
+		t.Errorf("stopped walk: count %d, err %v", count, walkErr())
+	}
+
+	seq, walkErr = fsys.WalkIter(context.Background(), "/missing")
+	for p := range seq {
+		t.Errorf("walk of missing root yields %s", p)
+	}
+	if walkErr() == nil {
+		t.Error("walk of missing root returns no error")
+	}
+}
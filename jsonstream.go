@@ -0,0 +1,116 @@
+package ydfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// requestItems sends GET request to url and decodes JSON object of the
+// response into result except for the array found by the path of keys
+// (e.g. "_embedded", "items"). Items of the array are passed to fn as
+// they are decoded, so that huge listings are never held in memory
+// whole. Decoding stops if fn returns false, result is incomplete then.
+func (c *apiclient) requestItems(ctx context.Context, url string, keys []string, result interface{}, fn func(*json.Decoder) (bool, error)) error {
+	r, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	acceptGzip(r)
+	ctx, cancel := withTimeout(ctx, c.metadataTimeout)
+	defer cancel()
+	resp, err := c.send(ctx, r, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	dec := json.NewDecoder(resp.Body)
+	rest, stopped, err := decodeItems(dec, keys, fn)
+	if err != nil {
+		return decodeError(err)
+	}
+	if stopped {
+		return nil
+	}
+	if err := json.Unmarshal(rest, result); err != nil {
+		return fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	return nil
+}
+
+// decodeItems decodes JSON object from dec passing items of the array
+// found by the path of keys to fn. It returns the object without the
+// array. Stopped is true if fn returned false.
+func decodeItems(dec *json.Decoder, keys []string, fn func(*json.Decoder) (bool, error)) (rest json.RawMessage, stopped bool, err error) {
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, false, err
+	}
+	fields := make(map[string]json.RawMessage)
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, false, err
+		}
+		key, _ := t.(string)
+		if len(keys) == 0 || key != keys[0] {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return nil, false, err
+			}
+			fields[key] = raw
+			continue
+		}
+		if len(keys) > 1 {
+			raw, stopped, err := decodeItems(dec, keys[1:], fn)
+			if err != nil || stopped {
+				return nil, stopped, err
+			}
+			fields[key] = raw
+			continue
+		}
+		if err := expectDelim(dec, '['); err != nil {
+			return nil, false, err
+		}
+		for dec.More() {
+			if ok, err := fn(dec); err != nil || !ok {
+				return nil, !ok, err
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return nil, false, err
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, false, err
+	}
+	rest, err = json.Marshal(fields)
+	return rest, false, err
+}
+
+// expectDelim reads delimiter d from dec.
+func expectDelim(dec *json.Decoder, d json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if t != d {
+		return fmt.Errorf("%w: unexpected %v in JSON, want %v", ErrInternal, t, d)
+	}
+	return nil
+}
+
+// decodeError converts error decoding response body: malformed JSON
+// is an internal error, failure to read it is a network one.
+func decodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, ErrNetwork), errors.Is(err, ErrInternal):
+		return err
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	return fmt.Errorf("%w: %w", ErrNetwork, err)
+}
@@ -0,0 +1,65 @@
+package ydfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDecodeItems(t *testing.T) {
+	// items go before other fields of the embedded object
+	data := `{"_embedded":{"items":[{"name":"a"},{"name":"b"},{"name":"c"}],"total":3},"type":"dir","name":"docs"}`
+	var names []string
+	rest, stopped, err := decodeItems(json.NewDecoder(strings.NewReader(data)), []string{"_embedded", "items"}, func(dec *json.Decoder) (bool, error) {
+		var item Resource
+		err := dec.Decode(&item)
+		names = append(names, item.Name)
+		return true, err
+	})
+	if err != nil || stopped {
+		t.Fatalf("decodeItems returns %v, %v", stopped, err)
+	}
+	if strings.Join(names, ",") != "a,b,c" {
+		t.Errorf("got items %v", names)
+	}
+	var res Resource
+	if err := json.Unmarshal(rest, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Type != "dir" || res.Name != "docs" || res.Embedded.Total != 3 || len(res.Embedded.Items) != 0 {
+		t.Errorf("got rest of object %+v", res)
+	}
+
+	_, stopped, err = decodeItems(json.NewDecoder(strings.NewReader(data)), []string{"_embedded", "items"}, func(dec *json.Decoder) (bool, error) {
+		return false, dec.Decode(&json.RawMessage{})
+	})
+	if err != nil || !stopped {
+		t.Errorf("decodeItems stopped by fn returns %v, %v", stopped, err)
+	}
+
+	_, _, err = decodeItems(json.NewDecoder(strings.NewReader(data[:40])), []string{"_embedded", "items"}, func(dec *json.Decoder) (bool, error) {
+		return true, dec.Decode(&json.RawMessage{})
+	})
+	if err = decodeError(err); !errors.Is(err, ErrNetwork) && !errors.Is(err, ErrInternal) {
+		t.Errorf("truncated JSON returns %v", err)
+	}
+}
+
+func TestListDirStopsEarly(t *testing.T) {
+	defer func(n int) { dirPageSize = n }(dirPageSize)
+	dirPageSize = 2
+	fsys, md := newMockFS(t)
+	for i := 0; i < 10; i++ {
+		md.put(fmt.Sprintf("/dir/%d", i), nil)
+	}
+	before := md.requests()
+	for range fsys.ReadDirIter(context.Background(), "/dir") {
+		break
+	}
+	if n := md.requests() - before; n != 1 {
+		t.Errorf("stopped iteration sends %d requests, want 1", n)
+	}
+}
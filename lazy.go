@@ -0,0 +1,72 @@
+package ydfs
+
+import (
+	"context"
+	"strings"
+)
+
+// WithLazyInit makes New and NewAppFolder return FS without checking the
+// token and reachability of the API. The check is made with the first
+// request of FS instead, so construction neither adds latency nor fails
+// when the API is briefly unavailable. See also Validate.
+func WithLazyInit() Option {
+	return func(o *options) {
+		o.lazyInit = true
+	}
+}
+
+// initKey marks context of requests made by initialization.
+type initKey struct{}
+
+// ensureInit initializes client unless it is already done. Requests
+// wait for the initialization made by a concurrent request.
+func (c *apiclient) ensureInit(ctx context.Context) error {
+	if c.init == nil || ctx.Value(initKey{}) != nil {
+		return nil
+	}
+	c.initMu.Lock()
+	defer c.initMu.Unlock()
+	if c.initDone {
+		return nil
+	}
+	return c.runInit(ctx)
+}
+
+// runInit runs initialization of the client. Must be called with initMu held.
+func (c *apiclient) runInit(ctx context.Context) error {
+	if err := c.init(context.WithValue(ctx, initKey{}, true)); err != nil {
+		return err
+	}
+	c.initDone = true
+	return nil
+}
+
+// validate initializes the client even if it is already done.
+func (c *apiclient) validate(ctx context.Context) error {
+	if c.init == nil {
+		return c.ping(ctx)
+	}
+	c.initMu.Lock()
+	defer c.initMu.Unlock()
+	return c.runInit(ctx)
+}
+
+// Validate implements FS
+func (y *ydfs) Validate(ctx context.Context) error {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	return y.client.validate(ctx)
+}
+
+// initAppFolder checks the token fetching the app folder, which tells
+// where the folder is, since the API may report its paths as disk paths.
+func (c *apiclient) initAppFolder(ctx context.Context) error {
+	res, err := c.getResourceMinTraffic(ctx, "/")
+	if err != nil {
+		return err
+	}
+	if p := strings.TrimPrefix(res.Path, "disk:"); p != res.Path && p != "/" {
+		c.appRoot = p
+	}
+	return nil
+}
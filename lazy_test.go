@@ -0,0 +1,80 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithLazyInit(t *testing.T) {
+	md := newMockDisk()
+	client := newMockClient(t, md)
+	fsys, err := New("badtoken", client, WithLazyInit())
+	if err != nil {
+		t.Fatalf("lazy New checks token: %v", err)
+	}
+	if md.requests() != 0 {
+		t.Errorf("lazy New sends %d requests", md.requests())
+	}
+	if err := fsys.Validate(context.Background()); !errors.Is(err, ErrAPI) {
+		t.Errorf("Validate with invalid token returns %v", err)
+	}
+	if _, err := fsys.Stat("/"); err == nil {
+		t.Error("Stat with invalid token succeeds")
+	}
+
+	fsys, err = New("mocktoken", client, WithLazyInit())
+	if err != nil {
+		t.Fatal(err)
+	}
+	md.put("/a.txt", []byte("a"))
+	if _, err := fsys.Stat("/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Validate(context.Background()); err != nil {
+		t.Errorf("Validate returns %v", err)
+	}
+}
+
+func TestNewAppFolderLazy(t *testing.T) {
+	md := newMockDisk()
+	md.put(mockAppRoot+"/docs/a.txt", []byte("a"))
+	fsys, err := NewAppFolder("mocktoken", newMockClient(t, md), WithLazyInit())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.requests() != 0 {
+		t.Errorf("lazy NewAppFolder sends %d requests", md.requests())
+	}
+	// the app folder is found with the first request
+	entries, err := fsys.ReadDir("/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "a.txt" {
+		t.Errorf("got entries %v", entries)
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	md := newMockDisk()
+	md.setDelays(time.Second, 0)
+	client := newMockClient(t, md)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := NewContext(ctx, "mocktoken", client); !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, ErrNetwork) {
+		t.Errorf("NewContext with hung API returns %v", err)
+	}
+	if _, err := NewAppFolderContext(ctx, "mocktoken", client); err == nil {
+		t.Error("NewAppFolderContext with expired context succeeds")
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("NewContext is blocked for %v", d)
+	}
+	md.setDelays(0, 0)
+	if _, err := NewContext(context.Background(), "mocktoken", client); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,129 @@
+package ydfs
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// rateLimiter limits total throughput of all readers it wraps.
+type rateLimiter struct {
+	mu   sync.Mutex
+	rate int64     // bytes per second
+	next time.Time // moment when the next byte is allowed through
+}
+
+func newRateLimiter(bytesPerSec int64) *rateLimiter {
+	return &rateLimiter{rate: bytesPerSec}
+}
+
+// wait blocks until n bytes are allowed through or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(int64(n) * int64(time.Second) / l.rate))
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// chunk returns max number of bytes allowed in a single read so
+// that the transfer is smooth rather than bursty.
+func (l *rateLimiter) chunk() int {
+	c := l.rate / 10
+	if c < 1 {
+		c = 1
+	}
+	if c > 32<<10 {
+		c = 32 << 10
+	}
+	return int(c)
+}
+
+// reader returns r throttled by l. Reads waiting for their turn
+// fail when ctx is done.
+func (l *rateLimiter) reader(ctx context.Context, r io.Reader) io.Reader {
+	return &limitedReader{ctx: ctx, r: r, l: l}
+}
+
+// limitedReader is io.Reader throttled by rateLimiter.
+type limitedReader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *rateLimiter
+}
+
+func (lr *limitedReader) Read(b []byte) (int, error) {
+	if c := lr.l.chunk(); len(b) > c {
+		b = b[:c]
+	}
+	n, err := lr.r.Read(b)
+	if n > 0 {
+		if werr := lr.l.wait(lr.ctx, n); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return n, err
+}
+
+// WithMaxConcurrentTransfers limits the number of uploads and downloads
+// performed by FS and its sub FS at the same time to n. Transfers over
+// the limit wait for running ones to finish, so that thousands of
+// goroutines calling WriteFile do not flood the API and the network.
+// A download is running until its body is read and closed, e.g. until
+// a streaming file is closed. Copies between files of the same FS
+// take one place for both the download and the upload. Zero or
+// negative n means no limit.
+func WithMaxConcurrentTransfers(n int) Option {
+	return func(o *options) {
+		o.maxTransfers = n
+	}
+}
+
+// transferSlots is a semaphore limiting concurrent transfers.
+type transferSlots chan struct{}
+
+// transferSlotKey marks context of transfers made while the caller
+// holds a slot of the transferSlots stored by the key.
+type transferSlotKey struct{}
+
+// holdingSlot returns a copy of ctx for transfers made while the
+// caller holds a slot of s, e.g. uploads of downloaded contents,
+// which must not wait for another slot to avoid deadlocks.
+func (s transferSlots) holdingSlot(ctx context.Context) context.Context {
+	if s == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, transferSlotKey{}, s)
+}
+
+// acquire waits for a free slot and returns function releasing it.
+func (s transferSlots) acquire(ctx context.Context) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	if held, ok := ctx.Value(transferSlotKey{}).(transferSlots); ok && held == s {
+		return func() {}, nil
+	}
+	select {
+	case s <- struct{}{}:
+		var once sync.Once
+		return func() { once.Do(func() { <-s }) }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
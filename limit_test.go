@@ -0,0 +1,144 @@
+package ydfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(10000)
+	data := bytes.Repeat([]byte("x"), 3000)
+	start := time.Now()
+	read, err := io.ReadAll(l.reader(context.Background(), bytes.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, data) {
+		t.Fatal("limited reader corrupts data")
+	}
+	// first chunk passes immediately, the rest must be delayed
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("3000 bytes at 10000 B/s read in %v", elapsed)
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	l := newRateLimiter(1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := io.ReadAll(l.reader(ctx, bytes.NewReader(make([]byte, 1000))))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("read of cancelled transfer returns %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("cancelled read waits for %v", elapsed)
+	}
+}
+
+// closeRecorder records whether it is closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestBandwidthLimitClosesBody(t *testing.T) {
+	fsys, _ := newMockFS(t, WithBandwidthLimit(1<<20))
+	y := fsys.(*ydfs)
+	l, err := y.client.getUploadLink(context.Background(), y.client.apiPath("/a.txt"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := &closeRecorder{Reader: strings.NewReader("a")}
+	r, _ := http.NewRequest(l.Method, l.Href, body)
+	if _, err := y.client.transfer(context.Background(), r, http.StatusCreated); err != nil {
+		t.Fatal(err)
+	}
+	if !body.closed {
+		t.Error("request body is not closed")
+	}
+}
+
+func TestBandwidthLimitTransfers(t *testing.T) {
+	fsys, _ := newMockFS(t, WithBandwidthLimit(1<<20))
+	body := bytes.Repeat([]byte("y"), 1<<12)
+	if err := fsys.WriteFile("/big.txt", body); err != nil {
+		t.Fatal(err)
+	}
+	data, err := fsys.ReadFile("/big.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, body) {
+		t.Error("data read differs from data written")
+	}
+}
+
+func TestMaxConcurrentTransfers(t *testing.T) {
+	var (
+		mu                sync.Mutex
+		inFlight, maxSeen int
+	)
+	count := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if !strings.HasSuffix(r.URL.Host, ".mock") {
+				return next.RoundTrip(r)
+			}
+			mu.Lock()
+			inFlight++
+			maxSeen = max(maxSeen, inFlight)
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			resp, err := next.RoundTrip(r)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return resp, err
+		})
+	}
+	fsys, _ := newMockFS(t, WithMaxConcurrentTransfers(2), WithMiddleware(count))
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := fmt.Sprintf("/f%d.txt", i)
+			if err := fsys.WriteFile(name, []byte(name)); err != nil {
+				t.Error(err)
+				return
+			}
+			if data, err := fsys.ReadFile(name); err != nil || string(data) != name {
+				t.Errorf("ReadFile returned %q, %v", data, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if maxSeen != 2 {
+		t.Errorf("%d transfers ran at once, want 2", maxSeen)
+	}
+
+	// a copy within the same FS takes one place
+	fsys, md := newMockFS(t, WithMaxConcurrentTransfers(1))
+	md.put("/src.txt", []byte("data"))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := CopyBetween(ctx, fsys, "/src.txt", fsys, "/dst.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := md.get("/dst.txt"); !ok || string(data.data) != "data" {
+		t.Errorf("copy is not made")
+	}
+}
@@ -0,0 +1,161 @@
+package ydfs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// expandTemplate expands URI template of templated link as defined by
+// RFC 6570. Simple ({var}), reserved ({+var}), fragment ({#var}), path
+// ({/var}), query ({?var}) and query continuation ({&var}) expressions
+// are supported; prefix and explode modifiers are not. Undefined
+// variables are omitted.
+func expandTemplate(tmpl string, vars map[string]string) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			b.WriteString(tmpl)
+			return b.String(), nil
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("%w: unterminated expression in URI template %q", ErrInternal, tmpl)
+		}
+		b.WriteString(tmpl[:start])
+		if err := expandExpression(&b, tmpl[start+1:start+end], vars); err != nil {
+			return "", fmt.Errorf("%w: URI template %q: %v", ErrInternal, tmpl, err)
+		}
+		tmpl = tmpl[start+end+1:]
+	}
+}
+
+// expandExpression writes expansion of a single template expression
+// without braces to b.
+func expandExpression(b *strings.Builder, expr string, vars map[string]string) error {
+	op := byte(0)
+	if expr != "" && strings.IndexByte("+#/?&", expr[0]) >= 0 {
+		op, expr = expr[0], expr[1:]
+	}
+	first, sep, named := "", ",", false
+	switch op {
+	case '#':
+		first = "#"
+	case '/':
+		first, sep = "/", "/"
+	case '?':
+		first, sep, named = "?", "&", true
+	case '&':
+		first, sep, named = "&", "&", true
+	}
+	escape := url.QueryEscape
+	if op == '+' || op == '#' {
+		escape = escapeReserved
+	} else if !named {
+		escape = url.PathEscape
+	}
+	n := 0
+	for _, name := range strings.Split(expr, ",") {
+		if name == "" || strings.ContainsAny(name, ":*") {
+			return fmt.Errorf("unsupported expression {%c%s}", op, expr)
+		}
+		value, ok := vars[name]
+		if !ok {
+			continue
+		}
+		if n == 0 {
+			b.WriteString(first)
+		} else {
+			b.WriteString(sep)
+		}
+		n++
+		if named {
+			b.WriteString(name + "=")
+		}
+		b.WriteString(escape(value))
+	}
+	return nil
+}
+
+// escapeReserved escapes value leaving characters reserved in URIs
+// as they are.
+func escapeReserved(value string) string {
+	var b strings.Builder
+	for _, c := range []byte(value) {
+		if c > ' ' && c < 0x7f && strings.IndexByte(`"%<>\^`+"`"+`{|}`, c) < 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// resolve expands template of l with vars if l is templated, so that
+// l can be followed as is.
+func (l *link) resolve(vars map[string]string) error {
+	if !l.Templated {
+		return nil
+	}
+	href, err := expandTemplate(l.Href, vars)
+	if err != nil {
+		return err
+	}
+	l.Href, l.Templated = href, false
+	return nil
+}
+
+// uploadFormField is the name of form field holding file contents
+// in multipart uploads.
+const uploadFormField = "file"
+
+// multipartBody wraps contents of file of the given size named name
+// into multipart/form-data body for uploads by POST. It returns the
+// body, its size (negative if size is unknown) and content type.
+func multipartBody(name string, data io.Reader, size int64) (io.Reader, int64, string, error) {
+	var head bytes.Buffer
+	mw := multipart.NewWriter(&head)
+	if _, err := mw.CreateFormFile(uploadFormField, name); err != nil {
+		return nil, 0, "", fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	headSize := head.Len()
+	if err := mw.Close(); err != nil {
+		return nil, 0, "", fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	// Close writes the closing boundary after the part header, which
+	// is sent after the contents instead
+	tail := head.Bytes()[headSize:]
+	body := io.MultiReader(bytes.NewReader(head.Bytes()[:headSize]), data, bytes.NewReader(tail))
+	if size >= 0 {
+		size += int64(headSize + len(tail))
+	}
+	return body, size, mw.FormDataContentType(), nil
+}
+
+// newUploadRequest creates request uploading data of the given size to
+// link l. Contents are sent as multipart form if the link requires POST.
+func newUploadRequest(l link, name string, data io.Reader, size int64) (*http.Request, error) {
+	contentType := ""
+	if l.Method == http.MethodPost {
+		var err error
+		if data, size, contentType, err = multipartBody(path.Base(name), data, size); err != nil {
+			return nil, err
+		}
+	}
+	r, err := http.NewRequest(l.Method, l.Href, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	// body is wrapped in transfer, so content length has to be set explicitly
+	r.ContentLength = size
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r, nil
+}
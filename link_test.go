@@ -0,0 +1,99 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	vars := map[string]string{"path": "disk:/a b/c.txt", "overwrite": "true", "id": "x/y"}
+	for tmpl, want := range map[string]string{
+		"https://host/upload":                      "https://host/upload",
+		"https://host/upload{?path,overwrite}":     "https://host/upload?path=disk%3A%2Fa+b%2Fc.txt&overwrite=true",
+		"https://host/upload?v=1{&overwrite,none}": "https://host/upload?v=1&overwrite=true",
+		"https://host/files/{id}":                  "https://host/files/x%2Fy",
+		"https://host/files{/id}":                  "https://host/files/x%2Fy",
+		"https://host/files/{+id}":                 "https://host/files/x/y",
+		"https://host/{none}upload{?none}":         "https://host/upload",
+	} {
+		have, err := expandTemplate(tmpl, vars)
+		if err != nil {
+			t.Errorf("%s: %v", tmpl, err)
+		} else if have != want {
+			t.Errorf("%s: want %s, have %s", tmpl, want, have)
+		}
+	}
+	for _, tmpl := range []string{"https://host/{path", "https://host/{path:3}", "https://host/{?list*}"} {
+		if _, err := expandTemplate(tmpl, vars); !errors.Is(err, ErrInternal) {
+			t.Errorf("%s: want ErrInternal, have %v", tmpl, err)
+		}
+	}
+}
+
+func TestUploadTemplatedLink(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/old.txt", []byte("old"))
+	for _, method := range []string{http.MethodPut, http.MethodPost} {
+		md.mu.Lock()
+		md.uploadLink = link{Href: "https://uploader.mock/upload{?path,overwrite}", Method: method, Templated: true}
+		md.mu.Unlock()
+		if err := fsys.WriteFile("/docs/new-"+method+".txt", []byte("contents")); err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		if e, ok := md.get("/docs/new-" + method + ".txt"); !ok || string(e.data) != "contents" {
+			t.Errorf("%s: file is not uploaded", method)
+		}
+		if err := fsys.WriteFile("/docs/old.txt", []byte(method)); err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		if e, _ := md.get("/docs/old.txt"); string(e.data) != method {
+			t.Errorf("%s: file is not overwritten", method)
+		}
+	}
+}
+
+func TestLinkResolve(t *testing.T) {
+	l := link{Href: "https://downloader.mock/public{?public_key,path}", Method: http.MethodGet, Templated: true}
+	if err := l.resolve(map[string]string{"public_key": "pk", "path": "/a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://downloader.mock/public?public_key=pk&path=%2Fa.txt"; l.Href != want || l.Templated {
+		t.Errorf("want resolved link %s, have %+v", want, l)
+	}
+	// links which are not templated are kept as is
+	l = link{Href: "https://host/{path}"}
+	if err := l.resolve(map[string]string{"path": "x"}); err != nil || l.Href != "https://host/{path}" {
+		t.Errorf("link is changed: %+v, %v", l, err)
+	}
+}
+
+func TestDownloadTemplatedLink(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("contents"))
+	md.downloadLink = link{Href: "https://downloader.mock/download{?path}", Method: http.MethodGet, Templated: true}
+
+	if data, err := fsys.ReadFile("/docs/a.txt"); err != nil || string(data) != "contents" {
+		t.Errorf("ReadFile returns %q, %v", data, err)
+	}
+	f, err := fsys.Open("/docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil || string(data) != "contents" {
+		t.Errorf("Open reads %q, %v", data, err)
+	}
+	local := filepath.Join(t.TempDir(), "a.txt")
+	if err := fsys.DownloadToFile(context.Background(), "/docs/a.txt", local, false); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(local); string(data) != "contents" {
+		t.Errorf("downloaded %q", data)
+	}
+}
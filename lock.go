@@ -0,0 +1,86 @@
+package ydfs
+
+import (
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockProperty is the custom property holding advisory lock of a resource.
+const lockProperty = "ydfs_lock"
+
+// lockFields are fields of resource needed to check its lock.
+var lockFields = []string{"custom_properties", "revision"}
+
+// formatLock returns value of lockProperty held by owner until expires.
+func formatLock(owner string, expires time.Time) string {
+	return strconv.FormatInt(expires.UnixMilli(), 10) + ":" + owner
+}
+
+// parseLock returns owner of the lock and its expiration time. It
+// returns false if value is not a lock.
+func parseLock(value string) (string, time.Time, bool) {
+	ms, owner, ok := strings.Cut(value, ":")
+	if !ok {
+		return "", time.Time{}, false
+	}
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return owner, time.UnixMilli(n), true
+}
+
+// TryLock implements FS. The API has no conditional updates, so the
+// lock is written and then read back: it is taken if the resource is
+// not changed since the write. Workers writing their locks at the same
+// moment may still all see their own writes and take the lock.
+func (y *ydfs) TryLock(name, owner string, ttl time.Duration) (bool, error) {
+	ctx, fullname := y.context(), y.fullPath(name)
+	res, err := y.client.getResource(ctx, fullname, 0, lockFields...)
+	if err != nil {
+		return false, &fs.PathError{Op: "lock", Path: name, Err: err}
+	}
+	if holder, expires, ok := parseLock(res.CustomProperties[lockProperty]); ok && holder != owner && time.Now().Before(expires) {
+		return false, nil
+	}
+	value := formatLock(owner, time.Now().Add(ttl))
+	patched, err := y.client.patchProperties(ctx, fullname, map[string]*string{lockProperty: &value})
+	y.opts.auditRecord(AuditLock, fullname, 0, err)
+	if err != nil {
+		return false, &fs.PathError{Op: "lock", Path: name, Err: err}
+	}
+	// the lock is taken only if the resource is not updated since our
+	// write, which is read again without sharing a request sent before
+	res, err = y.client.getResource(unshared(ctx), fullname, 0, lockFields...)
+	if err != nil {
+		return false, &fs.PathError{Op: "lock", Path: name, Err: err}
+	}
+	return res.Revision == patched.Revision && res.CustomProperties[lockProperty] == value, nil
+}
+
+// Unlock implements FS
+func (y *ydfs) Unlock(name, owner string) error {
+	ctx, fullname := y.context(), y.fullPath(name)
+	res, err := y.client.getResource(ctx, fullname, 0, lockFields...)
+	if err != nil {
+		return &fs.PathError{Op: "unlock", Path: name, Err: err}
+	}
+	holder, expires, ok := parseLock(res.CustomProperties[lockProperty])
+	switch {
+	case !ok:
+		return nil
+	case holder != owner && time.Now().Before(expires):
+		return &fs.PathError{Op: "unlock", Path: name, Err: ErrLocked}
+	case holder != owner:
+		// expired lock of another owner is left to be taken over
+		return nil
+	}
+	_, err = y.client.patchProperties(ctx, fullname, map[string]*string{lockProperty: nil})
+	y.opts.auditRecord(AuditUnlock, fullname, 0, err)
+	if err != nil {
+		return &fs.PathError{Op: "unlock", Path: name, Err: err}
+	}
+	return nil
+}
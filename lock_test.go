@@ -0,0 +1,87 @@
+package ydfs
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTryLock(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/shared/job.txt", []byte("job"))
+
+	for _, tc := range []struct {
+		owner string
+		ttl   time.Duration
+		want  bool
+	}{
+		{"a", time.Minute, true},
+		{"b", time.Minute, false},
+		{"a", time.Hour, true}, // extended by the holder
+	} {
+		if ok, err := fsys.TryLock("/shared/job.txt", tc.owner, tc.ttl); err != nil || ok != tc.want {
+			t.Fatalf("TryLock by %s: want %v, have %v %v", tc.owner, tc.want, ok, err)
+		}
+	}
+	if err := fsys.Unlock("/shared/job.txt", "b"); !errors.Is(err, ErrLocked) {
+		t.Errorf("want ErrLocked unlocking lock of another owner, have %v", err)
+	}
+	if err := fsys.Unlock("/shared/job.txt", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := md.get("/shared/job.txt"); len(e.props) != 0 {
+		t.Errorf("lock property is kept: %v", e.props)
+	}
+	if err := fsys.Unlock("/shared/job.txt", "a"); err != nil {
+		t.Errorf("Unlock of unlocked resource returns %v", err)
+	}
+
+	// expired locks are taken over
+	if ok, err := fsys.TryLock("/shared", "b", -time.Second); err != nil || !ok {
+		t.Fatalf("TryLock of directory: %v %v", ok, err)
+	}
+	if ok, err := fsys.TryLock("/shared", "a", time.Minute); err != nil || !ok {
+		t.Errorf("expired lock is not taken over: %v %v", ok, err)
+	}
+	if err := fsys.Unlock("/shared", "b"); !errors.Is(err, ErrLocked) {
+		t.Errorf("want ErrLocked unlocking lock taken over, have %v", err)
+	}
+
+	if _, err := fsys.TryLock("/missing", "a", time.Minute); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound, have %v", err)
+	}
+}
+
+func TestLockAudit(t *testing.T) {
+	var records []AuditRecord
+	fsys, md := newMockFS(t, WithPathPolicy("/ro", PathReadOnly), WithAuditFunc(func(r AuditRecord) {
+		records = append(records, r)
+	}))
+	md.put("/job.txt", []byte("job"))
+	md.put("/ro/job.txt", []byte("job"))
+	if ok, err := fsys.TryLock("/job.txt", "a", time.Minute); err != nil || !ok {
+		t.Fatalf("TryLock: %v %v", ok, err)
+	}
+	if err := fsys.Unlock("/job.txt", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.TryLock("/ro/job.txt", "a", time.Minute); err == nil {
+		t.Fatal("TryLock of read-only resource succeeds")
+	}
+	want := []struct {
+		op, path string
+		ok       bool
+	}{
+		{AuditLock, "/job.txt", true},
+		{AuditUnlock, "/job.txt", true},
+		{AuditLock, "/ro/job.txt", false},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("want %d records, have %d: %v", len(want), len(records), records)
+	}
+	for i, w := range want {
+		if r := records[i]; r.Op != w.op || r.Path != w.path || (r.Err == nil) != w.ok {
+			t.Errorf("record %d: want %+v, have %+v", i, w, r)
+		}
+	}
+}
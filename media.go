@@ -0,0 +1,67 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// SystemFolderPhotostream is the key of the camera uploads folder
+// in the map returned by SystemFolders.
+const SystemFolderPhotostream = "photostream"
+
+// mediaNameLayout is the layout of names given to camera uploads
+// by Yandex Disk apps.
+const mediaNameLayout = "2006-01-02 15-04-05"
+
+// SystemFolders implements FS
+func (y *ydfs) SystemFolders(ctx context.Context) (map[string]string, error) {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	info, err := y.client.getDiskInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folders := make(map[string]string, len(info.SystemFolders))
+	for key, p := range info.SystemFolders {
+		folders[key] = path.Clean("/" + strings.TrimPrefix(p, "disk:"))
+	}
+	return folders, nil
+}
+
+// UploadMedia implements FS
+func (y *ydfs) UploadMedia(ctx context.Context, name string, r io.Reader, taken time.Time) (string, error) {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	folders, err := y.SystemFolders(ctx)
+	if err != nil {
+		return "", &fs.PathError{Op: "upload", Path: name, Err: err}
+	}
+	dir, ok := folders[SystemFolderPhotostream]
+	if !ok {
+		return "", &fs.PathError{Op: "upload", Path: name, Err: fmt.Errorf("%w: no %s system folder", ErrNotFound, SystemFolderPhotostream)}
+	}
+	ext := strings.ToLower(path.Ext(name))
+	base := taken.Format(mediaNameLayout)
+	for i := 0; ; i++ {
+		dst := path.Join(dir, base+ext)
+		if i > 0 {
+			dst = path.Join(dir, fmt.Sprintf("%s_%d%s", base, i, ext))
+		}
+		// conflicts are reported before the contents are read, so
+		// the next name can be tried with the same reader
+		err := y.upload(ctx, dst, false, r, -1)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", &fs.PathError{Op: "upload", Path: name, Err: err}
+		}
+		return dst, nil
+	}
+}
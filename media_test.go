@@ -0,0 +1,49 @@
+package ydfs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestSystemFolders(t *testing.T) {
+	fsys, _ := newMockFS(t)
+	folders, err := fsys.SystemFolders(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := folders[SystemFolderPhotostream]; got != "/Camera" {
+		t.Errorf("photostream folder is %q, want /Camera", got)
+	}
+}
+
+func TestUploadMedia(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", nil)
+	md.put("/Camera/old.jpg", nil)
+	sub, err := fsys.Sub("/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	taken := time.Date(2021, 7, 4, 18, 30, 5, 0, time.UTC)
+	for i, want := range []string{
+		"/Camera/2021-07-04 18-30-05.jpg",
+		"/Camera/2021-07-04 18-30-05_1.jpg",
+		"/Camera/2021-07-04 18-30-05_2.jpg",
+	} {
+		content := []byte{'a' + byte(i)}
+		// a reader that can't be rewound is not read on conflicts
+		got, err := sub.UploadMedia(context.Background(), "IMG_0001.JPG", io.MultiReader(bytes.NewReader(content)), taken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("media is uploaded to %q, want %q", got, want)
+		}
+		if e, ok := md.get(want); !ok || !bytes.Equal(e.data, content) {
+			t.Errorf("%s has wrong contents", want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"strconv"
+)
+
+// ErrNoPreview is returned by OpenPreview for files the disk
+// makes no previews of, e.g. documents and archives.
+var ErrNoPreview = errors.New("no preview available")
+
+// QueryOption adds optional parameters to metadata requests
+// of StatExtended and ReadDirExtended.
+type QueryOption func(url.Values)
+
+// WithPreviewSize requests preview links of the given size. Size is
+// either one of predefined values ("S", "M", "L", "XL", "XXL", "XXXL")
+// or exact dimensions, e.g. "120x", "x240" or "120x240".
+func WithPreviewSize(size string) QueryOption {
+	return func(v url.Values) {
+		v.Set("preview_size", size)
+	}
+}
+
+// WithPreviewCrop makes the API crop previews to exactly the size
+// requested with WithPreviewSize instead of scaling them.
+func WithPreviewCrop() QueryOption {
+	return func(v url.Values) {
+		v.Set("preview_crop", "true")
+	}
+}
+
+// StatExtended implements FS
+func (y *ydfs) StatExtended(name string, opts ...QueryOption) (Resource, error) {
+	if err := checkName("stat", name); err != nil {
+		return Resource{}, err
+	}
+	fullname := y.fullPath(name)
+	v := make(url.Values)
+	v.Set("limit", "0")
+	for _, opt := range opts {
+		opt(v)
+	}
+	res, err := y.client.getResourceQuery(y.context(), fullname, v)
+	if err != nil {
+		return Resource{}, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	y.client.normalize(&res)
+	return res, nil
+}
+
+// ReadDirExtended implements FS
+func (y *ydfs) ReadDirExtended(name string, opts ...QueryOption) ([]Resource, error) {
+	fullname := y.fullPath(name)
+	v := make(url.Values)
+	v.Set("limit", strconv.Itoa((1<<31)-1))
+	if y.sort != "" {
+		v.Set("sort", y.sort)
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	res, err := y.client.getResourceQuery(y.context(), fullname, v)
+	if err != nil {
+		return []Resource{}, &fs.PathError{Op: "readdirent", Path: name, Err: err}
+	}
+	if !res.IsDir() {
+		return []Resource{}, &fs.PathError{Op: "readdirent", Path: name, Err: ErrNotDir}
+	}
+	y.client.normalize(&res)
+	return res.Embedded.Items, nil
+}
+
+// OpenPreview implements FS
+func (y *ydfs) OpenPreview(ctx context.Context, name, size string) (io.ReadCloser, error) {
+	ctx, cancel := y.bind(ctx)
+	fullname := y.fullPath(name)
+	v := make(url.Values)
+	v.Set("limit", "0")
+	v.Set("fields", "type,preview")
+	WithPreviewSize(size)(v)
+	res, err := y.client.getResourceQuery(ctx, fullname, v)
+	if err == nil && res.IsDir() {
+		err = ErrIsDir
+	} else if err == nil && res.PreviewLink == "" {
+		err = ErrNoPreview
+	}
+	if err != nil {
+		cancel()
+		return nil, &fs.PathError{Op: "preview", Path: name, Err: err}
+	}
+	body, err := y.client.getPreview(ctx, res.PreviewLink)
+	if err != nil {
+		cancel()
+		return nil, &fs.PathError{Op: "preview", Path: name, Err: err}
+	}
+	return &readCloser{Reader: body, close: func() error {
+		defer cancel()
+		return body.Close()
+	}}, nil
+}
@@ -0,0 +1,28 @@
+package ydfs
+
+import "testing"
+
+func TestStatExtendedPreview(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/photos/cat.jpg", []byte("meow"))
+	res, err := fsys.StatExtended("/photos/cat.jpg", WithPreviewSize("120x240"), WithPreviewCrop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Path != "/photos/cat.jpg" || res.Size != 4 {
+		t.Errorf("unexpected resource: %+v", res)
+	}
+	if want := "https://preview.mock/?size=120x240&crop=true"; res.PreviewLink != want {
+		t.Errorf("want preview %s, have %s", want, res.PreviewLink)
+	}
+	items, err := fsys.ReadDirExtended("/photos", WithPreviewSize("S"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].PreviewLink != "https://preview.mock/?size=S&crop=" {
+		t.Errorf("unexpected listing: %+v", items)
+	}
+	if _, err := fsys.ReadDirExtended("/photos/cat.jpg"); err == nil {
+		t.Error("ReadDirExtended succeeds on a file")
+	}
+}
@@ -0,0 +1,48 @@
+package ydfs
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// filesPageSize is the number of items requested per page
+// of flat files listing.
+const filesPageSize = 1000
+
+// OpenWithContentType implements FS
+func (y *ydfs) OpenWithContentType(name string) (fs.File, string, error) {
+	file, res, err := y.open(y.context(), name, os.O_RDONLY, mergeFields(y.client.fields, []string{"mime_type"}))
+	if err != nil {
+		return nil, "", err
+	}
+	return file, res.MimeType, nil
+}
+
+// FilesByMimeType implements FS
+func (y *ydfs) FilesByMimeType(ctx context.Context, prefix string) ([]Resource, error) {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	var result []Resource
+	fields := mergeFields(y.client.fields, []string{"mime_type"})
+	err := y.client.listFiles(ctx, filesPageSize, fields, func(res Resource) bool {
+		y.client.normalize(&res)
+		if y.inside(res.Path) && strings.HasPrefix(res.MimeType, prefix) {
+			result = append(result, res)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, &fs.PathError{Op: "list", Path: prefix, Err: err}
+	}
+	return result, nil
+}
+
+// inside reports whether full path p belongs to the tree of FS.
+func (y *ydfs) inside(p string) bool {
+	if !y.issub {
+		return true
+	}
+	return p == y.path || strings.HasPrefix(p, strings.TrimSuffix(y.path, "/")+"/")
+}
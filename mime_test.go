@@ -0,0 +1,50 @@
+package ydfs
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestOpenWithContentType(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/index.html", []byte("<p>hi</p>"))
+	file, ctype, err := fsys.OpenWithContentType("/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if ctype != "text/html; charset=utf-8" {
+		t.Errorf("unexpected content type: %q", ctype)
+	}
+	data, err := io.ReadAll(file)
+	if err != nil || string(data) != "<p>hi</p>" {
+		t.Errorf("reading opened file returned %q, %v", data, err)
+	}
+}
+
+func TestFilesByMimeType(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/a.png", []byte("a"))
+	md.put("/b.json", []byte("{}"))
+	md.put("/dir/c.jpg", []byte("c"))
+	md.put("/other/d.gif", []byte("d"))
+	images, err := fsys.FilesByMimeType(context.Background(), "image/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 3 {
+		t.Errorf("want 3 images, have %+v", images)
+	}
+	sub, err := fsys.Sub("/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	images, err = sub.FilesByMimeType(context.Background(), "image/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 1 || images[0].Path != "/dir/c.jpg" {
+		t.Errorf("sub FS returns files outside of its tree: %+v", images)
+	}
+}
@@ -0,0 +1,970 @@
+package ydfs
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockDisk is an in-memory imitation of Yandex Disk REST API
+// good enough to run tests without network access and a token.
+type mockDisk struct {
+	mu      sync.Mutex
+	entries map[string]*mockEntry // keyed by clean absolute path
+	public  map[string]string     // public key to path of published resource
+	views   map[string]int        // downloads of public resources by public key
+	ops     map[string]string     // status of async operations by id
+	peers   []*mockDisk           // disks whose public resources can be saved to this one
+	trash   map[string]*mockEntry // trashed resources keyed by path in trash
+
+	asyncDelete bool            // respond to deletion of directories with 202 Accepted
+	opCounter   int             // number of async operations started
+	autoFinish  map[string]bool // operations which succeed after the first status check
+
+	metaDelay     time.Duration // delay before answering API requests
+	transferDelay time.Duration // delay before answering uploads and downloads
+	queries       []url.Values  // query of every API request received
+	uploads       int           // number of uploads received
+	failCode      int           // status of error to answer the next API request with
+	failName      string        // name of the error
+	retryAfter    string        // Retry-After header of the failed response
+	scope         string        // access of token: "app" for app folder only, "read" for read-only, full if empty
+	revision      int64         // revision of the disk bumped by every change
+	uploadLink    link          // templated link and method returned for uploads if set
+	downloadLink  link          // templated link returned for downloads if set
+}
+
+type mockEntry struct {
+	dir       bool
+	data      []byte
+	modified  time.Time
+	antivirus string
+	share     string            // rights to shared folder, empty if not shared
+	owned     bool              // shared folder belongs to the disk owner
+	origin    string            // path the trashed resource was deleted from
+	id        string            // resource id kept across moves, see resourceID
+	props     map[string]string // custom properties
+	revision  int64             // bumped by updates of custom properties
+}
+
+// resourceID returns id of the resource as reported by the API.
+func (e *mockEntry) resourceID() string {
+	if e.id != "" {
+		return e.id
+	}
+	return fmt.Sprintf("%p", e)
+}
+
+func newMockDisk() *mockDisk {
+	return &mockDisk{
+		entries: map[string]*mockEntry{"/": {dir: true, modified: time.Now()}},
+		public:  map[string]string{},
+		views:   map[string]int{},
+		ops:     map[string]string{},
+		trash:   map[string]*mockEntry{"/": {dir: true, modified: time.Now()}},
+
+		autoFinish: map[string]bool{},
+	}
+}
+
+// rewriteTransport sends all requests to the test server
+// regardless of the host requested.
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t *rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// mockPreviewHeader starts images served by links to previews,
+// followed by the requested size.
+const mockPreviewHeader = "\x89PNG\r\n\x1a\n"
+
+// newMockFS starts mock server and returns FS talking to it.
+// mockAppRoot is disk path of the application folder (app:/).
+const mockAppRoot = "/Applications/mockapp"
+
+// newMockClient starts test server for md and returns client sending
+// all requests to it.
+func newMockClient(t testing.TB, md *mockDisk) *http.Client {
+	t.Helper()
+	srv := httptest.NewServer(md)
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+	return &http.Client{Transport: &rewriteTransport{target: target}}
+}
+
+func newMockFS(t testing.TB, opts ...Option) (FS, *mockDisk) {
+	t.Helper()
+	md := newMockDisk()
+	fsys, err := New("mocktoken", newMockClient(t, md), opts...)
+	if err != nil {
+		t.Fatalf("error creating mock filesystem: %v", err)
+	}
+	return fsys, md
+}
+
+// put stores file at p creating parent directories.
+func (md *mockDisk) put(p string, data []byte) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	for dir := path.Dir(p); dir != "/"; dir = path.Dir(dir) {
+		if _, ok := md.entries[dir]; !ok {
+			md.entries[dir] = &mockEntry{dir: true, modified: time.Now()}
+		}
+	}
+	md.entries[p] = &mockEntry{data: data, modified: time.Now()}
+	md.revision++
+}
+
+func (md *mockDisk) setDelays(meta, transfer time.Duration) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.metaDelay = meta
+	md.transferDelay = transfer
+}
+
+// update calls fn for entry at p with mu held.
+func (md *mockDisk) update(p string, fn func(e *mockEntry)) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	fn(md.entries[p])
+}
+
+func (md *mockDisk) get(p string) (*mockEntry, bool) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	e, ok := md.entries[p]
+	return e, ok
+}
+
+// addPeer lets resources published on peer be saved to md.
+func (md *mockDisk) addPeer(peer *mockDisk) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.peers = append(md.peers, peer)
+}
+
+// requests returns number of requests received so far.
+func (md *mockDisk) requests() int {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	return len(md.queries)
+}
+
+// contentBySHA256 returns copy of contents of a file with the given hash.
+func (md *mockDisk) contentBySHA256(hash string) ([]byte, bool) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	for _, e := range md.entries {
+		if !e.dir && fmt.Sprintf("%x", sha256.Sum256(e.data)) == hash {
+			return append([]byte(nil), e.data...), true
+		}
+	}
+	return nil, false
+}
+
+// uploadCount returns number of uploads received so far.
+func (md *mockDisk) uploadCount() int {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	return md.uploads
+}
+
+func (md *mockDisk) setScope(scope string) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.scope = scope
+}
+
+// denied reports whether request is not allowed by scope of the token.
+func (md *mockDisk) denied(r *http.Request) bool {
+	md.mu.Lock()
+	scope := md.scope
+	md.mu.Unlock()
+	if !strings.HasPrefix(r.URL.Path, "/v1/disk/") || strings.HasPrefix(r.URL.Path, "/v1/disk/public/") {
+		return false
+	}
+	switch scope {
+	case "app":
+		return !strings.HasPrefix(r.URL.Query().Get("path"), "app:")
+	case "read":
+		return r.Method != http.MethodGet || r.URL.Path == "/v1/disk/resources/upload"
+	}
+	return false
+}
+
+// failNext makes md answer the next request with error.
+func (md *mockDisk) failNext(code int, name string) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.failCode, md.failName = code, name
+}
+
+func (md *mockDisk) lastQuery() url.Values {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	if len(md.queries) == 0 {
+		return nil
+	}
+	return md.queries[len(md.queries)-1]
+}
+
+func mockError(w http.ResponseWriter, code int, name string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": name, "message": name, "description": name})
+}
+
+func mockJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// cleanAPIPath strips disk: scheme from path parameter
+// and translates app:/ paths to the application folder.
+func cleanAPIPath(p string) string {
+	if strings.HasPrefix(p, "app:") {
+		return path.Join(mockAppRoot, path.Clean("/"+strings.TrimPrefix(p, "app:")))
+	}
+	p = strings.TrimPrefix(p, "disk:")
+	return path.Clean("/" + p)
+}
+
+func (md *mockDisk) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	md.mu.Lock()
+	md.queries = append(md.queries, q)
+	delay := md.metaDelay
+	if r.URL.Path == "/upload" || r.URL.Path == "/download" {
+		delay = md.transferDelay
+	}
+	md.mu.Unlock()
+	time.Sleep(delay)
+	md.mu.Lock()
+	code, name, retry := md.failCode, md.failName, md.retryAfter
+	md.failCode = 0
+	md.mu.Unlock()
+	if code != 0 {
+		if retry != "" {
+			w.Header().Set("Retry-After", retry)
+		}
+		mockError(w, code, name)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/v1/") && !strings.HasPrefix(r.URL.Path, "/v1/disk/public/") && r.Header.Get("Authorization") != "OAuth mocktoken" {
+		mockError(w, http.StatusUnauthorized, "UnauthorizedError")
+		return
+	}
+	if md.denied(r) {
+		mockError(w, http.StatusForbidden, "ForbiddenError")
+		return
+	}
+	p := cleanAPIPath(q.Get("path"))
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		md.mu.Lock()
+		md.revision++
+		md.mu.Unlock()
+	}
+	switch {
+	case r.Host == "preview.mock":
+		if r.Header.Get("Authorization") != "OAuth mocktoken" {
+			mockError(w, http.StatusUnauthorized, "UnauthorizedError")
+			return
+		}
+		w.Write(append([]byte(mockPreviewHeader), q.Get("size")...))
+	case r.URL.Path == "/v1/disk" && r.Method == http.MethodGet:
+		md.mu.Lock()
+		revision := md.revision
+		md.mu.Unlock()
+		mockJSON(w, http.StatusOK, map[string]interface{}{
+			"revision":       revision,
+			"total_space":    1 << 30,
+			"user":           map[string]string{"login": "mock"},
+			"system_folders": map[string]string{"photostream": "disk:/Camera/", "downloads": "disk:/Downloads/"},
+		})
+	case r.URL.Path == "/v1/disk/resources" && r.Method == http.MethodGet:
+		md.serveResource(w, p, q)
+	case r.URL.Path == "/v1/disk/resources" && r.Method == http.MethodPut:
+		md.serveMkdir(w, p)
+	case r.URL.Path == "/v1/disk/resources" && r.Method == http.MethodPatch:
+		md.servePatch(w, r, p, q)
+	case r.URL.Path == "/v1/disk/resources" && r.Method == http.MethodDelete:
+		md.serveDelete(w, p, q.Get("permanently") == "true")
+	case r.URL.Path == "/v1/disk/trash/resources/restore" && r.Method == http.MethodPut:
+		md.serveRestore(w, path.Clean("/"+strings.TrimPrefix(q.Get("path"), "trash:")), q.Get("name"), q.Get("overwrite") == "true")
+	case r.URL.Path == "/v1/disk/trash/resources" && r.Method == http.MethodDelete:
+		md.servePurge(w, path.Clean("/"+strings.TrimPrefix(q.Get("path"), "trash:")))
+	case r.URL.Path == "/v1/disk/trash/resources" && r.Method == http.MethodGet:
+		md.serveTrash(w, path.Clean("/"+strings.TrimPrefix(q.Get("path"), "trash:")), q)
+	case strings.HasPrefix(r.URL.Path, "/v1/disk/operations/"):
+		md.mu.Lock()
+		id := path.Base(r.URL.Path)
+		status, ok := md.ops[id]
+		if ok && md.autoFinish[id] {
+			md.ops[id] = "success"
+		}
+		md.mu.Unlock()
+		if !ok {
+			mockError(w, http.StatusNotFound, "DiskNotFoundError")
+			return
+		}
+		mockJSON(w, http.StatusOK, map[string]string{"status": status})
+	case r.URL.Path == "/v1/disk/public/resources":
+		md.servePublic(w, q)
+	case r.URL.Path == "/v1/disk/public/resources/download":
+		md.mu.Lock()
+		root, ok := md.public[q.Get("public_key")]
+		md.views[q.Get("public_key")]++
+		md.mu.Unlock()
+		if !ok {
+			mockError(w, http.StatusNotFound, "DiskNotFoundError")
+			return
+		}
+		mockJSON(w, http.StatusOK, map[string]string{
+			"href":   "https://downloader.mock/download?path=" + url.QueryEscape(path.Join(root, p)),
+			"method": http.MethodGet,
+		})
+	case r.URL.Path == "/v1/disk/resources/files":
+		md.serveFiles(w, q, false)
+	case r.URL.Path == "/v1/disk/resources/last-uploaded":
+		q.Set("sort", "-modified")
+		q.Set("offset", "0")
+		md.serveFiles(w, q, false)
+	case r.URL.Path == "/v1/disk/resources/public":
+		md.serveFiles(w, q, true)
+	case r.URL.Path == "/v1/disk/resources/download":
+		if e, ok := md.get(p); !ok || e.dir {
+			mockError(w, http.StatusNotFound, "DiskNotFoundError")
+			return
+		}
+		md.mu.Lock()
+		l := md.downloadLink
+		md.mu.Unlock()
+		if l.Href != "" {
+			mockJSON(w, http.StatusOK, l)
+			return
+		}
+		mockJSON(w, http.StatusOK, map[string]string{
+			"href":   "https://downloader.mock/download?path=" + url.QueryEscape(p),
+			"method": http.MethodGet,
+		})
+	case r.URL.Path == "/v1/disk/resources/upload":
+		if _, ok := md.get(p); ok && q.Get("overwrite") != "true" {
+			mockError(w, http.StatusConflict, "DiskResourceAlreadyExistsError")
+			return
+		}
+		if _, ok := md.get(path.Dir(p)); !ok {
+			mockError(w, http.StatusConflict, "DiskPathDoesntExistsError")
+			return
+		}
+		md.mu.Lock()
+		l := md.uploadLink
+		md.mu.Unlock()
+		if l.Href != "" {
+			mockJSON(w, http.StatusOK, l)
+			return
+		}
+		mockJSON(w, http.StatusOK, map[string]string{
+			"href":   "https://uploader.mock/upload?path=" + url.QueryEscape(p),
+			"method": http.MethodPut,
+		})
+	case r.URL.Path == "/upload" && r.Method == http.MethodPut && r.Header.Get("Sha256") != "":
+		if data, ok := md.contentBySHA256(r.Header.Get("Sha256")); ok {
+			// known contents: respond without reading the body
+			md.put(p, data)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		fallthrough
+	case r.URL.Path == "/upload" && r.Method == http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		md.put(p, data)
+		md.mu.Lock()
+		md.uploads++
+		md.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	case r.URL.Path == "/upload" && r.Method == http.MethodPost:
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		md.put(p, data)
+		md.mu.Lock()
+		md.uploads++
+		md.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	case r.URL.Path == "/download" && r.Method == http.MethodGet:
+		e, ok := md.get(p)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.ServeContent(w, r, path.Base(p), e.modified, bytes.NewReader(e.data))
+	case r.URL.Path == "/v1/disk/resources/publish" && r.Method == http.MethodPut:
+		md.servePublish(w, p, true)
+	case r.URL.Path == "/v1/disk/resources/unpublish" && r.Method == http.MethodPut:
+		md.servePublish(w, p, false)
+	case r.URL.Path == "/v1/disk/public/resources/save-to-disk" && r.Method == http.MethodPost:
+		md.serveSaveToDisk(w, q)
+	case r.URL.Path == "/v1/disk/resources/copy" && r.Method == http.MethodPost:
+		md.serveCopy(w, cleanAPIPath(q.Get("from")), p, q.Get("overwrite") == "true", false)
+	case r.URL.Path == "/v1/disk/resources/move" && r.Method == http.MethodPost:
+		from := cleanAPIPath(q.Get("from"))
+		if md.serveCopy(w, from, p, q.Get("overwrite") == "true", true) {
+			md.mu.Lock()
+			for k := range md.entries {
+				if k == from || strings.HasPrefix(k, from+"/") {
+					delete(md.entries, k)
+				}
+			}
+			md.mu.Unlock()
+		}
+	default:
+		mockError(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func (md *mockDisk) resourceJSON(p string, e *mockEntry, q url.Values) map[string]interface{} {
+	name := path.Base(p)
+	if p == "/" {
+		name = "disk"
+	}
+	res := map[string]interface{}{
+		"name":        name,
+		"path":        "disk:" + p,
+		"modified":    e.modified.Format(time.RFC3339),
+		"created":     e.modified.Format(time.RFC3339),
+		"resource_id": e.resourceID(),
+		"revision":    e.revision,
+	}
+	if len(e.props) > 0 {
+		res["custom_properties"] = e.props
+	}
+	for key, published := range md.public {
+		if published == p {
+			res["public_key"] = key
+			res["public_url"] = "https://yadi.sk/d/" + key
+		}
+	}
+	for dir := p; ; dir = path.Dir(dir) {
+		if d, ok := md.entries[dir]; ok && d.share != "" {
+			res["share"] = map[string]interface{}{"is_root": dir == p, "is_owned": d.owned, "rights": d.share}
+			break
+		}
+		if dir == "/" {
+			break
+		}
+	}
+	if e.dir {
+		res["type"] = "dir"
+	} else {
+		res["type"] = "file"
+		res["size"] = len(e.data)
+		res["md5"] = fmt.Sprintf("%x", md5.Sum(e.data))
+		res["sha256"] = fmt.Sprintf("%x", sha256.Sum256(e.data))
+		res["mime_type"] = mime.TypeByExtension(path.Ext(p))
+		if res["mime_type"] == "" {
+			res["mime_type"] = "application/octet-stream"
+		}
+		switch mt := res["mime_type"].(string); {
+		case strings.HasPrefix(mt, "image/"):
+			res["media_type"] = "image"
+		case strings.HasPrefix(mt, "video/"):
+			res["media_type"] = "video"
+		case strings.HasPrefix(mt, "audio/"):
+			res["media_type"] = "audio"
+		case strings.HasPrefix(mt, "text/"):
+			res["media_type"] = "text"
+		}
+		if e.antivirus != "" {
+			res["antivirus_status"] = e.antivirus
+		}
+		if size := q.Get("preview_size"); size != "" && res["media_type"] != nil && res["media_type"] != "text" {
+			res["preview"] = "https://preview.mock/?size=" + size + "&crop=" + q.Get("preview_crop")
+		}
+	}
+	return res
+}
+
+// children returns sorted paths of direct children of dir. Must be called with mu held.
+func (md *mockDisk) children(dir string) []string {
+	var result []string
+	for p := range md.entries {
+		if p != "/" && path.Dir(p) == dir {
+			result = append(result, p)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// sortPaths sorts paths as the API does for sort parameter. Must be called with mu held.
+func (md *mockDisk) sortPaths(paths []string, by string) {
+	desc := strings.HasPrefix(by, "-")
+	by = strings.TrimPrefix(by, "-")
+	less := func(a, b string) bool {
+		ea, eb := md.entries[a], md.entries[b]
+		switch by {
+		case "size":
+			return len(ea.data) < len(eb.data)
+		case "modified", "created":
+			return ea.modified.Before(eb.modified)
+		default:
+			return a < b
+		}
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		if desc {
+			return less(paths[j], paths[i])
+		}
+		return less(paths[i], paths[j])
+	})
+}
+
+func (md *mockDisk) serveResource(w http.ResponseWriter, p string, q url.Values) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	e, ok := md.entries[p]
+	if !ok {
+		mockError(w, http.StatusNotFound, "DiskNotFoundError")
+		return
+	}
+	res := md.resourceJSON(p, e, q)
+	if e.dir {
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		children := md.children(p)
+		md.sortPaths(children, q.Get("sort"))
+		items := []map[string]interface{}{}
+		for i := offset; i < len(children) && i < offset+limit; i++ {
+			items = append(items, md.resourceJSON(children[i], md.entries[children[i]], q))
+		}
+		res["_embedded"] = map[string]interface{}{
+			"items":  items,
+			"path":   "disk:" + p,
+			"limit":  limit,
+			"offset": offset,
+			"total":  len(children),
+			"sort":   "name",
+		}
+	}
+	mockJSON(w, http.StatusOK, res)
+}
+
+// servePublic serves metadata of published resources. Paths are
+// shown relative to the published resource.
+func (md *mockDisk) servePublic(w http.ResponseWriter, q url.Values) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	root, ok := md.public[q.Get("public_key")]
+	if !ok {
+		mockError(w, http.StatusNotFound, "DiskNotFoundError")
+		return
+	}
+	p := path.Join(root, cleanAPIPath(q.Get("path")))
+	e, ok := md.entries[p]
+	if !ok {
+		mockError(w, http.StatusNotFound, "DiskNotFoundError")
+		return
+	}
+	rel := func(abs string) string {
+		return path.Clean("/" + strings.TrimPrefix(abs, root))
+	}
+	res := md.resourceJSON(p, e, q)
+	res["path"] = rel(p)
+	res["public_key"] = q.Get("public_key")
+	res["views_count"] = md.views[q.Get("public_key")]
+	res["owner"] = map[string]string{"login": "mock", "display_name": "Mock User"}
+	if e.dir {
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		children := md.children(p)
+		items := []map[string]interface{}{}
+		for i := offset; i < len(children) && i < offset+limit; i++ {
+			item := md.resourceJSON(children[i], md.entries[children[i]], q)
+			item["path"] = rel(children[i])
+			items = append(items, item)
+		}
+		res["_embedded"] = map[string]interface{}{
+			"items":  items,
+			"path":   rel(p),
+			"limit":  limit,
+			"offset": offset,
+			"total":  len(children),
+		}
+	}
+	mockJSON(w, http.StatusOK, res)
+}
+
+func (md *mockDisk) serveFiles(w http.ResponseWriter, q url.Values, public bool) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	var files []string
+	if public {
+		for _, p := range md.public {
+			files = append(files, p)
+		}
+	} else {
+		for p, e := range md.entries {
+			if !e.dir {
+				files = append(files, p)
+			}
+		}
+	}
+	if types := q.Get("media_type"); types != "" {
+		matching := files[:0]
+		for _, p := range files {
+			if mt, ok := md.resourceJSON(p, md.entries[p], nil)["media_type"].(string); ok && slices.Contains(strings.Split(types, ","), mt) {
+				matching = append(matching, p)
+			}
+		}
+		files = matching
+	}
+	md.sortPaths(files, q.Get("sort"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	items := []map[string]interface{}{}
+	for i := offset; i < len(files) && i < offset+limit; i++ {
+		items = append(items, md.resourceJSON(files[i], md.entries[files[i]], q))
+	}
+	mockJSON(w, http.StatusOK, map[string]interface{}{"items": items, "limit": limit, "offset": offset})
+}
+
+func (md *mockDisk) serveMkdir(w http.ResponseWriter, p string) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	if _, ok := md.entries[p]; ok {
+		mockError(w, http.StatusConflict, "DiskPathPointsToExistentDirectoryError")
+		return
+	}
+	if _, ok := md.entries[path.Dir(p)]; !ok {
+		mockError(w, http.StatusConflict, "DiskPathDoesntExistsError")
+		return
+	}
+	md.entries[p] = &mockEntry{dir: true, modified: time.Now()}
+	mockJSON(w, http.StatusCreated, map[string]string{
+		"href":   "https://cloud-api.yandex.net/v1/disk/resources?path=" + url.QueryEscape("disk:"+p),
+		"method": http.MethodGet,
+	})
+}
+
+// startOperation registers async operation which finishes after the
+// first status check and returns link to it. Must be called with mu held.
+func (md *mockDisk) startOperation() map[string]string {
+	md.opCounter++
+	id := "op" + strconv.Itoa(md.opCounter)
+	md.ops[id] = "in-progress"
+	md.autoFinish[id] = true
+	return map[string]string{
+		"href":   "https://cloud-api.yandex.net/v1/disk/operations/" + id,
+		"method": http.MethodGet,
+	}
+}
+
+func (md *mockDisk) serveDelete(w http.ResponseWriter, p string, permanently bool) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	if _, ok := md.entries[p]; !ok {
+		mockError(w, http.StatusNotFound, "DiskNotFoundError")
+		return
+	}
+	async := md.asyncDelete && md.entries[p].dir
+	trashed := "/" + path.Base(p)
+	for i := 1; md.trash[trashed] != nil; i++ {
+		trashed = fmt.Sprintf("/%s_%d", path.Base(p), i)
+	}
+	for k, e := range md.entries {
+		if k == p || strings.HasPrefix(k, p+"/") {
+			if !permanently {
+				e.origin = k
+				md.trash[trashed+strings.TrimPrefix(k, p)] = e
+			}
+			delete(md.entries, k)
+		}
+	}
+	if async {
+		mockJSON(w, http.StatusAccepted, md.startOperation())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveRestore restores trashed resource at p to its origin directory
+// renamed to name if it is not empty. Directories are restored
+// asynchronously.
+func (md *mockDisk) serveRestore(w http.ResponseWriter, p, name string, overwrite bool) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	e, ok := md.trash[p]
+	if !ok || p == "/" {
+		mockError(w, http.StatusNotFound, "DiskNotFoundError")
+		return
+	}
+	dest := e.origin
+	if name != "" {
+		dest = path.Join(path.Dir(dest), name)
+	}
+	if _, ok := md.entries[dest]; ok && !overwrite {
+		mockError(w, http.StatusConflict, "DiskResourceAlreadyExistsError")
+		return
+	}
+	for k := range md.entries {
+		if k == dest || strings.HasPrefix(k, dest+"/") {
+			delete(md.entries, k)
+		}
+	}
+	for k, te := range md.trash {
+		if k == p || strings.HasPrefix(k, p+"/") {
+			te.origin = ""
+			md.entries[dest+strings.TrimPrefix(k, p)] = te
+			delete(md.trash, k)
+		}
+	}
+	if e.dir {
+		mockJSON(w, http.StatusAccepted, md.startOperation())
+		return
+	}
+	mockJSON(w, http.StatusCreated, map[string]string{"href": "https://cloud-api.yandex.net/v1/disk/resources?path=" + url.QueryEscape("disk:"+dest)})
+}
+
+// servePurge permanently deletes trashed resource at p.
+func (md *mockDisk) servePurge(w http.ResponseWriter, p string) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	if _, ok := md.trash[p]; !ok || p == "/" {
+		mockError(w, http.StatusNotFound, "DiskNotFoundError")
+		return
+	}
+	for k := range md.trash {
+		if k == p || strings.HasPrefix(k, p+"/") {
+			delete(md.trash, k)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveTrash serves metadata of trashed resources.
+func (md *mockDisk) serveTrash(w http.ResponseWriter, p string, q url.Values) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	e, ok := md.trash[p]
+	if !ok {
+		mockError(w, http.StatusNotFound, "DiskNotFoundError")
+		return
+	}
+	resource := func(p string, e *mockEntry) map[string]interface{} {
+		res := md.resourceJSON(p, e, q)
+		res["path"] = "trash:" + p
+		if e.origin != "" {
+			res["origin_path"] = "disk:" + e.origin
+			res["deleted"] = e.modified.Format(time.RFC3339)
+		}
+		if p == "/" {
+			res["name"] = "trash"
+		}
+		return res
+	}
+	res := resource(p, e)
+	if e.dir {
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		var children []string
+		for k := range md.trash {
+			if k != "/" && path.Dir(k) == p {
+				children = append(children, k)
+			}
+		}
+		sort.Strings(children)
+		items := []map[string]interface{}{}
+		for i := offset; i < len(children) && i < offset+limit; i++ {
+			items = append(items, resource(children[i], md.trash[children[i]]))
+		}
+		res["_embedded"] = map[string]interface{}{
+			"items":  items,
+			"path":   "trash:" + p,
+			"limit":  limit,
+			"offset": offset,
+			"total":  len(children),
+		}
+	}
+	mockJSON(w, http.StatusOK, res)
+}
+
+// servePatch updates custom properties of resource at p, null values
+// remove properties.
+func (md *mockDisk) servePatch(w http.ResponseWriter, r *http.Request, p string, q url.Values) {
+	if r.Header.Get("Content-Type") != "application/json" {
+		mockError(w, http.StatusUnsupportedMediaType, "UnsupportedMediaTypeError")
+		return
+	}
+	var body struct {
+		Props map[string]*string `json:"custom_properties"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		mockError(w, http.StatusBadRequest, "FieldValidationError")
+		return
+	}
+	md.mu.Lock()
+	e, ok := md.entries[p]
+	if ok {
+		if e.props == nil {
+			e.props = make(map[string]string)
+		}
+		for k, v := range body.Props {
+			if v == nil {
+				delete(e.props, k)
+			} else {
+				e.props[k] = *v
+			}
+		}
+		e.revision++
+	}
+	md.mu.Unlock()
+	if !ok {
+		mockError(w, http.StatusNotFound, "DiskNotFoundError")
+		return
+	}
+	md.serveResource(w, p, q)
+}
+
+// serveCopy copies resource at from with its children to p and reports
+// whether it is copied. Copies made for moves keep resource ids.
+func (md *mockDisk) serveCopy(w http.ResponseWriter, from, p string, overwrite, move bool) bool {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	if _, ok := md.entries[from]; !ok {
+		mockError(w, http.StatusNotFound, "DiskNotFoundError")
+		return false
+	}
+	if _, ok := md.entries[path.Dir(p)]; !ok {
+		mockError(w, http.StatusConflict, "DiskPathDoesntExistsError")
+		return false
+	}
+	if _, ok := md.entries[p]; ok && !overwrite {
+		mockError(w, http.StatusConflict, "DiskResourceAlreadyExistsError")
+		return false
+	}
+	for k, e := range md.entries {
+		if k == from || strings.HasPrefix(k, from+"/") {
+			c := *e
+			c.modified = time.Now()
+			if c.id = ""; move {
+				c.id = e.resourceID()
+			}
+			md.entries[p+strings.TrimPrefix(k, from)] = &c
+		}
+	}
+	mockJSON(w, http.StatusCreated, map[string]string{
+		"href":   "https://cloud-api.yandex.net/v1/disk/resources?path=" + url.QueryEscape("disk:"+p),
+		"method": http.MethodGet,
+	})
+	return true
+}
+
+// servePublish publishes or unpublishes resource at p.
+func (md *mockDisk) servePublish(w http.ResponseWriter, p string, publish bool) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	if _, ok := md.entries[p]; !ok {
+		mockError(w, http.StatusNotFound, "DiskNotFoundError")
+		return
+	}
+	key := "pk" + strings.ReplaceAll(p, "/", "-")
+	if publish {
+		md.public[key] = p
+	} else {
+		delete(md.public, key)
+	}
+	mockJSON(w, http.StatusOK, map[string]string{
+		"href":   "https://cloud-api.yandex.net/v1/disk/resources?path=" + url.QueryEscape("disk:"+p),
+		"method": http.MethodGet,
+	})
+}
+
+// publicTree returns copies of entries of resource published under key
+// keyed by path relative to the resource or nil if key is unknown.
+func (md *mockDisk) publicTree(key string) map[string]*mockEntry {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	root, ok := md.public[key]
+	if !ok {
+		return nil
+	}
+	tree := map[string]*mockEntry{}
+	for p, e := range md.entries {
+		if p == root || strings.HasPrefix(p, root+"/") {
+			c := *e
+			c.data = append([]byte(nil), e.data...)
+			tree[path.Clean("/"+strings.TrimPrefix(p, root))] = &c
+		}
+	}
+	return tree
+}
+
+// serveSaveToDisk copies public resource of md or its peers. Saving
+// of directories is reported as async operation.
+func (md *mockDisk) serveSaveToDisk(w http.ResponseWriter, q url.Values) {
+	md.mu.Lock()
+	disks := append([]*mockDisk{md}, md.peers...)
+	md.mu.Unlock()
+	var tree map[string]*mockEntry
+	for _, d := range disks {
+		if tree = d.publicTree(q.Get("public_key")); tree != nil {
+			break
+		}
+	}
+	if tree == nil {
+		mockError(w, http.StatusNotFound, "DiskNotFoundError")
+		return
+	}
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	dst := path.Join(cleanAPIPath(q.Get("save_path")), q.Get("name"))
+	if _, ok := md.entries[path.Dir(dst)]; !ok {
+		mockError(w, http.StatusConflict, "DiskPathDoesntExistsError")
+		return
+	}
+	for rel, e := range tree {
+		md.entries[path.Join(dst, rel)] = e
+	}
+	if tree["/"].dir {
+		mockJSON(w, http.StatusAccepted, md.startOperation())
+		return
+	}
+	mockJSON(w, http.StatusCreated, map[string]string{
+		"href":   "https://cloud-api.yandex.net/v1/disk/resources?path=" + url.QueryEscape("disk:"+dst),
+		"method": http.MethodGet,
+	})
+}
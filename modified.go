@@ -0,0 +1,48 @@
+package ydfs
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// ListModifiedSince implements FS
+func (y *ydfs) ListModifiedSince(ctx context.Context, since time.Time, limit int) ([]Resource, error) {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	if limit <= 0 {
+		return nil, &fs.PathError{Op: "modified", Path: "/", Err: fmt.Errorf("%w: limit must be positive", fs.ErrInvalid)}
+	}
+	prefix := strings.TrimSuffix(y.fullPath("/"), "/") + "/"
+	var changed []Resource
+	// files come from the most recently modified, so the listing
+	// is paged through until the first file not modified since
+	err := y.client.listFilesSorted(ctx, filesPageSize, "-modified", y.client.fields, func(file Resource) bool {
+		if !file.Modified.After(since) {
+			return false
+		}
+		y.client.normalize(&file)
+		if strings.HasPrefix(file.Path, prefix) {
+			changed = append(changed, file)
+		}
+		return ctx.Err() == nil
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, &fs.PathError{Op: "modified", Path: "/", Err: err}
+	}
+	// the oldest changes go first, files modified at the same time
+	// as the last one returned are never split between pages
+	result := make([]Resource, 0, len(changed))
+	for i := len(changed) - 1; i >= 0; i-- {
+		if len(result) >= limit && !changed[i].Modified.Equal(result[len(result)-1].Modified) {
+			break
+		}
+		result = append(result, changed[i])
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package ydfs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestListModifiedSince(t *testing.T) {
+	fsys, md := newMockFS(t)
+	base := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, p := range []string{"/a", "/docs/b", "/docs/c", "/docs/d", "/e"} {
+		md.put(p, []byte(p))
+		modified := base.Add(time.Duration(i) * time.Hour)
+		if p == "/docs/d" {
+			// modified at the same time as /docs/c
+			modified = base.Add(2 * time.Hour)
+		}
+		md.update(p, func(e *mockEntry) { e.modified = modified })
+	}
+
+	var got []string
+	since := base
+	for {
+		page, err := fsys.ListModifiedSince(context.Background(), since, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, res := range page {
+			got = append(got, res.Path)
+		}
+		since = page[len(page)-1].Modified
+	}
+	if len(got) != 4 || got[0] != "/docs/b" || got[3] != "/e" {
+		t.Errorf("got changed files %v", got)
+	}
+
+	sub, err := fsys.Sub("/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := sub.ListModifiedSince(context.Background(), base.Add(90*time.Minute), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 {
+		t.Errorf("got %d changed files of sub FS, want 2", len(page))
+	}
+	if _, err := fsys.ListModifiedSince(context.Background(), base, 0); err == nil {
+		t.Error("zero limit is accepted")
+	}
+}
@@ -0,0 +1,220 @@
+package ydfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Statuses of asynchronous operations as reported by the API.
+const (
+	OperationSuccess    = "success"
+	OperationFailed     = "failed"
+	OperationInProgress = "in-progress"
+)
+
+// Operation describes asynchronous operation (e.g. removal of a large
+// directory) started by FS, which has not been seen finished yet.
+type Operation struct {
+	ID      string    `json:"id"`
+	Href    string    `json:"href"`    // link to check status of the operation
+	Op      string    `json:"op"`      // operation name: "remove", "copy" etc.
+	Path    string    `json:"path"`    // full path of the resource
+	Started time.Time `json:"started"` // when the operation was started
+}
+
+// WithOperationsFile makes FS keep the list of pending asynchronous
+// operations in the named file, so that operations started before
+// a restart of the program can be reported with Operations and
+// checked with GetOperationStatus after the restart.
+func WithOperationsFile(name string) Option {
+	return func(o *options) {
+		o.operationsFile = name
+	}
+}
+
+// operationRegistry holds pending operations started by apiclient.
+type operationRegistry struct {
+	mu   sync.Mutex
+	ops  map[string]Operation
+	file string // file to persist registry to, not persisted if empty
+}
+
+// newOperationRegistry creates registry loading pending
+// operations from file (if not empty and exists).
+func newOperationRegistry(file string) (*operationRegistry, error) {
+	r := &operationRegistry{ops: make(map[string]Operation), file: file}
+	if file == "" {
+		return r, nil
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var ops []Operation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("%w: malformed operations file %s: %v", ErrInternal, file, err)
+	}
+	for _, op := range ops {
+		r.ops[op.ID] = op
+	}
+	return r, nil
+}
+
+func (r *operationRegistry) add(op Operation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops[op.ID] = op
+	r.save()
+}
+
+func (r *operationRegistry) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.ops[id]; !ok {
+		return
+	}
+	delete(r.ops, id)
+	r.save()
+}
+
+// list returns pending operations sorted by start time.
+func (r *operationRegistry) list() []Operation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ops := make([]Operation, 0, len(r.ops))
+	for _, op := range r.ops {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Started.Before(ops[j].Started) })
+	return ops
+}
+
+// save writes registry to its file. Must be called with mu held.
+// Registry is a best effort record, so errors are ignored.
+func (r *operationRegistry) save() {
+	if r.file == "" {
+		return
+	}
+	ops := make([]Operation, 0, len(r.ops))
+	for _, op := range r.ops {
+		ops = append(ops, op)
+	}
+	data, err := json.Marshal(ops)
+	if err != nil {
+		return
+	}
+	tmp := r.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return
+	}
+	os.Rename(tmp, r.file)
+}
+
+// registerOperation records operation started by request
+// which returned link l.
+func (c *apiclient) registerOperation(l link, op, name string) Operation {
+	id := l.OperationID
+	if id == "" {
+		id = path.Base(l.Href)
+	}
+	o := Operation{ID: id, Href: l.Href, Op: op, Path: name, Started: time.Now()}
+	c.operations.add(o)
+	return o
+}
+
+// getOperationStatus fetches status of operation identified by id.
+// Finished operations are removed from the registry.
+func (c *apiclient) getOperationStatus(ctx context.Context, id string) (string, error) {
+	var op operation
+	err := c.requestInterface(ctx, http.MethodGet, http.StatusOK, urlOperations+"/"+id, nil, &op)
+	if err != nil {
+		return "", err
+	}
+	if op.Status != OperationInProgress {
+		c.operations.remove(id)
+	}
+	return op.Status, nil
+}
+
+// Limits of the interval between status checks of a pending operation.
+// The interval grows exponentially from the min to the max value.
+var (
+	operationPollMin = 250 * time.Millisecond
+	operationPollMax = 15 * time.Second
+)
+
+// defaultOperationTimeout is how long FS waits for an
+// asynchronous operation to finish by default.
+const defaultOperationTimeout = 10 * time.Minute
+
+// WithOperationTimeout sets how long FS waits for asynchronous
+// operations (e.g. removal of large directories) to finish. If the
+// operation does not finish in time, the call returns ErrOperationPending
+// and the operation can be checked later with GetOperationStatus.
+// Default timeout is 10 minutes.
+func WithOperationTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.operationTimeout = d
+	}
+}
+
+// waitOperation polls status of operation at href until it finishes
+// using exponential backoff with jitter. It returns error if the operation
+// has failed or has not finished within operation timeout of the client.
+func (c *apiclient) waitOperation(ctx context.Context, href string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.operationTimeout)
+	defer cancel()
+	interval := operationPollMin
+	for {
+		var op operation
+		if err := c.requestInterface(ctx, http.MethodGet, http.StatusOK, href, nil, &op); err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("%w: %s", ErrOperationPending, href)
+			}
+			return err
+		}
+		switch op.Status {
+		case OperationSuccess:
+			c.operations.remove(path.Base(href))
+			return nil
+		case OperationFailed:
+			c.operations.remove(path.Base(href))
+			return fmt.Errorf("%w: operation %s failed", ErrAPI, href)
+		}
+		// sleeping for random duration within [interval/2, interval)
+		sleep := interval/2 + time.Duration(rand.Int63n(int64(interval/2)+1))
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("%w: %s", ErrOperationPending, href)
+			}
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+		if interval *= 2; interval > operationPollMax {
+			interval = operationPollMax
+		}
+	}
+}
+
+// GetOperationStatus implements FS
+func (y *ydfs) GetOperationStatus(id string) (string, error) {
+	return y.client.getOperationStatus(y.context(), id)
+}
+
+// Operations implements FS
+func (y *ydfs) Operations() []Operation {
+	return y.client.operations.list()
+}
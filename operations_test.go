@@ -0,0 +1,80 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOperationsRegistry(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ops.json")
+	fsys, md := newMockFS(t, WithOperationsFile(file))
+	md.ops["op1"] = OperationInProgress
+	md.ops["op2"] = OperationSuccess
+	y := fsys.(*ydfs)
+	y.client.registerOperation(link{Href: "https://cloud-api.yandex.net/v1/disk/operations/op1"}, "remove", "/big")
+	y.client.registerOperation(link{OperationID: "op2", Href: "https://cloud-api.yandex.net/v1/disk/operations/op2"}, "remove", "/other")
+
+	// registry survives restart
+	fsys, _ = newMockFS(t, WithOperationsFile(file))
+	ops := fsys.Operations()
+	if len(ops) != 2 || ops[0].ID != "op1" || ops[0].Path != "/big" || ops[1].ID != "op2" {
+		t.Fatalf("unexpected operations after restart: %+v", ops)
+	}
+
+	// status checks go to the first mock, so point the new FS there
+	y.client.operations = fsys.(*ydfs).client.operations
+	status, err := y.GetOperationStatus("op2")
+	if err != nil || status != OperationSuccess {
+		t.Fatalf("GetOperationStatus returned %q, %v", status, err)
+	}
+	status, err = y.GetOperationStatus("op1")
+	if err != nil || status != OperationInProgress {
+		t.Fatalf("GetOperationStatus returned %q, %v", status, err)
+	}
+	if ops := y.Operations(); len(ops) != 1 || ops[0].ID != "op1" {
+		t.Errorf("finished operation is not removed from registry: %+v", ops)
+	}
+	if _, err := y.GetOperationStatus("unknown"); err == nil {
+		t.Error("GetOperationStatus of unknown operation succeeds")
+	}
+}
+
+func TestRemoveAsync(t *testing.T) {
+	operationPollMin = time.Millisecond
+	defer func() { operationPollMin = 250 * time.Millisecond }()
+	fsys, md := newMockFS(t)
+	md.asyncDelete = true
+	md.put("/big/a.txt", []byte("a"))
+	if err := fsys.RemoveAll("/big"); err != nil {
+		t.Fatalf("RemoveAll with async deletion: %v", err)
+	}
+	if _, ok := md.get("/big"); ok {
+		t.Error("directory is not removed")
+	}
+	if ops := fsys.Operations(); len(ops) != 0 {
+		t.Errorf("finished operations are left in registry: %+v", ops)
+	}
+	if md.ops["op1"] != OperationSuccess {
+		t.Errorf("operation status was not polled")
+	}
+}
+
+func TestWaitOperationTimeout(t *testing.T) {
+	operationPollMin = time.Millisecond
+	defer func() { operationPollMin = 250 * time.Millisecond }()
+	fsys, md := newMockFS(t, WithOperationTimeout(50*time.Millisecond))
+	md.ops["stuck"] = OperationInProgress
+	md.ops["broken"] = OperationFailed
+	c := fsys.(*ydfs).client
+	err := c.waitOperation(context.Background(), "https://cloud-api.yandex.net/v1/disk/operations/stuck")
+	if !errors.Is(err, ErrOperationPending) {
+		t.Errorf("want ErrOperationPending, have %v", err)
+	}
+	err = c.waitOperation(context.Background(), "https://cloud-api.yandex.net/v1/disk/operations/broken")
+	if !errors.Is(err, ErrAPI) {
+		t.Errorf("want ErrAPI for failed operation, have %v", err)
+	}
+}
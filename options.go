@@ -0,0 +1,173 @@
+package ydfs
+
+import (
+	"crypto/tls"
+	"io/fs"
+	"net/http"
+	"time"
+)
+
+// Option configures FS returned by New.
+type Option func(*options)
+
+// options holds configuration shared by FS and all its sub FS.
+type options struct {
+	audit           []func(AuditRecord) // audit journal handlers
+	bandwidth       int64               // bytes per second for transfers, 0 means unlimited
+	maxTransfers    int                 // max number of concurrent transfers, 0 means unlimited
+	fields          []string            // extra fields requested for resource metadata
+	smallFile       int64               // files smaller than this are downloaded by Open
+	skipSame        bool                // skip uploads of unchanged contents
+	noOverwrite     bool                // WriteFile and WriteFileStream do not replace files
+	renamePolicy    RenamePolicy        // what Rename does when the new name exists
+	noInstant       bool                // do not offer uploads by hashes
+	fileMode        fs.FileMode         // permission bits reported for files
+	dirMode         fs.FileMode         // permission bits reported for directories
+	chunkSize       int64               // read files in chunks of this size if positive
+	readAhead       int                 // number of chunks fetched ahead of reader
+	readWindow      int64               // bytes kept behind streaming reader
+	versions        int                 // number of previous copies of files kept
+	lazyInit        bool                // do not validate token on construction
+	continueOnError bool                // bulk operations do not stop at failures
+	cacheTTL        time.Duration       // how long metadata is cached, 0 disables cache
+	revisionCheck   time.Duration       // how often cache is validated by disk revision
+
+	reservedDir  string      // directory of objects of the library
+	unicodeForm  UnicodeForm // normalization form of paths
+	hideReserved bool        // leave reservedDir out of listings
+
+	deleteGuard func(path string, info fs.FileInfo) bool // consulted before deletions
+	policy      []pathRule                               // access restrictions of paths
+
+	refuseInfected bool              // refuse to read infected files
+	warnInfected   func(name string) // called when infected file is read
+
+	operationsFile   string        // file to keep pending operations in
+	operationTimeout time.Duration // max time to wait for async operation
+	metadataTimeout  time.Duration // timeout of metadata requests
+	metadataLimited  bool          // metadataTimeout is set WithMetadataTimeout
+	transferTimeout  time.Duration // timeout of uploads and downloads
+
+	transport  http.RoundTripper // replaces transport of http.Client
+	middleware []Middleware      // wrap transport, the first is the outermost
+	proxy      string            // proxy URL
+	tlsConfig  *tls.Config       // TLS configuration of transport
+
+	requestIDHeader string // header to send correlation id in
+	appName         string // product token of the application in User-Agent
+}
+
+// newClient creates API client configured according to options.
+// If client is nil then client with tuned transport is used.
+func (o *options) newClient(token string, client *http.Client) (*apiclient, error) {
+	hc, err := o.httpClient(client)
+	if err != nil {
+		return nil, err
+	}
+	c := newApiClient(token, hc)
+	c.header.Set("User-Agent", o.userAgent())
+	ops, err := newOperationRegistry(o.operationsFile)
+	if err != nil {
+		return nil, err
+	}
+	c.operations = ops
+	if o.operationTimeout > 0 {
+		c.operationTimeout = o.operationTimeout
+	}
+	c.metadataTimeout = o.metadataTimeout
+	c.transferTimeout = o.transferTimeout
+	c.requestIDHeader = o.requestIDHeader
+	c.policy = o.policy
+	c.unicodeForm = o.unicodeForm
+	if o.hideReserved {
+		c.hideDir = o.reservedDir
+	}
+	c.cache = newMetaCache(o.cacheTTL)
+	if c.cache != nil {
+		c.cache.checkEvery = o.revisionCheck
+	}
+	if !o.noInstant {
+		c.instantUpload = instantUploadMinSize
+	}
+	if o.bandwidth > 0 {
+		c.limiter = newRateLimiter(o.bandwidth)
+	}
+	if o.maxTransfers > 0 {
+		c.transfers = make(transferSlots, o.maxTransfers)
+	}
+	c.fields = mergeFields(minimalFields, o.fields)
+	if o.checksAntivirus() {
+		c.fields = mergeFields(c.fields, []string{"antivirus_status"})
+	}
+	return c, nil
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{fileMode: defaultFileMode, dirMode: defaultDirMode, reservedDir: defaultReservedDir}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithBandwidthLimit limits combined speed of all uploads and downloads
+// performed by FS to bytesPerSec. Zero or negative value means no limit.
+func WithBandwidthLimit(bytesPerSec int64) Option {
+	return func(o *options) {
+		o.bandwidth = bytesPerSec
+	}
+}
+
+// WithFields sets additional fields to be requested from the API
+// along with the metadata FS needs to function (name, path, type,
+// size and modified). By default only the latter are requested.
+// Nested fields use dot notation, e.g. "exif.date_time".
+func WithFields(fields ...string) Option {
+	return func(o *options) {
+		o.fields = append(o.fields, fields...)
+	}
+}
+
+// WithMetadataTimeout limits duration of every metadata request
+// (stat, listing, mkdir etc.) to d. Zero means no limit, also for FS
+// created with nil http.Client, which otherwise limits metadata
+// requests to 30 seconds.
+//
+// Timeout of http.Client applies to all requests including uploads
+// and downloads. Consider using a client without timeout along with
+// WithMetadataTimeout and WithTransferTimeout instead.
+func WithMetadataTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.metadataTimeout = d
+		o.metadataLimited = true
+	}
+}
+
+// WithTransferTimeout limits duration of every upload and download
+// (including reading of the response body) to d. Zero means no limit.
+func WithTransferTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.transferTimeout = d
+	}
+}
+
+// WithFileMode sets permission bits reported by Mode of files and
+// directories (0644 and 0755 by default). The disk has no notion of
+// permissions, so the bits are the same for all resources, but tools
+// copying files from FS to local disk use them. Bits other than
+// permission ones are ignored.
+func WithFileMode(file, dir fs.FileMode) Option {
+	return func(o *options) {
+		o.fileMode = file.Perm()
+		o.dirMode = dir.Perm()
+	}
+}
+
+// info returns fs.FileInfo of res with mode set according to options.
+func (o *options) info(res Resource) *ydinfo {
+	mode := o.fileMode
+	if res.IsDir() {
+		mode = fs.ModeDir | o.dirMode
+	}
+	return &ydinfo{res: res, mode: mode}
+}
@@ -0,0 +1,91 @@
+package ydfs
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithFields(t *testing.T) {
+	fsys, md := newMockFS(t, WithFields("md5", "name"))
+	md.put("/a.txt", []byte("a"))
+	if _, err := fsys.Stat("/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Split(md.lastQuery().Get("fields"), ",")
+	want := append(append([]string{}, minimalFields...), "md5")
+	if strings.Join(fields, ",") != strings.Join(want, ",") {
+		t.Errorf("want fields %v, have %v", want, fields)
+	}
+}
+
+func TestListingFields(t *testing.T) {
+	have := strings.Join(listingFields("name", "type"), ",")
+	want := "name,type,_embedded.items.name,_embedded.items.type,_embedded.total,_embedded.limit,_embedded.offset"
+	if have != want {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func TestReadDirRequestsListingFields(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/dir/a.txt", []byte("a"))
+	entries, err := fsys.ReadDir("/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("want 1 entry, have %d", len(entries))
+	}
+	fields := md.lastQuery().Get("fields")
+	for _, f := range minimalFields {
+		if !strings.Contains(fields, "_embedded.items."+f) {
+			t.Errorf("field %s of embedded items not requested: %s", f, fields)
+		}
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	fsys, md := newMockFS(t, WithMetadataTimeout(50*time.Millisecond), WithTransferTimeout(time.Second))
+	md.put("/a.txt", []byte("a"))
+	md.setDelays(200*time.Millisecond, 0)
+	if _, err := fsys.Stat("/a.txt"); !errors.Is(err, ErrNetwork) {
+		t.Errorf("slow metadata request: want ErrNetwork, have %v", err)
+	}
+
+	md.setDelays(0, 200*time.Millisecond)
+	if _, err := fsys.ReadFile("/a.txt"); err != nil {
+		t.Errorf("transfer within timeout fails: %v", err)
+	}
+
+	fsys, md = newMockFS(t, WithTransferTimeout(50*time.Millisecond))
+	md.put("/a.txt", []byte("a"))
+	md.setDelays(0, 200*time.Millisecond)
+	if _, err := fsys.ReadFile("/a.txt"); !errors.Is(err, ErrNetwork) {
+		t.Errorf("slow transfer: want ErrNetwork, have %v", err)
+	}
+}
+
+func TestWithFileMode(t *testing.T) {
+	fsys, md := newMockFS(t, WithFileMode(0400, fs.ModeSetuid|0500))
+	md.put("/dir/a.txt", []byte("a"))
+	info, err := fsys.Stat("/dir/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode() != 0400 {
+		t.Errorf("file mode is %v", info.Mode())
+	}
+	entries, err := fsys.ReadDir("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	if info, _ := entries[0].Info(); info.Mode() != fs.ModeDir|0500 {
+		t.Errorf("dir mode is %v", info.Mode())
+	}
+}
@@ -0,0 +1,41 @@
+package ydfs
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ping performs the lightest authenticated request: it fetches
+// the revision of the disk only.
+func (c *apiclient) ping(ctx context.Context) error {
+	_, err := c.diskRevision(ctx)
+	return err
+}
+
+// diskRevision fetches the revision of the disk, which changes with
+// every change on the disk.
+func (c *apiclient) diskRevision(ctx context.Context) (int64, error) {
+	u, _ := url.Parse(urlBase)
+	u.RawQuery = url.Values{"fields": {"revision"}}.Encode()
+	var info diskInfo
+	err := c.requestInterface(ctx, http.MethodGet, http.StatusOK, u.String(), nil, &info)
+	return info.Revision, err
+}
+
+// Ping implements FS
+func (y *ydfs) Ping(ctx context.Context) error {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	return y.client.ping(ctx)
+}
+
+// Ping checks that the API is reachable and accepts the token
+// without creating FS. Client and options are used as in New.
+func Ping(ctx context.Context, token string, client *http.Client, opts ...Option) error {
+	c, err := newOptions(opts...).newClient(token, client)
+	if err != nil {
+		return err
+	}
+	return c.ping(ctx)
+}
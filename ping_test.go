@@ -0,0 +1,28 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	fsys, md := newMockFS(t)
+	if err := fsys.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if q := md.lastQuery(); q.Get("fields") != "revision" {
+		t.Errorf("Ping requests fields %q", q.Get("fields"))
+	}
+
+	client := newMockClient(t, md)
+	if err := Ping(context.Background(), "mocktoken", client); err != nil {
+		t.Errorf("Ping with valid token returns %v", err)
+	}
+	if err := Ping(context.Background(), "badtoken", client); !errors.Is(err, ErrAPI) {
+		t.Errorf("Ping with invalid token returns %v", err)
+	}
+	if _, err := New("badtoken", client); err == nil {
+		t.Error("New accepts invalid token")
+	}
+}
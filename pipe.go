@@ -0,0 +1,126 @@
+package ydfs
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+)
+
+// Errors of writers returned by OpenWrite.
+var (
+	ErrTooLarge         = errors.New("file exceeds size limit")
+	ErrChecksumMismatch = errors.New("checksum of uploaded file does not match")
+)
+
+// WriteOption configures writer returned by OpenWrite.
+type WriteOption func(*pipeWriter)
+
+// WithVerify makes the writer hash written data and compare the sums
+// with ones reported by the disk when the upload is finished. Close
+// fails with ErrChecksumMismatch if they differ.
+func WithVerify() WriteOption {
+	return func(w *pipeWriter) {
+		w.md5, w.sha256 = md5.New(), sha256.New()
+	}
+}
+
+// WithMaxSize makes writes fail with ErrTooLarge as soon as more than
+// n bytes are written. The upload is aborted then.
+func WithMaxSize(n int64) WriteOption {
+	return func(w *pipeWriter) {
+		w.maxSize = n
+	}
+}
+
+// pipeWriter streams data written to it to the upload running in
+// background.
+type pipeWriter struct {
+	y    *ydfs
+	ctx  context.Context
+	name string
+	pw   *io.PipeWriter
+	done chan error
+	err  error // returned by Close
+
+	written     int64
+	maxSize     int64     // no limit if zero
+	md5, sha256 hash.Hash // nil unless verified
+}
+
+// OpenWrite implements FS
+func (y *ydfs) OpenWrite(ctx context.Context, name string, opts ...WriteOption) (io.WriteCloser, error) {
+	if err := y.client.checkWrite(y.fullPath(name), false); err != nil {
+		return nil, &fs.PathError{Op: "write", Path: name, Err: err}
+	}
+	pr, pw := io.Pipe()
+	w := &pipeWriter{y: y, ctx: ctx, name: name, pw: pw, done: make(chan error, 1)}
+	for _, opt := range opts {
+		opt(w)
+	}
+	go func() {
+		ctx, cancel := y.bind(ctx)
+		defer cancel()
+		err := y.writeStream(ctx, name, pr, -1)
+		// unblock writers if the upload fails before reading everything
+		pr.CloseWithError(err)
+		w.done <- err
+	}()
+	return w, nil
+}
+
+// Write implements io.Writer
+func (w *pipeWriter) Write(p []byte) (int, error) {
+	if w.maxSize > 0 && w.written+int64(len(p)) > w.maxSize {
+		if w.err == nil {
+			w.err = &fs.PathError{Op: "write", Path: w.name, Err: fmt.Errorf("%w: more than %d bytes", ErrTooLarge, w.maxSize)}
+			w.pw.CloseWithError(w.err)
+		}
+		return 0, w.err
+	}
+	n, err := w.pw.Write(p)
+	w.written += int64(n)
+	if w.md5 != nil {
+		w.md5.Write(p[:n])
+		w.sha256.Write(p[:n])
+	}
+	return n, err
+}
+
+// Close finishes the upload and returns its error.
+func (w *pipeWriter) Close() error {
+	if w.done == nil {
+		return w.err
+	}
+	w.pw.Close()
+	err := <-w.done
+	w.done = nil
+	if w.err != nil {
+		return w.err
+	}
+	if err == nil && w.md5 != nil {
+		err = w.verify()
+	}
+	w.err = err
+	return err
+}
+
+// verify compares sums of written data with sums of the uploaded file.
+// Its request is not shared with requests sent before the upload.
+func (w *pipeWriter) verify() error {
+	ctx, cancel := w.y.bind(w.ctx)
+	defer cancel()
+	res, err := w.y.client.getResource(unshared(ctx), w.y.fullPath(w.name), 0, "md5", "sha256")
+	if err != nil {
+		return &fs.PathError{Op: "write", Path: w.name, Err: err}
+	}
+	if res.MD5 != hex.EncodeToString(w.md5.Sum(nil)) || res.SHA256 != "" && res.SHA256 != hex.EncodeToString(w.sha256.Sum(nil)) {
+		return &fs.PathError{Op: "write", Path: w.name, Err: ErrChecksumMismatch}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package ydfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestOpenWrite(t *testing.T) {
+	fsys, md := newMockFS(t, WithPathPolicy("/ro", PathReadOnly))
+	w, err := fsys.OpenWrite(context.Background(), "/data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.NewEncoder(w).Encode(map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := md.get("/data.json"); !ok || string(e.data) != "{\"a\":1}\n" {
+		t.Errorf("unexpected uploaded contents")
+	}
+
+	w, err = fsys.OpenWrite(context.Background(), "/missing/data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("data"))
+	if err := w.Close(); err == nil {
+		t.Error("failed upload is not reported by Close")
+	}
+	if _, err := w.Write([]byte("more")); err == nil {
+		t.Error("write after failed upload succeeds")
+	}
+
+	if _, err := fsys.OpenWrite(context.Background(), "/ro/data.json"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("want fs.ErrPermission, have %v", err)
+	}
+}
+
+func TestOpenWriteVerify(t *testing.T) {
+	fsys, md := newMockFS(t)
+	w, err := fsys.OpenWrite(context.Background(), "/a.txt", WithVerify())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("hello"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	md.update("/a.txt", func(e *mockEntry) { e.data = []byte("corrupted") })
+	if err := w.(*pipeWriter).verify(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("want ErrChecksumMismatch, have %v", err)
+	}
+}
+
+func TestOpenWriteMaxSize(t *testing.T) {
+	fsys, md := newMockFS(t)
+	w, err := fsys.OpenWrite(context.Background(), "/big.bin", WithMaxSize(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("12345")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("67890")); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("want ErrTooLarge, have %v", err)
+	}
+	if err := w.Close(); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("want ErrTooLarge from Close, have %v", err)
+	}
+	if _, ok := md.get("/big.bin"); ok {
+		t.Error("upload exceeding size limit is not aborted")
+	}
+}
+
+func TestOpenWriteVerifyConcurrent(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/a.txt", []byte("old"))
+	c := fsys.(*ydfs).client
+	// request of the same fields as verify sent before the upload
+	// must not answer verification of the new contents
+	md.setDelays(200*time.Millisecond, 0)
+	before := md.requests()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.getResource(context.Background(), "/a.txt", 0, "md5", "sha256")
+	}()
+	for md.requests() == before {
+		time.Sleep(time.Millisecond)
+	}
+	md.setDelays(0, 0)
+	w, err := fsys.OpenWrite(context.Background(), "/a.txt", WithVerify())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("hello"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	n := 0
+	for _, q := range md.queries[before:] {
+		if q.Get("fields") == "md5,sha256" {
+			n++
+		}
+	}
+	if n != 2 {
+		t.Errorf("verification shares request sent before the upload")
+	}
+}
@@ -0,0 +1,107 @@
+package ydfs
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// PathAccess restricts access to resources under a path (see WithPathPolicy).
+type PathAccess int
+
+const (
+	// PathReadOnly forbids changes of resources under the path:
+	// uploads, creation of directories, removal, copying to the path,
+	// publishing etc. fail with fs.ErrPermission.
+	PathReadOnly PathAccess = iota + 1
+	// PathHidden makes resources under the path inaccessible: they
+	// are reported as not found and left out of directory listings.
+	PathHidden
+)
+
+// pathRule restricts access to resources under prefix.
+type pathRule struct {
+	prefix string
+	access PathAccess
+}
+
+// WithPathPolicy restricts access to resources under path prefix for FS
+// and all its sub FS, e.g. to hand FS to semi-trusted code without
+// letting it delete anything under /Photos. Prefix is a path from the
+// root of the disk (or of the app folder for NewAppFolder). The option
+// can be given several times, the strictest of matching rules applies.
+func WithPathPolicy(prefix string, access PathAccess) Option {
+	return func(o *options) {
+		o.policy = append(o.policy, pathRule{prefix: path.Clean("/" + prefix), access: access})
+	}
+}
+
+// access returns the strictest access restriction of the resource
+// with the given full path, zero if it is unrestricted.
+func access(rules []pathRule, name string) PathAccess {
+	var result PathAccess
+	name = path.Clean("/" + name)
+	for _, rule := range rules {
+		if rule.access > result && (rule.prefix == "/" || name == rule.prefix || strings.HasPrefix(name, rule.prefix+"/")) {
+			result = rule.access
+		}
+	}
+	return result
+}
+
+// hidden reports whether the resource with the given full path is hidden.
+func (c *apiclient) hidden(name string) bool {
+	return access(c.policy, name) == PathHidden
+}
+
+// checkRead returns error if the resource with the given full path is hidden.
+func (c *apiclient) checkRead(name string) error {
+	if c.hidden(name) {
+		return fmt.Errorf("%w: %s is hidden by path policy", ErrNotFound, name)
+	}
+	return nil
+}
+
+// checkWrite returns error if the resource with the given full path
+// can not be changed. If tree is set the resource is changed with all
+// its children, so restricted paths within it are checked as well.
+func (c *apiclient) checkWrite(name string, tree bool) error {
+	switch access(c.policy, name) {
+	case PathHidden:
+		return fmt.Errorf("%w: %s is hidden by path policy", ErrNotFound, name)
+	case PathReadOnly:
+		return fmt.Errorf("%w: %s is read-only by path policy", fs.ErrPermission, name)
+	}
+	if tree && restrictedWithin(c.policy, name, PathReadOnly) {
+		return fmt.Errorf("%w: %s contains paths restricted by path policy", fs.ErrPermission, name)
+	}
+	return nil
+}
+
+// restrictedWithin reports whether there are paths restricted at least
+// to access within (but not at) the given full path.
+func restrictedWithin(rules []pathRule, name string, access PathAccess) bool {
+	name = path.Clean("/" + name)
+	for _, rule := range rules {
+		if rule.access >= access && rule.prefix != name && (name == "/" || strings.HasPrefix(rule.prefix, name+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterHidden removes hidden items from embedded resources of
+// normalized r.
+func (c *apiclient) filterHidden(r *Resource) {
+	if len(c.policy) == 0 && c.hideDir == "" || len(r.Embedded.Items) == 0 {
+		return
+	}
+	items := r.Embedded.Items[:0]
+	for _, item := range r.Embedded.Items {
+		if !c.unlisted(item.Path, false) {
+			items = append(items, item)
+		}
+	}
+	r.Embedded.Items = items
+}
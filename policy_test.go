@@ -0,0 +1,93 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestPathPolicy(t *testing.T) {
+	fsys, md := newMockFS(t,
+		WithPathPolicy("/Photos", PathReadOnly),
+		WithPathPolicy("/secret/", PathHidden),
+		WithPathPolicy("/docs/archive", PathReadOnly),
+	)
+	md.put("/Photos/a.jpg", []byte("a"))
+	md.put("/secret/key", []byte("k"))
+	md.put("/docs/archive/old.txt", []byte("o"))
+	md.put("/docs/new.txt", []byte("n"))
+	md.put("/other.txt", []byte("x"))
+
+	// read-only paths can be read, but not changed
+	if _, err := fsys.ReadFile("/Photos/a.jpg"); err != nil {
+		t.Errorf("read-only file can't be read: %v", err)
+	}
+	for op, err := range map[string]error{
+		"WriteFile": fsys.WriteFile("/Photos/b.jpg", []byte("b")),
+		"Mkdir":     fsys.Mkdir("/Photos/2021"),
+		"Remove":    fsys.Remove("/Photos/a.jpg"),
+		"RemoveAll": fsys.RemoveAll("/Photos"),
+	} {
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("%s under read-only path returns %v", op, err)
+		}
+	}
+	if _, err := fsys.Publish("/Photos/a.jpg"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Publish under read-only path returns %v", err)
+	}
+	// directories containing restricted paths can't be removed as a whole
+	if err := fsys.RemoveAll("/docs"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("RemoveAll of directory with read-only path returns %v", err)
+	}
+	if _, ok := md.get("/docs/archive/old.txt"); !ok {
+		t.Error("read-only file is removed")
+	}
+	if err := fsys.Remove("/other.txt"); err != nil {
+		t.Errorf("unrestricted file can't be removed: %v", err)
+	}
+
+	// hidden paths are not found and not listed
+	if _, err := fsys.Stat("/secret/key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat of hidden file returns %v", err)
+	}
+	if _, err := fsys.ReadFile("/secret/key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ReadFile of hidden file returns %v", err)
+	}
+	if err := fsys.WriteFile("/secret/key", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("WriteFile of hidden file returns %v", err)
+	}
+	entries, err := fsys.ReadDir("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() == "secret" {
+			t.Error("hidden directory is listed")
+		}
+	}
+	seq, errf := fsys.WalkIter(context.Background(), "/")
+	for p := range seq {
+		if p == "/secret" || p == "/secret/key" {
+			t.Errorf("%s is walked", p)
+		}
+	}
+	if err := errf(); err != nil {
+		t.Fatal(err)
+	}
+
+	// policy applies to sub FS
+	sub, err := fsys.Sub("/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.WriteFile("/archive/x.txt", nil); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("WriteFile under read-only path of sub FS returns %v", err)
+	}
+	if err := sub.WriteFile("/x.txt", []byte("x")); err != nil {
+		t.Errorf("WriteFile of unrestricted file of sub FS returns %v", err)
+	}
+	if _, err := fsys.Sub("/secret"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Sub of hidden directory returns %v", err)
+	}
+}
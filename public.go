@@ -0,0 +1,75 @@
+package ydfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+)
+
+// publicSource provides resources of a public resource to roFS.
+type publicSource struct {
+	client *apiclient // api client
+	key    string     // public key or public URL of the resource
+}
+
+// resource implements roSource
+func (p *publicSource) resource(ctx context.Context, name string, limit, offset int) (Resource, error) {
+	return p.client.getPublicResource(ctx, p.key, name, limit, offset)
+}
+
+// readFile implements roSource
+func (p *publicSource) readFile(ctx context.Context, name string) ([]byte, error) {
+	return p.client.getPublicFile(ctx, p.key, name)
+}
+
+// NewPublic returns read-only fs.FS providing access to a resource
+// published on Yandex Disk. The resource is identified by publicKey which
+// is either its public key or its public URL. No token is required.
+// If the resource is a folder, its nested folders and files are
+// accessible by their paths relative to the folder, e.g. "docs/a.txt".
+// Returned FS also implements fs.StatFS, fs.ReadDirFS and fs.ReadFileFS.
+// Client and options are treated the same way as by New.
+func NewPublic(publicKey string, client *http.Client, opts ...Option) (fs.FS, error) {
+	o := newOptions(opts...)
+	c, err := o.newClient("", client)
+	if err != nil {
+		return nil, err
+	}
+	src := &publicSource{client: c, key: publicKey}
+	if _, err := src.resource(context.TODO(), "/", 0, 0); err != nil {
+		return nil, err
+	}
+	return &roFS{src: src, opts: o}, nil
+}
+
+// DownloadPublic downloads resource published at publicURL
+// (e.g. https://disk.yandex.ru/d/xxxx) and writes its contents to w.
+// Published folders are downloaded as zip archives.
+func DownloadPublic(ctx context.Context, publicURL string, w io.Writer) error {
+	u, err := url.Parse(publicURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: invalid public URL %q", fs.ErrInvalid, publicURL)
+	}
+	// the API accepts public URL in place of public key
+	c := newApiClient("", http.DefaultClient)
+	l, err := c.getPublicDownloadLink(ctx, u.String(), "")
+	if err != nil {
+		return err
+	}
+	r, err := http.NewRequest(l.Method, l.Href, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	body, err := c.transferStream(ctx, r, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if _, err := io.Copy(w, body); err != nil {
+		return fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package ydfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// newMockPublicFS starts mock server and returns public FS
+// over the resource at root.
+func newMockPublicFS(t *testing.T, md *mockDisk, root string) fs.FS {
+	t.Helper()
+	md.mu.Lock()
+	md.public["pubkey"] = root
+	md.mu.Unlock()
+	fsys, err := NewPublic("pubkey", newMockClient(t, md))
+	if err != nil {
+		t.Fatalf("error creating public filesystem: %v", err)
+	}
+	return fsys
+}
+
+func TestPublicFS(t *testing.T) {
+	md := newMockDisk()
+	md.put("/shared/a.txt", []byte("a"))
+	md.put("/shared/docs/b.txt", []byte("bb"))
+	md.put("/private.txt", []byte("secret"))
+	fsys := newMockPublicFS(t, md, "/shared")
+
+	data, err := fs.ReadFile(fsys, "docs/b.txt")
+	if err != nil || string(data) != "bb" {
+		t.Errorf("ReadFile of nested file returned %q, %v", data, err)
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil || len(entries) != 2 {
+		t.Errorf("ReadDir of public root returned %v, %v", entries, err)
+	}
+	if _, err := fs.Stat(fsys, "../private.txt"); err == nil {
+		t.Error("public FS gives access to resources outside of the published folder")
+	}
+	var walked []string
+	err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		walked = append(walked, p)
+		return nil
+	})
+	if err != nil || len(walked) != 4 {
+		t.Errorf("WalkDir visited %v, %v", walked, err)
+	}
+}
+
+func TestPublicFSPagination(t *testing.T) {
+	md := newMockDisk()
+	for i := 0; i < roPageSize+10; i++ {
+		md.put(fmt.Sprintf("/shared/f%04d", i), []byte{})
+	}
+	fsys := newMockPublicFS(t, md, "/shared")
+	entries, err := fs.ReadDir(fsys, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != roPageSize+10 {
+		t.Errorf("want %d entries, have %d", roPageSize+10, len(entries))
+	}
+}
+
+func TestDownloadPublic(t *testing.T) {
+	md := newMockDisk()
+	md.put("/shared.txt", []byte("public data"))
+	md.public["https://disk.yandex.ru/d/abc"] = "/shared.txt"
+	srv := httptest.NewServer(md)
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+	saved := http.DefaultClient.Transport
+	http.DefaultClient.Transport = &rewriteTransport{target: target}
+	defer func() { http.DefaultClient.Transport = saved }()
+
+	var buf bytes.Buffer
+	if err := DownloadPublic(context.Background(), "https://disk.yandex.ru/d/abc", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "public data" {
+		t.Errorf("downloaded %q", buf.String())
+	}
+	if err := DownloadPublic(context.Background(), "not a url", &buf); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("want fs.ErrInvalid for invalid URL, have %v", err)
+	}
+	if err := DownloadPublic(context.Background(), "https://disk.yandex.ru/d/nope", &buf); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for unknown URL, have %v", err)
+	}
+}
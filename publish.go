@@ -0,0 +1,108 @@
+package ydfs
+
+import (
+	"context"
+	"io/fs"
+	"path"
+)
+
+// Publish implements FS
+func (y *ydfs) Publish(name string) (string, error) {
+	fullname := y.fullPath(name)
+	ctx := y.context()
+	err := y.client.publish(ctx, fullname)
+	y.opts.auditRecord(AuditPublish, fullname, 0, err)
+	if err != nil {
+		return "", &fs.PathError{Op: "publish", Path: name, Err: err}
+	}
+	res, err := y.client.getResource(ctx, fullname, 0, "public_url")
+	if err != nil {
+		return "", &fs.PathError{Op: "publish", Path: name, Err: err}
+	}
+	return res.PublicURL, nil
+}
+
+// PublicLink describes public link of a resource for share management.
+type PublicLink struct {
+	URL   string       // public URL, e.g. to be encoded in QR code
+	Key   string       // public key, see NewPublic
+	Views int          // number of views of the link reported by the API
+	Owner *PublicOwner // owner of the resource as shown to visitors
+}
+
+// PublishWithInfo implements FS
+func (y *ydfs) PublishWithInfo(ctx context.Context, name string) (*PublicLink, error) {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	fullname := y.fullPath(name)
+	err := y.client.publish(ctx, fullname)
+	y.opts.auditRecord(AuditPublish, fullname, 0, err)
+	if err != nil {
+		return nil, &fs.PathError{Op: "publish", Path: name, Err: err}
+	}
+	res, err := y.client.getResource(ctx, fullname, 0, "public_url", "public_key")
+	if err != nil {
+		return nil, &fs.PathError{Op: "publish", Path: name, Err: err}
+	}
+	// views and owner are only reported in public metadata
+	pub, err := y.client.getPublicResource(ctx, res.PublicKey, "/", 0, 0)
+	if err != nil {
+		return nil, &fs.PathError{Op: "publish", Path: name, Err: err}
+	}
+	return &PublicLink{URL: res.PublicURL, Key: res.PublicKey, Views: pub.ViewsCount, Owner: pub.Owner}, nil
+}
+
+// Unpublish implements FS
+func (y *ydfs) Unpublish(name string) error {
+	fullname := y.fullPath(name)
+	err := y.client.unpublish(y.context(), fullname)
+	y.opts.auditRecord(AuditUnpublish, fullname, 0, err)
+	if err != nil {
+		return &fs.PathError{Op: "unpublish", Path: name, Err: err}
+	}
+	return nil
+}
+
+// TransferViaPublicLink copies file or directory srcPath of srcFS into
+// directory dstDir of dstFS without downloading it: the resource is
+// published on the source account, saved to disk by the destination
+// account and then unpublished again (unless it had been published
+// before). Both FS must be created by New.
+func TransferViaPublicLink(ctx context.Context, srcFS FS, srcPath string, dstFS FS, dstDir string) (err error) {
+	src, ok := srcFS.(*ydfs)
+	if !ok {
+		return &fs.PathError{Op: "transfer", Path: srcPath, Err: fs.ErrInvalid}
+	}
+	dst, ok := dstFS.(*ydfs)
+	if !ok {
+		return &fs.PathError{Op: "transfer", Path: dstDir, Err: fs.ErrInvalid}
+	}
+	srcName, dstName := src.fullPath(srcPath), dst.fullPath(dstDir)
+	res, err := src.client.getResource(ctx, srcName, 0, "name", "size", "public_key")
+	if err != nil {
+		return &fs.PathError{Op: "transfer", Path: srcPath, Err: err}
+	}
+	if res.PublicKey == "" {
+		perr := src.client.publish(ctx, srcName)
+		src.opts.auditRecord(AuditPublish, srcName, 0, perr)
+		if perr != nil {
+			return &fs.PathError{Op: "publish", Path: srcPath, Err: perr}
+		}
+		defer func() {
+			uerr := src.client.unpublish(ctx, srcName)
+			src.opts.auditRecord(AuditUnpublish, srcName, 0, uerr)
+			if uerr != nil && err == nil {
+				err = &fs.PathError{Op: "unpublish", Path: srcPath, Err: uerr}
+			}
+		}()
+		if res, err = src.client.getResource(ctx, srcName, 0, "name", "size", "public_key"); err != nil {
+			return &fs.PathError{Op: "transfer", Path: srcPath, Err: err}
+		}
+	}
+	err = dst.client.saveToDisk(ctx, res.PublicKey, res.Name, dstName)
+	dst.opts.auditRecord(AuditSave, path.Join(dstName, res.Name), res.Size, err)
+	if err != nil {
+		return &fs.PathError{Op: "save", Path: path.Join(dstDir, res.Name), Err: err}
+	}
+	return nil
+}
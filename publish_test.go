@@ -0,0 +1,110 @@
+package ydfs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	sub, err := fsys.Sub("/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := sub.Publish("/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u == "" {
+		t.Error("Publish returns empty URL")
+	}
+	res, err := fsys.StatExtended("/docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.PublicURL != u || res.PublicKey == "" {
+		t.Errorf("published resource has public_url %q and public_key %q", res.PublicURL, res.PublicKey)
+	}
+	if err := sub.Unpublish("/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := fsys.StatExtended("/docs/a.txt"); res.PublicKey != "" {
+		t.Error("resource is still published")
+	}
+	if _, err := fsys.Publish("/missing"); err == nil {
+		t.Error("Publish of missing resource succeeds")
+	}
+}
+
+func TestTransferViaPublicLink(t *testing.T) {
+	src, srcDisk := newMockFS(t)
+	dst, dstDisk := newMockFS(t)
+	dstDisk.addPeer(srcDisk)
+	srcDisk.put("/photos/a.jpg", []byte("a"))
+	srcDisk.put("/photos/2020/b.jpg", []byte("b"))
+	srcDisk.put("/shared.txt", []byte("shared"))
+	dstDisk.put("/backup/x", nil)
+
+	if err := TransferViaPublicLink(context.Background(), src, "/photos", dst, "/backup"); err != nil {
+		t.Fatal(err)
+	}
+	for p, want := range map[string]string{
+		"/backup/photos/a.jpg":      "a",
+		"/backup/photos/2020/b.jpg": "b",
+	} {
+		if e, ok := dstDisk.get(p); !ok || string(e.data) != want {
+			t.Errorf("%s is not transferred", p)
+		}
+	}
+	if res, _ := src.StatExtended("/photos"); res.PublicKey != "" {
+		t.Error("source is left published after transfer")
+	}
+
+	// resources published before are left published
+	if _, err := src.Publish("/shared.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := TransferViaPublicLink(context.Background(), src, "/shared.txt", dst, "/"); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := dstDisk.get("/shared.txt"); !ok || string(e.data) != "shared" {
+		t.Error("file is not transferred")
+	}
+	if res, _ := src.StatExtended("/shared.txt"); res.PublicKey == "" {
+		t.Error("resource published before transfer is unpublished")
+	}
+
+	if err := TransferViaPublicLink(context.Background(), src, "/shared.txt", dst, "/missing"); err == nil {
+		t.Error("transfer to missing directory succeeds")
+	}
+}
+
+func TestPublishWithInfo(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	ctx := context.Background()
+	link, err := fsys.PublishWithInfo(ctx, "/docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.URL == "" || link.Key == "" || link.Views != 0 {
+		t.Errorf("unexpected link: %+v", link)
+	}
+	if link.Owner == nil || link.Owner.Login != "mock" {
+		t.Errorf("unexpected owner: %+v", link.Owner)
+	}
+	md.mu.Lock()
+	md.views[link.Key] = 3
+	md.mu.Unlock()
+	again, err := fsys.PublishWithInfo(ctx, "/docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Key != link.Key || again.Views != 3 {
+		t.Errorf("want the same link with 3 views, have %+v", again)
+	}
+	if _, err := fsys.PublishWithInfo(ctx, "/missing"); err == nil {
+		t.Error("PublishWithInfo of missing resource succeeds")
+	}
+}
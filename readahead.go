@@ -0,0 +1,301 @@
+package ydfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// WithReadAhead turns on streaming reads: files opened by Open are
+// downloaded in chunks of chunkSize bytes as the caller reads them
+// instead of being fetched whole by the first Read. Up to chunks
+// following chunks are downloaded while the caller consumes the current
+// one, which keeps sequential reads fast over high latency connections.
+// Memory used by a file is about (chunks+1)*chunkSize bytes.
+// Zero or negative chunkSize turns streaming off.
+func WithReadAhead(chunkSize int64, chunks int) Option {
+	return func(o *options) {
+		o.chunkSize = chunkSize
+		if chunks < 0 {
+			chunks = 0
+		}
+		o.readAhead = chunks
+	}
+}
+
+// WithReadWindow makes streaming reads (see WithReadAhead) keep the
+// last size bytes read from each file in memory, so that seeking back
+// within them is cheap: the bytes are read again from memory and
+// streaming resumes where it stopped instead of starting over at the
+// new offset. Memory used by a file grows by size bytes. Zero or
+// negative size keeps nothing, which is the default.
+func WithReadWindow(size int64) Option {
+	return func(o *options) {
+		o.readWindow = max(size, 0)
+	}
+}
+
+// readWindow keeps the last bytes read from stream of a file in a ring
+// buffer: byte at offset off of the file is kept at buf[off%len(buf)].
+type readWindow struct {
+	size int    // number of bytes to keep
+	buf  []byte // allocated by the first add
+	n    int    // number of bytes kept
+	end  int64  // offset of file after the last byte kept
+}
+
+// start returns offset of file of the first byte kept.
+func (w *readWindow) start() int64 {
+	return w.end - int64(w.n)
+}
+
+// contains reports whether reading at off can be served from the window
+// or continue the stream, which is positioned at the end of the window.
+func (w *readWindow) contains(off int64) bool {
+	return off >= w.start() && off <= w.end
+}
+
+// reset empties the window of stream restarted at off.
+func (w *readWindow) reset(off int64) {
+	w.n, w.end = 0, off
+}
+
+// add keeps b read from stream at the end of the window.
+func (w *readWindow) add(b []byte) {
+	w.end += int64(len(b))
+	if w.size == 0 {
+		return
+	}
+	if w.buf == nil {
+		w.buf = make([]byte, w.size)
+	}
+	if len(b) > w.size {
+		b = b[len(b)-w.size:]
+	}
+	i := int((w.end - int64(len(b))) % int64(w.size))
+	n := copy(w.buf[i:], b)
+	copy(w.buf, b[n:])
+	w.n = min(w.n+len(b), w.size)
+}
+
+// read copies bytes kept at offset off to b and returns their number,
+// which is zero if off is not in the window.
+func (w *readWindow) read(b []byte, off int64) int {
+	if off < w.start() || off >= w.end {
+		return 0
+	}
+	b = b[:min(int64(len(b)), w.end-off)]
+	i := int(off % int64(w.size))
+	n := copy(b, w.buf[i:])
+	copy(b[n:], w.buf)
+	return len(b)
+}
+
+// readChunked reads file in streaming mode.
+func (file *ydfile) readChunked(b []byte) (int, error) {
+	if n := file.window.read(b, int64(file.roffset)); n > 0 {
+		file.roffset += n
+		return n, nil
+	}
+	if file.stream == nil {
+		ctx := file.ctx
+		if file.link == nil {
+			l, err := file.client.getDownloadLink(ctx, file.path)
+			if err != nil {
+				return 0, &fs.PathError{Op: "read", Path: file.name, Err: err}
+			}
+			file.link = &l
+		}
+		file.stream = newChunkReader(ctx, file.client, *file.link, int64(file.roffset), file.size, file.chunkSize, file.readAhead)
+		file.window.reset(int64(file.roffset))
+	}
+	n, err := file.stream.Read(b)
+	file.window.add(b[:n])
+	file.roffset += n
+	if err != nil && err != io.EOF {
+		return n, &fs.PathError{Op: "read", Path: file.name, Err: err}
+	}
+	return n, err
+}
+
+// chunk is contents of a file range or error fetching it.
+type chunk struct {
+	data []byte
+	err  error
+}
+
+// chunkReader reads file of known size from download link by range
+// requests keeping up to ahead requests in flight after the current one.
+type chunkReader struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	client *apiclient
+	link   link
+	size   int64 // size of file
+	chunk  int64 // size of chunk
+	ahead  int   // number of chunks requested ahead
+
+	next    int64        // offset of next chunk to request
+	pending []chan chunk // chunks in flight in file order
+	cur     []byte       // unread part of current chunk
+	err     error        // sticky error
+}
+
+// newChunkReader returns reader of file of given size starting at offset.
+func newChunkReader(ctx context.Context, c *apiclient, l link, offset, size, chunkSize int64, ahead int) *chunkReader {
+	ctx, cancel := context.WithCancel(ctx)
+	return &chunkReader{ctx: ctx, cancel: cancel, client: c, link: l, next: offset, size: size, chunk: chunkSize, ahead: ahead}
+}
+
+// fill starts fetching chunks until ahead chunks after the current one
+// are in flight or the end of file is reached.
+func (r *chunkReader) fill() {
+	for len(r.pending) <= r.ahead && r.next < r.size {
+		offset, length := r.next, r.chunk
+		if offset+length > r.size {
+			length = r.size - offset
+		}
+		r.next += length
+		ch := make(chan chunk, 1)
+		r.pending = append(r.pending, ch)
+		go func() {
+			body, err := r.client.getFileRange(r.ctx, r.link, offset, length)
+			if err != nil {
+				ch <- chunk{err: err}
+				return
+			}
+			defer body.Close()
+			data, err := io.ReadAll(body)
+			if err == nil && int64(len(data)) != length {
+				err = fmt.Errorf("%w: got %d bytes of range, want %d", ErrNetwork, len(data), length)
+			} else if err != nil {
+				err = fmt.Errorf("%w: %w", ErrNetwork, err)
+			}
+			ch <- chunk{data: data, err: err}
+		}()
+	}
+}
+
+func (r *chunkReader) Read(b []byte) (int, error) {
+	for len(r.cur) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+		if len(r.pending) == 0 {
+			return 0, io.EOF
+		}
+		c := <-r.pending[0]
+		r.pending = r.pending[1:]
+		if c.err != nil {
+			r.err = c.err
+			r.cancel()
+			return 0, r.err
+		}
+		r.cur = c.data
+	}
+	n := copy(b, r.cur)
+	r.cur = r.cur[n:]
+	return n, nil
+}
+
+// Close cancels requests in flight.
+func (r *chunkReader) Close() error {
+	r.cancel()
+	return nil
+}
+
+// openContent returns contents of the named file of fsys described by
+// res for serving over HTTP: only the parts read are downloaded, so
+// that HEAD and range requests do not fetch whole files.
+func openContent(ctx context.Context, fsys FS, name string, res Resource) (io.ReadSeekCloser, error) {
+	if y, ok := fsys.(*ydfs); ok {
+		return y.openRange(ctx, name, res)
+	}
+	return fsys.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// openRange returns reader of the named file described by res.
+func (y *ydfs) openRange(ctx context.Context, name string, res Resource) (*rangeReader, error) {
+	if err := y.checkAntivirus(res); err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &rangeReader{ctx: ctx, client: y.client, name: name, path: y.fullPath(name), size: res.Size}, nil
+}
+
+// rangeReader reads file from its download link by range requests.
+// The link is fetched by the first Read, and Seek to another offset
+// starts a new request with the next Read.
+type rangeReader struct {
+	ctx    context.Context
+	client *apiclient
+	name   string // name the file is opened with
+	path   string // full path of file
+	size   int64  // size of file
+	link   *link  // download link, nil until the first Read
+
+	body   io.ReadCloser // contents from offset to the end of file
+	offset int64         // offset of the next Read
+}
+
+func (r *rangeReader) Read(b []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		if r.link == nil {
+			l, err := r.client.getDownloadLink(r.ctx, r.path)
+			if err != nil {
+				return 0, &fs.PathError{Op: "read", Path: r.name, Err: err}
+			}
+			r.link = &l
+		}
+		body, err := r.client.getFileRange(r.ctx, *r.link, r.offset, r.size-r.offset)
+		if err != nil {
+			return 0, &fs.PathError{Op: "read", Path: r.name, Err: err}
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(b)
+	r.offset += int64(n)
+	if err == io.EOF && r.offset < r.size {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil && err != io.EOF {
+		return n, &fs.PathError{Op: "read", Path: r.name, Err: fmt.Errorf("%w: %w", ErrNetwork, err)}
+	}
+	return n, err
+}
+
+// Seek implements io.Seeker
+func (r *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	case io.SeekStart:
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: r.name, Err: fs.ErrInvalid}
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: r.name, Err: fs.ErrInvalid}
+	}
+	if offset != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// Close closes the request in flight if any.
+func (r *rangeReader) Close() error {
+	if r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package ydfs
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestReadAhead(t *testing.T) {
+	fsys, md := newMockFS(t, WithReadAhead(1000, 2))
+	data := make([]byte, 10500)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	md.put("/big.bin", data)
+	md.put("/empty", []byte{})
+
+	f, err := fsys.Open("/big.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := md.requests()
+	got, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("read %d bytes which differ from file", len(got))
+	}
+	// download link and 11 chunks
+	if md.requests()-n != 12 {
+		t.Errorf("streaming read sends %d requests, want 12", md.requests()-n)
+	}
+	f.Close()
+
+	f, err = fsys.Open("/empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := io.ReadAll(f); err != nil || len(got) != 0 {
+		t.Errorf("read of empty file returns %q, %v", got, err)
+	}
+
+	// closing in the middle of the file cancels chunks in flight
+	f, err = fsys.Open("/big.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 10)
+	if _, err := io.ReadFull(f, buf); err != nil || !bytes.Equal(buf, data[:10]) {
+		t.Fatalf("read %v, %v", buf, err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadWindow(t *testing.T) {
+	fsys, md := newMockFS(t, WithReadAhead(1000, 0), WithReadWindow(2500))
+	data := make([]byte, 10000)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	md.put("/big.bin", data)
+	f, err := fsys.Open("/big.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	seeker := f.(io.Seeker)
+	buf := make([]byte, 5000)
+	if _, err := io.ReadFull(f, buf); err != nil || !bytes.Equal(buf, data[:5000]) {
+		t.Fatalf("read %v", err)
+	}
+
+	// seeking back within the window does not restart streaming
+	n := md.requests()
+	if _, err := seeker.Seek(2600, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	buf = make([]byte, 3000)
+	if _, err := io.ReadFull(f, buf); err != nil || !bytes.Equal(buf, data[2600:5600]) {
+		t.Fatalf("read after seek within window: %v", err)
+	}
+	if md.requests()-n != 1 {
+		t.Errorf("read after seek within window sends %d requests, want 1", md.requests()-n)
+	}
+
+	// seeking back beyond the window does
+	n = md.requests()
+	if _, err := seeker.Seek(1000, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	buf = make([]byte, 100)
+	if _, err := io.ReadFull(f, buf); err != nil || !bytes.Equal(buf, data[1000:1100]) {
+		t.Fatalf("read after seek beyond window: %v", err)
+	}
+	if md.requests()-n != 1 {
+		t.Errorf("read after seek beyond window sends %d requests, want 1", md.requests()-n)
+	}
+	if rest, err := io.ReadAll(f); err != nil || !bytes.Equal(rest, data[1100:]) {
+		t.Errorf("read to the end: %v", err)
+	}
+}
+
+func TestReadWindowWraps(t *testing.T) {
+	w := readWindow{size: 5}
+	w.reset(3)
+	w.add([]byte("abc"))
+	w.add([]byte("defg"))
+	if w.contains(4) || !w.contains(5) || !w.contains(10) || w.contains(11) {
+		t.Errorf("unexpected window [%d, %d]", w.start(), w.end)
+	}
+	b := make([]byte, 10)
+	if n := w.read(b, 5); string(b[:n]) != "cdefg" {
+		t.Errorf("read %q", b[:n])
+	}
+	w.add([]byte("0123456789"))
+	if n := w.read(b, 15); string(b[:n]) != "56789" {
+		t.Errorf("read %q after long add", b[:n])
+	}
+	if n := w.read(b, 14); n != 0 {
+		t.Errorf("read %q before window", b[:n])
+	}
+}
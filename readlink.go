@@ -0,0 +1,21 @@
+package ydfs
+
+import (
+	"io/fs"
+)
+
+// ReadLink implements fs.ReadLinkFS. Yandex Disk has no symbolic links,
+// so ReadLink fails with fs.ErrInvalid for any existing file as
+// os.Readlink does for regular files.
+func (y *ydfs) ReadLink(name string) (string, error) {
+	if _, err := y.stat(y.context(), "readlink", name); err != nil {
+		return "", err
+	}
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+}
+
+// Lstat implements fs.ReadLinkFS. Having no symbolic links to
+// report it is the same as Stat.
+func (y *ydfs) Lstat(name string) (fs.FileInfo, error) {
+	return y.stat(y.context(), "lstat", name)
+}
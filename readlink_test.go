@@ -0,0 +1,26 @@
+//go:build go1.25
+
+package ydfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+var _ fs.ReadLinkFS = FS(nil)
+
+func TestReadLink(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	if _, err := fs.ReadLink(fsys, "/docs/a.txt"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("want fs.ErrInvalid reading link of regular file, have %v", err)
+	}
+	if _, err := fs.ReadLink(fsys, "/docs/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound reading link of missing file, have %v", err)
+	}
+	info, err := fs.Lstat(fsys, "/docs/a.txt")
+	if err != nil || info.Size() != 1 || info.Mode()&fs.ModeSymlink != 0 {
+		t.Errorf("unexpected Lstat result: %v %v", info, err)
+	}
+}
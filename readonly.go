@@ -0,0 +1,161 @@
+package ydfs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"path"
+)
+
+// roPageSize is the number of directory entries requested
+// per page when listing directories of roFS.
+const roPageSize = 1000
+
+// roSource provides resources of a read-only FS view.
+type roSource interface {
+	// resource fetches the named resource with up to limit
+	// directory entries starting at offset.
+	resource(ctx context.Context, name string, limit, offset int) (Resource, error)
+	// readFile fetches contents of the named file.
+	readFile(ctx context.Context, name string) ([]byte, error)
+}
+
+// roFS implements read-only fs.FS over resources of src, e.g.
+// public resources and trash.
+type roFS struct {
+	src  roSource // resources of FS
+	opts *options // configuration of FS
+}
+
+// roPath converts name to path within roFS.
+func roPath(name string) string {
+	return path.Clean("/" + name)
+}
+
+// Open implements fs.FS
+func (p *roFS) Open(name string) (fs.File, error) {
+	res, err := p.src.resource(context.TODO(), roPath(name), 0, 0)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &roFile{fsys: p, res: res}, nil
+}
+
+// Stat implements fs.StatFS
+func (p *roFS) Stat(name string) (fs.FileInfo, error) {
+	res, err := p.src.resource(context.TODO(), roPath(name), 0, 0)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return p.opts.info(res), nil
+}
+
+// ReadDir implements fs.ReadDirFS
+func (p *roFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := p.readDir(context.TODO(), roPath(name))
+	if err != nil {
+		return []fs.DirEntry{}, &fs.PathError{Op: "readdirent", Path: name, Err: err}
+	}
+	return entries, nil
+}
+
+// readDir fetches all entries of the directory page by page.
+func (p *roFS) readDir(ctx context.Context, name string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	for offset := 0; ; {
+		res, err := p.src.resource(ctx, name, roPageSize, offset)
+		if err != nil {
+			return entries, err
+		}
+		if !res.IsDir() {
+			return entries, ErrNotDir
+		}
+		for i := range res.Embedded.Items {
+			entries = append(entries, p.opts.info(res.Embedded.Items[i]))
+		}
+		offset += len(res.Embedded.Items)
+		if len(res.Embedded.Items) == 0 || offset >= res.Embedded.Total {
+			return entries, nil
+		}
+	}
+}
+
+// ReadFile implements fs.ReadFileFS
+func (p *roFS) ReadFile(name string) ([]byte, error) {
+	data, err := p.src.readFile(context.TODO(), roPath(name))
+	if err != nil {
+		return []byte{}, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return data, nil
+}
+
+// roFile implements fs.File and fs.ReadDirFile for roFS.
+type roFile struct {
+	fsys    *roFS
+	res     Resource
+	data    *bytes.Reader // contents of a file, fetched on first Read
+	entries []fs.DirEntry // entries of a directory, fetched on first ReadDir
+	listed  bool          // true if entries have been fetched
+	closed  bool
+}
+
+// Read implements fs.File
+func (f *roFile) Read(b []byte) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.res.Path, Err: fs.ErrClosed}
+	}
+	if f.res.IsDir() {
+		return 0, &fs.PathError{Op: "read", Path: f.res.Path, Err: ErrIsDir}
+	}
+	if f.data == nil {
+		data, err := f.fsys.src.readFile(context.TODO(), f.res.Path)
+		if err != nil {
+			return 0, &fs.PathError{Op: "read", Path: f.res.Path, Err: err}
+		}
+		f.data = bytes.NewReader(data)
+	}
+	return f.data.Read(b)
+}
+
+// Stat implements fs.File
+func (f *roFile) Stat() (fs.FileInfo, error) {
+	return f.fsys.opts.info(f.res), nil
+}
+
+// Close implements fs.File
+func (f *roFile) Close() error {
+	f.closed = true
+	f.data = nil
+	f.entries = nil
+	return nil
+}
+
+// ReadDir implements fs.ReadDirFile
+func (f *roFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !f.res.IsDir() {
+		return []fs.DirEntry{}, &fs.PathError{Op: "readdirent", Path: f.res.Path, Err: ErrNotDir}
+	}
+	if !f.listed {
+		entries, err := f.fsys.readDir(context.TODO(), f.res.Path)
+		if err != nil {
+			return []fs.DirEntry{}, &fs.PathError{Op: "readdirent", Path: f.res.Path, Err: err}
+		}
+		f.entries = entries
+		f.listed = true
+	}
+	if n <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+	if len(f.entries) == 0 {
+		return []fs.DirEntry{}, io.EOF
+	}
+	if n > len(f.entries) {
+		n = len(f.entries)
+	}
+	entries := f.entries[:n]
+	f.entries = f.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,118 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+)
+
+// RenamePolicy tells Rename what to do when the new name exists.
+type RenamePolicy uint8
+
+const (
+	// RenameFail makes Rename fail with fs.ErrExist. It is the default.
+	RenameFail RenamePolicy = iota
+
+	// RenameOverwrite makes Rename replace the existing file as
+	// os.Rename does. A file never replaces a directory or vice versa:
+	// Rename fails with ErrIsDir or ErrNotDir instead.
+	RenameOverwrite
+
+	// RenameMerge makes Rename replace existing files like
+	// RenameOverwrite and merge directories: contents of the old
+	// directory are moved into the existing one recursively by
+	// server-side moves and the emptied old directory is removed.
+	// Merging is not atomic, if it fails some of the contents may be
+	// moved already.
+	RenameMerge
+)
+
+// WithRenamePolicy sets behaviour of Rename when the new name exists
+// (RenameFail by default). Replaced files and directories are subject
+// to the deletion guard (see WithDeleteGuard).
+func WithRenamePolicy(p RenamePolicy) Option {
+	return func(o *options) {
+		o.renamePolicy = p
+	}
+}
+
+// Rename implements FS
+func (y *ydfs) Rename(oldname, newname string) error {
+	return y.rename(y.context(), oldname, newname)
+}
+
+// rename moves oldname to newname according to rename policy.
+func (y *ydfs) rename(ctx context.Context, oldname, newname string) error {
+	if err := checkName("rename", oldname); err != nil {
+		return err
+	}
+	if err := checkName("rename", newname); err != nil {
+		return err
+	}
+	from, to := y.fullPath(oldname), y.fullPath(newname)
+	err := y.client.moveResource(ctx, from, to, false)
+	if errors.Is(err, fs.ErrExist) && y.opts.renamePolicy != RenameFail {
+		return y.replace(ctx, oldname, newname)
+	}
+	y.opts.auditRecord(AuditRename, to, 0, err)
+	if err != nil {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: err}
+	}
+	return nil
+}
+
+// replace moves oldname over existing newname according to rename
+// policy.
+func (y *ydfs) replace(ctx context.Context, oldname, newname string) error {
+	from, to := y.fullPath(oldname), y.fullPath(newname)
+	src, err := y.client.getResourceMinTraffic(ctx, from)
+	if err != nil {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: err}
+	}
+	dst, err := y.client.getResourceMinTraffic(ctx, to)
+	if err != nil {
+		return &fs.PathError{Op: "rename", Path: newname, Err: err}
+	}
+	switch {
+	case !src.IsDir() && dst.IsDir():
+		return &fs.PathError{Op: "rename", Path: newname, Err: ErrIsDir}
+	case src.IsDir() && !dst.IsDir():
+		return &fs.PathError{Op: "rename", Path: newname, Err: ErrNotDir}
+	case src.IsDir() && y.opts.renamePolicy == RenameMerge:
+		return y.merge(ctx, oldname, newname)
+	}
+	if err := y.guardDelete(newname, dst); err != nil {
+		return err
+	}
+	err = y.client.moveResource(ctx, from, to, true)
+	y.opts.auditRecord(AuditRename, to, 0, err)
+	if err != nil {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: err}
+	}
+	return nil
+}
+
+// merge moves contents of directory oldname into existing directory
+// newname and removes oldname.
+func (y *ydfs) merge(ctx context.Context, oldname, newname string) error {
+	from := y.fullPath(oldname)
+	res, err := y.client.getResourceListing(ctx, from, "")
+	if err != nil {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: err}
+	}
+	for _, item := range res.Embedded.Items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := y.rename(ctx, path.Join(oldname, item.Name), path.Join(newname, item.Name)); err != nil {
+			return err
+		}
+	}
+	err = y.client.delResourcePermanently(ctx, from)
+	y.opts.auditRecord(AuditRemove, from, 0, err)
+	if err != nil {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package ydfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestRenamePolicy(t *testing.T) {
+	fsys, md := newMockFS(t, WithRenamePolicy(RenameOverwrite))
+	md.put("/a.txt", []byte("new"))
+	md.put("/b.txt", []byte("old"))
+	md.put("/dir/c.txt", []byte("c"))
+	if err := fsys.Rename("/a.txt", "/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := fsys.ReadFile("/b.txt"); err != nil || string(data) != "new" {
+		t.Errorf("file is not replaced: %q, %v", data, err)
+	}
+	if err := fsys.Rename("/b.txt", "/dir"); !errors.Is(err, ErrIsDir) {
+		t.Errorf("want ErrIsDir renaming file over directory, have %v", err)
+	}
+	if err := fsys.Rename("/dir", "/b.txt"); !errors.Is(err, ErrNotDir) {
+		t.Errorf("want ErrNotDir renaming directory over file, have %v", err)
+	}
+
+	fsys, md = newMockFS(t, WithRenamePolicy(RenameMerge), WithDeleteGuard(func(p string, info fs.FileInfo) bool {
+		return p != "/dst/keep.txt"
+	}))
+	md.put("/src/a.txt", []byte("new a"))
+	md.put("/src/sub/b.txt", []byte("b"))
+	md.put("/src/keep.txt", []byte("new keep"))
+	md.put("/dst/a.txt", []byte("old a"))
+	md.put("/dst/sub/c.txt", []byte("c"))
+	md.put("/dst/keep.txt", []byte("old keep"))
+	if err := fsys.Rename("/src", "/dst"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("want guard refusal, have %v", err)
+	}
+	if data, _ := md.get("/dst/keep.txt"); string(data.data) != "old keep" {
+		t.Error("file protected by guard is replaced")
+	}
+	if _, ok := md.get("/src/keep.txt"); !ok {
+		t.Error("file refused by guard is removed from the old directory")
+	}
+
+	fsys, md = newMockFS(t, WithRenamePolicy(RenameMerge))
+	md.put("/src/a.txt", []byte("new a"))
+	md.put("/src/sub/b.txt", []byte("b"))
+	md.put("/dst/a.txt", []byte("old a"))
+	md.put("/dst/sub/c.txt", []byte("c"))
+	md.put("/dst/d.txt", []byte("d"))
+	if err := fsys.Rename("/src", "/dst"); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"/dst/a.txt": "new a", "/dst/sub/b.txt": "b", "/dst/sub/c.txt": "c", "/dst/d.txt": "d"} {
+		if data, err := fsys.ReadFile(name); err != nil || string(data) != want {
+			t.Errorf("%s: have %q, %v, want %q", name, data, err, want)
+		}
+	}
+	if _, ok := md.get("/src"); ok {
+		t.Error("merged directory is not removed")
+	}
+}
+
+func TestRename(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/a/x.txt", []byte("x"))
+	md.put("/b/y.txt", []byte("y"))
+	if err := fsys.Rename("/a/x.txt", "/b/z.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("/a/x.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("source exists after rename: %v", err)
+	}
+	if data, err := fsys.ReadFile("/b/z.txt"); err != nil || string(data) != "x" {
+		t.Errorf("unexpected contents after rename: %q %v", data, err)
+	}
+	if err := fsys.Rename("/b/z.txt", "/b/y.txt"); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("want fs.ErrExist renaming over existing file, have %v", err)
+	}
+}
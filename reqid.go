@@ -0,0 +1,123 @@
+package ydfs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// responseRequestIDHeaders are the headers which may carry
+// id assigned to the request by the API.
+var responseRequestIDHeaders = []string{"X-Request-Id", "Yandex-Cloud-Request-ID"}
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying correlation id.
+// The id is sent with every API request made with the returned context
+// if FS is created with WithRequestIDHeader option.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// WithRequestIDHeader makes FS send correlation id in the named header
+// (e.g. "X-Request-Id") with every request. The id is taken from the
+// request context (see ContextWithRequestID) or generated randomly.
+func WithRequestIDHeader(name string) Option {
+	return func(o *options) {
+		o.requestIDHeader = name
+	}
+}
+
+// requestID returns correlation id from ctx or a new random id.
+func requestID(ctx context.Context) string {
+	if ctx != nil {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok && id != "" {
+			return id
+		}
+	}
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RequestError describes failed API request. Err wraps one of the
+// package errors (ErrNotFound, ErrAPI etc.), so errors.Is can be used
+// to find out the reason of the failure.
+type RequestError struct {
+	Method       string        // HTTP method
+	URL          string        // requested URL
+	Status       int           // HTTP status code, zero if no response has been received
+	RequestID    string        // correlation id sent by FS (see WithRequestIDHeader)
+	APIRequestID string        // id assigned to the request by the API, if any
+	RetryAfter   time.Duration // delay requested by Retry-After header of the response, if any
+	Err          error
+}
+
+func (e *RequestError) Error() string {
+	s := e.Method + " " + e.URL
+	if e.Status != 0 {
+		s += fmt.Sprintf(" (status %d)", e.Status)
+	}
+	if e.RequestID != "" {
+		s += " [request id " + e.RequestID + "]"
+	}
+	if e.APIRequestID != "" {
+		s += " [API request id " + e.APIRequestID + "]"
+	}
+	return s + ": " + e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// requestError wraps err with context of request r
+// and response resp (which may be nil).
+func (c *apiclient) requestError(r *http.Request, resp *http.Response, err error) error {
+	e := &RequestError{Method: r.Method, URL: r.URL.String(), Err: err}
+	if c.requestIDHeader != "" {
+		e.RequestID = r.Header.Get(c.requestIDHeader)
+	}
+	if resp != nil {
+		e.Status = resp.StatusCode
+		for _, h := range responseRequestIDHeaders {
+			if id := resp.Header.Get(h); id != "" {
+				e.APIRequestID = id
+				break
+			}
+		}
+		e.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	return e
+}
+
+// parseRetryAfter parses value of Retry-After header, which is either
+// delay in seconds or HTTP date, into delay from now. Malformed and
+// past values are zero.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// retryAfter returns delay requested by the API in response to the
+// request failed with err, zero if none.
+func retryAfter(err error) time.Duration {
+	var e *RequestError
+	if errors.As(err, &e) {
+		return e.RetryAfter
+	}
+	return 0
+}
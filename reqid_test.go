@@ -0,0 +1,78 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestID(t *testing.T) {
+	md := newMockDisk()
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("X-Correlation-Id"))
+		w.Header().Set("X-Request-Id", "api-id")
+		md.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+	c := &http.Client{Transport: &rewriteTransport{target: target}}
+	fsys, err := New("mocktoken", c, WithRequestIDHeader("X-Correlation-Id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 1 || seen[0] == "" {
+		t.Errorf("random request id is not sent: %q", seen)
+	}
+
+	ctx := ContextWithRequestID(context.Background(), "corr-42")
+	err = fsys.DownloadFile(ctx, "/nonexistent", &memWriterAt{}, 1)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want ErrNotFound, have %v", err)
+	}
+	if seen[len(seen)-1] != "corr-42" {
+		t.Errorf("request id from context is not sent: %q", seen)
+	}
+	var re *RequestError
+	if !errors.As(err, &re) {
+		t.Fatalf("error does not contain RequestError: %v", err)
+	}
+	if re.Method != http.MethodGet || !strings.Contains(re.URL, "/v1/disk/resources") || re.Status != http.StatusNotFound {
+		t.Errorf("unexpected request context: %+v", re)
+	}
+	if re.RequestID != "corr-42" || re.APIRequestID != "api-id" {
+		t.Errorf("unexpected request ids: %+v", re)
+	}
+	if !strings.Contains(err.Error(), "corr-42") {
+		t.Errorf("error message lacks request id: %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for value, want := range map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"-5":                            0,
+		"soon":                          0,
+		"Wed, 01 May 2024 12:00:30 GMT": 30 * time.Second,
+		"Wed, 01 May 2024 11:00:00 GMT": 0,
+	} {
+		if have := parseRetryAfter(value, now); have != want {
+			t.Errorf("%q: want %v, have %v", value, want, have)
+		}
+	}
+
+	fsys, md := newMockFS(t)
+	md.retryAfter = "7"
+	md.failNext(http.StatusTooManyRequests, "TooManyRequestsError")
+	_, err := fsys.Stat("/")
+	if !errors.Is(err, ErrTooManyRequests) || retryAfter(err) != 7*time.Second {
+		t.Errorf("want throttling error with retry after 7s, have %v (%v)", err, retryAfter(err))
+	}
+}
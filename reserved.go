@@ -0,0 +1,45 @@
+package ydfs
+
+import (
+	"path"
+	"strings"
+)
+
+// defaultReservedDir is the hidden directory the library keeps its own
+// objects in unless WithReservedDir is given.
+const defaultReservedDir = "/.ydfs"
+
+// WithReservedDir sets the directory where the library keeps its own
+// objects: previous versions of files (see WithVersioning) and temporary
+// objects (see CleanupTemp). Dir is a path from the root of the disk (or
+// of the app folder for NewAppFolder), "/.ydfs" by default. All FS
+// working with the same disk must use the same directory.
+func WithReservedDir(dir string) Option {
+	return func(o *options) {
+		o.reservedDir = path.Clean("/" + dir)
+	}
+}
+
+// WithHideReserved leaves the directory set by WithReservedDir out of
+// listings of ReadDir, WalkIter, Syncer and other functions listing
+// directories, so that objects of the library do not show up next to
+// user files. The directory is still accessible by its path.
+func WithHideReserved() Option {
+	return func(o *options) {
+		o.hideReserved = true
+	}
+}
+
+// unlisted reports whether the resource with the given full path is left
+// out of directory listings. Within is set for flat listings of files,
+// where everything under the reserved directory is left out, while in
+// listings of directories it is enough to leave out the directory.
+func (c *apiclient) unlisted(name string, within bool) bool {
+	if c.hidden(name) {
+		return true
+	}
+	if c.hideDir == "" {
+		return false
+	}
+	return name == c.hideDir || within && strings.HasPrefix(name, c.hideDir+"/")
+}
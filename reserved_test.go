@@ -0,0 +1,54 @@
+package ydfs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReservedDir(t *testing.T) {
+	fsys, md := newMockFS(t, WithReservedDir(".lib"), WithHideReserved(), WithVersioning(2))
+	md.put("/docs/a.txt", []byte("v1"))
+	if err := fsys.WriteFile("/docs/a.txt", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	versions, err := fsys.ListVersions("/docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 || !strings.HasPrefix(versions[0].Resource.Path, "/.lib/versions/docs/a.txt/") {
+		t.Errorf("unexpected versions: %+v", versions)
+	}
+
+	entries, err := fsys.ReadDir("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() == ".lib" {
+			t.Error("reserved directory is listed by ReadDir")
+		}
+	}
+	seq, errf := fsys.WalkIter(context.Background(), "/")
+	for p := range seq {
+		if strings.HasPrefix(p, "/.lib") {
+			t.Errorf("reserved path %s is walked", p)
+		}
+	}
+	if err := errf(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("/.lib/versions"); err != nil {
+		t.Errorf("reserved directory is not accessible by path: %v", err)
+	}
+
+	md.put("/.lib/tmp/stale", nil)
+	md.update("/.lib/tmp/stale", func(e *mockEntry) { e.modified = time.Now().Add(-time.Hour) })
+	if err := fsys.CleanupTemp(context.Background(), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := md.get("/.lib/tmp/stale"); ok {
+		t.Error("stale temporary object is not removed from reserved directory")
+	}
+}
@@ -0,0 +1,101 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// ContentSource provides contents of files restored by Restore by
+// slash-separated paths relative to the root of snapshot, as fs.FS
+// does. Any fs.FS is ContentSource, e.g. os.DirFS for a local directory,
+// *zip.Reader for an archive or sub FS of another disk.
+type ContentSource interface {
+	Open(name string) (fs.File, error)
+}
+
+// Restore implements FS
+func (y *ydfs) Restore(ctx context.Context, snapshot *TreeSnapshot, src ContentSource) error {
+	if snapshot.Root == "" {
+		return &fs.PathError{Op: "restore", Path: snapshot.Root, Err: fmt.Errorf("%w: snapshot has no root", fs.ErrInvalid)}
+	}
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	root := snapshot.Root
+	if err := y.WithContext(ctx).MkdirAll(root); err != nil {
+		return err
+	}
+	existing, err := y.remoteTree(ctx, root, "sha256")
+	if err != nil {
+		return &fs.PathError{Op: "restore", Path: root, Err: err}
+	}
+	errs := make(PathErrors)
+	failedDirs := map[string]bool{}
+	for _, e := range snapshot.Entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if failedDirs[path.Dir(e.Path)] {
+			failedDirs[e.Path] = e.Dir
+			continue
+		}
+		name := path.Join(root, e.Path)
+		if e.Dir {
+			if err := y.WithContext(ctx).MkdirAll(name); err != nil {
+				errs.add(name, err)
+				failedDirs[e.Path] = true
+			}
+			continue
+		}
+		// files restored before are recognized by hashes
+		if res, ok := existing[e.Path]; ok && e.MD5 != "" && matchesEntry(res, e) {
+			continue
+		}
+		if err := y.restoreFile(ctx, name, e, src); err != nil {
+			errs.add(name, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// restoreFile uploads contents of entry e from src to the named file
+// and checks hashes of the uploaded file against the ones recorded in
+// the snapshot. Files which fail the check are removed.
+func (y *ydfs) restoreFile(ctx context.Context, name string, e SnapshotEntry, src ContentSource) error {
+	f, err := src.Open(e.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	size := int64(-1)
+	if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+		size = info.Size()
+	}
+	if err := y.writeStream(ctx, name, f, size); err != nil {
+		return err
+	}
+	fullname := y.fullPath(name)
+	res, err := y.client.getResource(ctx, fullname, 0, "size", "md5", "sha256")
+	if err != nil {
+		return &fs.PathError{Op: "restore", Path: name, Err: err}
+	}
+	if matchesEntry(res, e) {
+		return nil
+	}
+	err = y.client.delResourcePermanently(ctx, fullname)
+	y.opts.auditRecord(AuditRemove, fullname, 0, err)
+	return &fs.PathError{Op: "restore", Path: name, Err: errors.Join(ErrChecksumMismatch, err)}
+}
+
+// matchesEntry reports whether file res has size and hashes recorded
+// in entry e. Hashes missing either in res or in e are not compared.
+func matchesEntry(res Resource, e SnapshotEntry) bool {
+	return res.Size == e.Size &&
+		(e.MD5 == "" || res.MD5 == "" || res.MD5 == e.MD5) &&
+		(e.SHA256 == "" || res.SHA256 == "" || res.SHA256 == e.SHA256)
+}
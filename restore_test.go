@@ -0,0 +1,59 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRestore(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	md.put("/docs/sub/b.txt", []byte("bb"))
+	ctx := context.Background()
+	snap, err := fsys.Snapshot(ctx, "/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snap.Root = "/restored"
+	src := fstest.MapFS{
+		"a.txt":     {Data: []byte("a")},
+		"sub/b.txt": {Data: []byte("bb")},
+	}
+	if err := fsys.Restore(ctx, snap, src); err != nil {
+		t.Fatal(err)
+	}
+	for p, want := range map[string]string{"/restored/a.txt": "a", "/restored/sub/b.txt": "bb"} {
+		if e, ok := md.get(p); !ok || string(e.data) != want {
+			t.Errorf("%s is not restored", p)
+		}
+	}
+
+	// restored files are skipped, corrupted contents are not kept
+	uploads := md.uploads
+	md.put("/restored/a.txt", []byte("x"))
+	src["a.txt"] = &fstest.MapFile{Data: []byte("y")}
+	err = fsys.Restore(ctx, snap, src)
+	var errs PathErrors
+	if !errors.As(err, &errs) || !errors.Is(errs["/restored/a.txt"], ErrChecksumMismatch) {
+		t.Errorf("want checksum mismatch of a.txt, have %v", err)
+	}
+	if md.uploads != uploads+1 {
+		t.Errorf("want 1 upload, have %d", md.uploads-uploads)
+	}
+	if _, ok := md.get("/restored/a.txt"); ok {
+		t.Error("file failing verification is kept")
+	}
+
+	delete(src, "sub/b.txt")
+	snap.Root = "/other"
+	if err := fsys.Restore(ctx, snap, src); err == nil {
+		t.Error("Restore succeeds with missing contents")
+	}
+	snap.Root = ""
+	if err := fsys.Restore(ctx, snap, src); err == nil {
+		t.Error("Restore succeeds without root")
+	}
+}
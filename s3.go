@@ -0,0 +1,357 @@
+package ydfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// s3MaxKeys is the default and maximum number of keys returned by
+// a single ListObjectsV2 request.
+const s3MaxKeys = 1000
+
+// S3Handler returns a handler which serves fsys with a minimal subset of
+// the S3 protocol: ListObjectsV2, GetObject (and HeadObject), PutObject
+// and DeleteObject. Requests are expected in path style
+// (/bucket/key), buckets are directories at the root of fsys and keys
+// are slash separated paths of files inside them. Directories are not
+// listed as objects, but are reported as common prefixes.
+//
+// Request signatures are not verified, so the handler must be put behind
+// authentication or only be reachable by trusted clients. Streaming
+// (aws-chunked) uploads are not supported.
+func S3Handler(fsys FS) http.Handler {
+	return &s3Handler{fsys: fsys}
+}
+
+// s3Handler implements S3Handler
+type s3Handler struct {
+	fsys FS
+}
+
+// s3Object describes an object in ListObjectsV2 response
+type s3Object struct {
+	Key          string
+	LastModified string
+	ETag         string `xml:",omitempty"`
+	Size         int64
+	StorageClass string
+}
+
+// s3Prefix is a common prefix in ListObjectsV2 response
+type s3Prefix struct {
+	Prefix string
+}
+
+// s3ListResult is a ListObjectsV2 response
+type s3ListResult struct {
+	XMLName               xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name                  string
+	Prefix                string
+	Delimiter             string `xml:",omitempty"`
+	StartAfter            string `xml:",omitempty"`
+	ContinuationToken     string `xml:",omitempty"`
+	NextContinuationToken string `xml:",omitempty"`
+	MaxKeys               int
+	KeyCount              int
+	IsTruncated           bool
+	Contents              []s3Object
+	CommonPrefixes        []s3Prefix
+}
+
+// s3ErrorResult is an error response
+type s3ErrorResult struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string
+	Message  string
+	Resource string
+}
+
+// ServeHTTP implements http.Handler
+func (s *s3Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	switch {
+	case bucket == "":
+		s3Error(w, r, http.StatusNotImplemented, "NotImplemented", "listing buckets is not supported")
+	case key == "" && r.Method == http.MethodGet:
+		s.listObjects(w, r, bucket)
+	case key == "":
+		s3Error(w, r, http.StatusNotImplemented, "NotImplemented", "bucket operation is not supported")
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		s.getObject(w, r, bucket, key)
+	case r.Method == http.MethodPut:
+		s.putObject(w, r, bucket, key)
+	case r.Method == http.MethodDelete:
+		s.deleteObject(w, r, bucket, key)
+	default:
+		s3Error(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "method is not allowed")
+	}
+}
+
+// getObject serves contents of the object. HEAD requests are answered
+// from metadata, and GET requests download only the requested range.
+func (s *s3Handler) getObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	name := path.Join("/", bucket, key)
+	res, err := s.fsys.StatExtended(name)
+	if err != nil {
+		s3FSError(w, r, err, "NoSuchKey")
+		return
+	}
+	if res.IsDir() {
+		s3Error(w, r, http.StatusNotFound, "NoSuchKey", "the specified key does not exist")
+		return
+	}
+	content, err := openContent(r.Context(), s.fsys, name, res)
+	if err != nil {
+		s3FSError(w, r, err, "NoSuchKey")
+		return
+	}
+	defer content.Close()
+	if res.MD5 != "" {
+		w.Header().Set("ETag", `"`+res.MD5+`"`)
+	}
+	ctype := res.MimeType
+	if ctype == "" {
+		ctype = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", ctype)
+	http.ServeContent(w, r, name, res.Modified, content)
+}
+
+// putObject uploads the object creating directories of its key as
+// needed. Keys ending with slash create directories. Objects which do
+// not match Content-MD5 of the request are removed after the upload.
+func (s *s3Handler) putObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		s3Error(w, r, http.StatusNotImplemented, "NotImplemented", "streaming uploads are not supported")
+		return
+	}
+	if info, err := s.fsys.Stat("/" + bucket); err != nil || !info.IsDir() {
+		s3FSError(w, r, err, "NoSuchBucket")
+		return
+	}
+	name := path.Join("/", bucket, key)
+	if strings.HasSuffix(key, "/") {
+		if err := s.fsys.MkdirAll(name); err != nil {
+			s3FSError(w, r, err, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+		return
+	}
+	if dir := path.Dir(name); dir != "/"+bucket {
+		if err := s.fsys.MkdirAll(dir); err != nil {
+			s3FSError(w, r, err, "NoSuchKey")
+			return
+		}
+	}
+	var want []byte
+	if v := r.Header.Get("Content-MD5"); v != "" {
+		var err error
+		if want, err = base64.StdEncoding.DecodeString(v); err != nil || len(want) != md5.Size {
+			s3Error(w, r, http.StatusBadRequest, "InvalidDigest", "the Content-MD5 you specified is not valid")
+			return
+		}
+	}
+	h := md5.New()
+	if err := writeContent(r.Context(), s.fsys, name, io.TeeReader(r.Body, h), r.ContentLength); err != nil {
+		s3FSError(w, r, err, "NoSuchKey")
+		return
+	}
+	sum := h.Sum(nil)
+	if want != nil && !bytes.Equal(sum, want) {
+		// the object is not stored when the digest does not match
+		s.fsys.Remove(name)
+		s3Error(w, r, http.StatusBadRequest, "BadDigest", "the Content-MD5 you specified did not match what was received")
+		return
+	}
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum)+`"`)
+}
+
+// writeContent uploads contents of r to the named file of fsys. Size of
+// r is passed to the upload when known (not negative).
+func writeContent(ctx context.Context, fsys FS, name string, r io.Reader, size int64) error {
+	if y, ok := fsys.(*ydfs); ok {
+		return y.writeStream(ctx, name, r, size)
+	}
+	return fsys.WriteFileStream(name, r)
+}
+
+// deleteObject removes the object. Removing missing object succeeds
+// as S3 does.
+func (s *s3Handler) deleteObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	err := s.fsys.Remove(path.Join("/", bucket, key))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		s3FSError(w, r, err, "NoSuchKey")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listObjects serves ListObjectsV2 request. Only directories which may
+// contain matching keys past the continuation token are read, the walk
+// stops once the page is filled, and with "/" delimiter directories
+// under the prefix are not descended into at all.
+func (s *s3Handler) listObjects(w http.ResponseWriter, r *http.Request, bucket string) {
+	q := r.URL.Query()
+	result := s3ListResult{
+		Name:              bucket,
+		Prefix:            q.Get("prefix"),
+		Delimiter:         q.Get("delimiter"),
+		StartAfter:        q.Get("start-after"),
+		ContinuationToken: q.Get("continuation-token"),
+		MaxKeys:           s3MaxKeys,
+	}
+	if v := q.Get("max-keys"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s3Error(w, r, http.StatusBadRequest, "InvalidArgument", "invalid max-keys")
+			return
+		}
+		result.MaxKeys = min(n, s3MaxKeys)
+	}
+	after := result.StartAfter
+	if result.ContinuationToken != "" {
+		token, err := base64.RawURLEncoding.DecodeString(result.ContinuationToken)
+		if err != nil {
+			s3Error(w, r, http.StatusBadRequest, "InvalidArgument", "invalid continuation token")
+			return
+		}
+		after = max(after, string(token))
+	}
+
+	type item struct {
+		key string
+		res *Resource // nil for common prefixes
+	}
+	// items past after are collected in key order until there is one
+	// more than fits the page, which tells that the list is truncated
+	var (
+		items    []item
+		prefixes = make(map[string]bool)
+	)
+	full := func() bool { return len(items) > result.MaxKeys }
+	add := func(it item) {
+		if it.key > after && !full() {
+			items = append(items, it)
+		}
+	}
+	addPrefix := func(p string) {
+		if !prefixes[p] {
+			prefixes[p] = true
+			add(item{key: p})
+		}
+	}
+	// keys of a directory share its key as prefix, so walking entries
+	// sorted by key lists the whole tree in key order
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		entries, err := s.fsys.ReadDirExtended(path.Join("/", bucket, dir))
+		if err != nil {
+			return err
+		}
+		keyed := make([]item, len(entries))
+		for i := range entries {
+			res := &entries[i]
+			keyed[i] = item{key: dir + res.Name, res: res}
+			if res.IsDir() {
+				keyed[i].key += "/"
+			}
+		}
+		sort.Slice(keyed, func(i, j int) bool { return keyed[i].key < keyed[j].key })
+		for _, it := range keyed {
+			if full() {
+				return nil
+			}
+			key, res := it.key, it.res
+			switch {
+			case res.IsDir() && result.Delimiter == "/" && key != result.Prefix && strings.HasPrefix(key, result.Prefix):
+				addPrefix(key)
+			case res.IsDir() && (strings.HasPrefix(key, result.Prefix) || strings.HasPrefix(result.Prefix, key)):
+				// keys of directories before after are all listed already
+				if key <= after && !strings.HasPrefix(after, key) {
+					continue
+				}
+				if err := walk(key); err != nil {
+					return err
+				}
+			case res.IsDir() || !strings.HasPrefix(key, result.Prefix):
+			case result.Delimiter != "" && strings.Contains(key[len(result.Prefix):], result.Delimiter):
+				rest := key[len(result.Prefix):]
+				addPrefix(result.Prefix + rest[:strings.Index(rest, result.Delimiter)+len(result.Delimiter)])
+			default:
+				add(it)
+			}
+		}
+		return nil
+	}
+	if err := walk(""); err != nil {
+		s3FSError(w, r, err, "NoSuchBucket")
+		return
+	}
+
+	last := after
+	for _, it := range items {
+		if result.KeyCount == result.MaxKeys {
+			result.IsTruncated = true
+			result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(last))
+			break
+		}
+		result.KeyCount++
+		last = it.key
+		if it.res == nil {
+			result.CommonPrefixes = append(result.CommonPrefixes, s3Prefix{Prefix: it.key})
+			continue
+		}
+		obj := s3Object{
+			Key:          it.key,
+			LastModified: it.res.Modified.UTC().Format("2006-01-02T15:04:05.000Z"),
+			Size:         it.res.Size,
+			StorageClass: "STANDARD",
+		}
+		if it.res.MD5 != "" {
+			obj.ETag = `"` + it.res.MD5 + `"`
+		}
+		result.Contents = append(result.Contents, obj)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(result)
+}
+
+// s3FSError replies with S3 error matching err returned by FS. notFound
+// is the code reported for missing resources.
+func s3FSError(w http.ResponseWriter, r *http.Request, err error, notFound string) {
+	switch {
+	case err == nil, errors.Is(err, fs.ErrNotExist), errors.Is(err, ErrNotDir):
+		s3Error(w, r, http.StatusNotFound, notFound, "the specified resource does not exist")
+	case errors.Is(err, fs.ErrPermission), errors.Is(err, ErrInfected):
+		s3Error(w, r, http.StatusForbidden, "AccessDenied", "access denied")
+	case errors.Is(err, ErrTooManyRequests):
+		s3Error(w, r, http.StatusServiceUnavailable, "SlowDown", "please reduce your request rate")
+	default:
+		s3Error(w, r, http.StatusInternalServerError, "InternalError", err.Error())
+	}
+}
+
+// s3Error writes S3 error response.
+func s3Error(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(s3ErrorResult{Code: code, Message: message, Resource: r.URL.Path})
+}
@@ -0,0 +1,178 @@
+package ydfs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestS3Handler(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/bucket/a.txt", []byte("a"))
+	md.put("/bucket/dir/b.txt", []byte("bb"))
+	md.put("/bucket/dir/sub/c.txt", []byte("ccc"))
+	srv := httptest.NewServer(S3Handler(fsys))
+	defer srv.Close()
+
+	do := func(method, p, body string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+p, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+	list := func(q url.Values) s3ListResult {
+		t.Helper()
+		q.Set("list-type", "2")
+		code, body := do(http.MethodGet, "/bucket?"+q.Encode(), "")
+		if code != http.StatusOK {
+			t.Fatalf("list: %d %s", code, body)
+		}
+		var result s3ListResult
+		if err := xml.Unmarshal([]byte(body), &result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	keys := func(result s3ListResult) string {
+		var k []string
+		for _, o := range result.Contents {
+			k = append(k, o.Key)
+		}
+		for _, p := range result.CommonPrefixes {
+			k = append(k, p.Prefix)
+		}
+		return strings.Join(k, ",")
+	}
+
+	if code, _ := do(http.MethodPut, "/bucket/new/d.txt", "dddd"); code != http.StatusOK {
+		t.Errorf("PutObject: %d", code)
+	}
+	if code, body := do(http.MethodGet, "/bucket/new/d.txt", ""); code != http.StatusOK || body != "dddd" {
+		t.Errorf("GetObject: %d %q", code, body)
+	}
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/bucket/bad.txt", strings.NewReader("bad"))
+	req.Header.Set("Content-MD5", "1B2M2Y8AsgTpgAmY7PhCfg==") // digest of empty body
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if resp.Body.Close(); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("PutObject with wrong Content-MD5: %d", resp.StatusCode)
+	}
+	if _, ok := md.get("/bucket/bad.txt"); ok {
+		t.Error("PutObject with wrong Content-MD5 stored the object")
+	}
+	req, _ = http.NewRequest(http.MethodPut, srv.URL+"/bucket/good.txt", strings.NewReader(""))
+	req.Header.Set("Content-MD5", "1B2M2Y8AsgTpgAmY7PhCfg==")
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if resp.Body.Close(); resp.StatusCode != http.StatusOK {
+		t.Errorf("PutObject with Content-MD5: %d", resp.StatusCode)
+	}
+	if code, _ := do(http.MethodDelete, "/bucket/good.txt", ""); code != http.StatusNoContent {
+		t.Errorf("DeleteObject: %d", code)
+	}
+	if code, body := do(http.MethodGet, "/bucket/missing", ""); code != http.StatusNotFound || !strings.Contains(body, "NoSuchKey") {
+		t.Errorf("GetObject of missing key: %d %s", code, body)
+	}
+	if code, body := do(http.MethodPut, "/nobucket/x", "x"); code != http.StatusNotFound || !strings.Contains(body, "NoSuchBucket") {
+		t.Errorf("PutObject to missing bucket: %d %s", code, body)
+	}
+
+	if k := keys(list(url.Values{})); k != "a.txt,dir/b.txt,dir/sub/c.txt,new/d.txt" {
+		t.Errorf("unexpected keys: %s", k)
+	}
+	if k := keys(list(url.Values{"delimiter": {"/"}})); k != "a.txt,dir/,new/" {
+		t.Errorf("unexpected keys with delimiter: %s", k)
+	}
+	if k := keys(list(url.Values{"prefix": {"dir/"}, "delimiter": {"/"}})); k != "dir/b.txt,dir/sub/" {
+		t.Errorf("unexpected keys with prefix: %s", k)
+	}
+	page := list(url.Values{"max-keys": {"3"}})
+	if !page.IsTruncated || page.KeyCount != 3 || page.Contents[0].ETag == "" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	page = list(url.Values{"max-keys": {"3"}, "continuation-token": {page.NextContinuationToken}})
+	if page.IsTruncated || keys(page) != "new/d.txt" {
+		t.Errorf("unexpected second page: %+v", page)
+	}
+
+	if code, _ := do(http.MethodDelete, "/bucket/a.txt", ""); code != http.StatusNoContent {
+		t.Errorf("DeleteObject: %d", code)
+	}
+	if _, ok := md.get("/bucket/a.txt"); ok {
+		t.Error("DeleteObject left object in place")
+	}
+	if code, _ := do(http.MethodDelete, "/bucket/a.txt", ""); code != http.StatusNoContent {
+		t.Errorf("DeleteObject of missing key: %d", code)
+	}
+}
+
+func TestS3HeadObject(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/bucket/a.txt", []byte("hello"))
+	srv := httptest.NewServer(S3Handler(fsys))
+	defer srv.Close()
+
+	n := md.requests()
+	resp, err := http.Head(srv.URL + "/bucket/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ContentLength != 5 || resp.Header.Get("ETag") != `"5d41402abc4b2a76b9719d911017c592"` {
+		t.Errorf("unexpected HeadObject response: %d, length %d, ETag %s", resp.StatusCode, resp.ContentLength, resp.Header.Get("ETag"))
+	}
+	if got := md.requests() - n; got != 1 {
+		t.Errorf("HeadObject made %d requests, want metadata request only", got)
+	}
+}
+
+func TestS3ListObjectsPage(t *testing.T) {
+	fsys, md := newMockFS(t)
+	for i := range 10 {
+		md.put(fmt.Sprintf("/bucket/d%d/x.txt", i), []byte("x"))
+	}
+	srv := httptest.NewServer(S3Handler(fsys))
+	defer srv.Close()
+
+	list := func(q url.Values) (s3ListResult, int) {
+		t.Helper()
+		n := md.requests()
+		resp, err := http.Get(srv.URL + "/bucket?list-type=2&" + q.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var result s3ListResult
+		if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		return result, md.requests() - n
+	}
+
+	// the bucket and directories up to the first key past the page
+	page, n := list(url.Values{"max-keys": {"2"}})
+	if !page.IsTruncated || len(page.Contents) != 2 || page.Contents[1].Key != "d1/x.txt" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	if n != 4 {
+		t.Errorf("first page made %d requests, want 4", n)
+	}
+	// the bucket, the directory of start-after and the directories past it
+	page, n = list(url.Values{"max-keys": {"2"}, "start-after": {"d7/x.txt"}})
+	if page.IsTruncated || len(page.Contents) != 2 || page.Contents[0].Key != "d8/x.txt" {
+		t.Fatalf("unexpected last page: %+v", page)
+	}
+	if n != 4 {
+		t.Errorf("last page made %d requests, want 4", n)
+	}
+}
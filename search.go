@@ -0,0 +1,38 @@
+package ydfs
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// SearchName implements FS
+func (y *ydfs) SearchName(ctx context.Context, substring string, limit int) ([]Resource, error) {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	if limit <= 0 {
+		return nil, &fs.PathError{Op: "search", Path: substring, Err: fmt.Errorf("%w: limit must be positive", fs.ErrInvalid)}
+	}
+	substring = strings.ToLower(substring)
+	var result []Resource
+	// the API has no search, so the flat listing of files is paged
+	// through until enough matches are found
+	err := y.client.listFiles(ctx, filesPageSize, y.client.fields, func(res Resource) bool {
+		if !strings.Contains(strings.ToLower(res.Name), substring) {
+			return ctx.Err() == nil
+		}
+		y.client.normalize(&res)
+		if y.inside(res.Path) {
+			result = append(result, res)
+		}
+		return len(result) < limit && ctx.Err() == nil
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, &fs.PathError{Op: "search", Path: substring, Err: err}
+	}
+	return result, nil
+}
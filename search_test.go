@@ -0,0 +1,54 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+func TestSearchName(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/Report-2024.pdf", []byte("a"))
+	md.put("/docs/report-2023.pdf", []byte("b"))
+	md.put("/archive/report.zip", []byte("c"))
+	md.put("/reports/notes.txt", []byte("d"))
+	found, err := fsys.SearchName(context.Background(), "REPORT", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, res := range found {
+		paths = append(paths, res.Path)
+		if res.Size == 0 {
+			t.Errorf("%s has no metadata", res.Path)
+		}
+	}
+	if len(paths) != 3 || paths[0] != "/archive/report.zip" || paths[1] != "/docs/Report-2024.pdf" {
+		t.Errorf("unexpected matches: %q", paths)
+	}
+
+	// listing stops once enough files are found
+	for i := 0; i < 2*filesPageSize; i++ {
+		md.put(fmt.Sprintf("/zzz/file%04d", i), nil)
+	}
+	n := md.requests()
+	if found, err := fsys.SearchName(context.Background(), "report", 1); err != nil || len(found) != 1 {
+		t.Errorf("SearchName with limit returned %v, %v", found, err)
+	}
+	if md.requests()-n != 1 {
+		t.Errorf("search sends %d requests, want 1", md.requests()-n)
+	}
+
+	sub, err := fsys.Sub("/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, err := sub.SearchName(context.Background(), "report", 10); err != nil || len(found) != 2 {
+		t.Errorf("SearchName in sub FS returned %v, %v", found, err)
+	}
+	if _, err := fsys.SearchName(context.Background(), "x", 0); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("want fs.ErrInvalid for zero limit, have %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package ydfs
+
+import (
+	"context"
+	"io/fs"
+	"path"
+)
+
+// Access rights to shared folders as reported in ShareInfo.Rights.
+const (
+	ShareRightsReadOnly  = "r"
+	ShareRightsReadWrite = "rw"
+)
+
+// ShareInfo describes access to a resource within a shared folder.
+type ShareInfo struct {
+	IsRoot  bool   `json:"is_root"`  // the resource is the shared folder itself
+	IsOwned bool   `json:"is_owned"` // the folder is shared by the owner of the disk
+	Rights  string `json:"rights"`   // ShareRightsReadOnly or ShareRightsReadWrite
+}
+
+// ReadOnly reports whether the resource can not be modified.
+func (s *ShareInfo) ReadOnly() bool {
+	return s.Rights == ShareRightsReadOnly
+}
+
+// shareFields are fields requested to find shared folders.
+var shareFields = []string{"name", "path", "type", "share"}
+
+// ListSharedFolders implements FS
+func (y *ydfs) ListSharedFolders(ctx context.Context) ([]Resource, error) {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	var (
+		result []Resource
+		queue  = []string{"/"}
+	)
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		res, err := y.client.getResourceWithEmbedded(ctx, y.fullPath(dir), listingFields(shareFields...)...)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdirent", Path: dir, Err: err}
+		}
+		y.client.normalize(&res)
+		for _, item := range res.Embedded.Items {
+			switch {
+			case !item.IsDir():
+			case item.Share != nil && item.Share.IsRoot:
+				result = append(result, item)
+			case item.Share == nil:
+				queue = append(queue, path.Join(dir, item.Name))
+			}
+		}
+	}
+	return result, nil
+}
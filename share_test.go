@@ -0,0 +1,54 @@
+package ydfs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestListSharedFolders(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/team/docs/a.txt", []byte("a"))
+	md.put("/work/readonly/b.txt", []byte("b"))
+	md.put("/work/own/c.txt", []byte("c"))
+	md.put("/private/d.txt", []byte("d"))
+	md.update("/team", func(e *mockEntry) { e.share = ShareRightsReadWrite })
+	md.update("/work/readonly", func(e *mockEntry) { e.share = ShareRightsReadOnly })
+	md.update("/work/own", func(e *mockEntry) { e.share, e.owned = ShareRightsReadWrite, true })
+
+	shared, err := fsys.ListSharedFolders(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]*ShareInfo{}
+	for _, res := range shared {
+		got[res.Path] = res.Share
+	}
+	if len(got) != 3 {
+		t.Fatalf("ListSharedFolders returns %v, want 3 folders", shared)
+	}
+	if s := got["/work/readonly"]; s == nil || !s.ReadOnly() || s.IsOwned {
+		t.Errorf("read-only share is reported as %+v", s)
+	}
+	if s := got["/work/own"]; s == nil || s.ReadOnly() || !s.IsOwned {
+		t.Errorf("owned share is reported as %+v", s)
+	}
+
+	res, err := fsys.StatExtended("/team/docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Share == nil || res.Share.IsRoot || res.Share.Rights != ShareRightsReadWrite {
+		t.Errorf("StatExtended reports share info %+v", res.Share)
+	}
+	if res, _ := fsys.StatExtended("/private/d.txt"); res.Share != nil {
+		t.Errorf("StatExtended reports share info %+v for private file", res.Share)
+	}
+
+	sub, err := fsys.Sub("/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shared, err := sub.ListSharedFolders(context.Background()); err != nil || len(shared) != 2 {
+		t.Errorf("ListSharedFolders of sub FS returns %v, %v", shared, err)
+	}
+}
@@ -0,0 +1,88 @@
+package ydfs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// flightGroup collapses concurrent identical requests into one. Zero
+// value is ready to use.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+// flight is a request in progress shared by its waiters.
+type flight struct {
+	done    chan struct{}
+	waiters int
+	cancel  context.CancelFunc
+	code    int
+	data    []byte
+	err     error
+}
+
+// do calls fn once for concurrent calls with the same key and returns
+// its results to all of them. Every caller stops waiting when its ctx
+// is done, and the shared call is cancelled once nobody waits for it.
+// fn is called with context which keeps values of ctx of the first
+// caller, but not its cancellation.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(context.Context) (int, []byte, error)) (int, []byte, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flight)
+	}
+	f, ok := g.calls[key]
+	if !ok {
+		fctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = f
+		go func() {
+			f.code, f.data, f.err = fn(fctx)
+			cancel()
+			g.forget(key, f)
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.code, f.data, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			g.forgetLocked(key, f)
+		}
+		g.mu.Unlock()
+		return 0, nil, fmt.Errorf("%w: %w", ErrNetwork, context.Cause(ctx))
+	}
+}
+
+// forget removes f from g so that later calls with key start anew.
+func (g *flightGroup) forget(key string, f *flight) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.forgetLocked(key, f)
+}
+
+// forgetLocked is forget which must be called with g.mu held.
+func (g *flightGroup) forgetLocked(key string, f *flight) {
+	if g.calls[key] == f {
+		delete(g.calls, key)
+	}
+}
+
+// unsharedKey marks context of requests which must not share responses
+// of identical requests in flight, which may have been sent before
+// a change the caller needs to see.
+type unsharedKey struct{}
+
+// unshared returns ctx whose requests are sent on their own.
+func unshared(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unsharedKey{}, true)
+}
@@ -0,0 +1,97 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSingleFlight(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	md.setDelays(100*time.Millisecond, 0)
+	before := md.requests()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if info, err := fsys.Stat("/docs/a.txt"); err != nil || info.Size() != 1 {
+				t.Errorf("unexpected Stat result: %v %v", info, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := md.requests() - before; n != 1 {
+		t.Errorf("want 1 request for concurrent identical Stat calls, have %d", n)
+	}
+}
+
+func TestSingleFlightUnshared(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/a.txt", []byte("a"))
+	md.setDelays(100*time.Millisecond, 0)
+	c := fsys.(*ydfs).client
+	before := md.requests()
+	var wg sync.WaitGroup
+	for _, ctx := range []context.Context{context.Background(), unshared(context.Background())} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.getResource(ctx, "/a.txt", 0, lockFields...); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := md.requests() - before; n != 2 {
+		t.Errorf("want unshared request sent on its own, have %d requests", n)
+	}
+}
+
+func TestSingleFlightCancel(t *testing.T) {
+	var g flightGroup
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (int, []byte, error) {
+		close(started)
+		select {
+		case <-release:
+			return 200, []byte("ok"), nil
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		_, _, err := g.do(ctx, "k", fn)
+		errc <- err
+	}()
+	<-started
+	done := make(chan []byte)
+	go func() {
+		_, data, _ := g.do(context.Background(), "k", fn)
+		done <- data
+	}()
+	// wait for the second caller to join the flight
+	for {
+		g.mu.Lock()
+		n := g.calls["k"].waiters
+		g.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled for cancelled caller, have %v", err)
+	}
+	close(release)
+	if data := <-done; string(data) != "ok" {
+		t.Errorf("cancelling one caller affected another: %q", data)
+	}
+}
@@ -0,0 +1,29 @@
+package ydfs
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+)
+
+// WithSkipUnchanged makes WriteFile (and Sync of files) compare MD5
+// of data being written with MD5 of the existing file as reported by
+// the API and skip the upload if they are the same. This costs a
+// metadata request per write, but saves bandwidth when the same
+// contents are written over and over again (e.g. by naive sync scripts).
+func WithSkipUnchanged() Option {
+	return func(o *options) {
+		o.skipSame = true
+	}
+}
+
+// unchanged reports whether file at full path name has contents equal
+// to data. Any error (e.g. missing file) means that the file is changed.
+func unchanged(ctx context.Context, c *apiclient, name string, data []byte) bool {
+	res, err := c.getResource(ctx, name, 0, "type", "size", "md5")
+	if err != nil || !res.IsFile() || res.Size != int64(len(data)) || res.MD5 == "" {
+		return false
+	}
+	sum := md5.Sum(data)
+	return res.MD5 == hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,43 @@
+package ydfs
+
+import (
+	"testing"
+)
+
+func TestSkipUnchanged(t *testing.T) {
+	fsys, md := newMockFS(t, WithSkipUnchanged())
+	if err := fsys.WriteFile("/a.txt", []byte("same")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.WriteFile("/a.txt", []byte("same")); err != nil {
+		t.Fatal(err)
+	}
+	if n := md.uploadCount(); n != 1 {
+		t.Errorf("unchanged file is uploaded again, %d uploads", n)
+	}
+	if err := fsys.WriteFile("/a.txt", []byte("diff")); err != nil {
+		t.Fatal(err)
+	}
+	if n := md.uploadCount(); n != 2 {
+		t.Errorf("changed file is not uploaded, %d uploads", n)
+	}
+
+	f, err := fsys.Create("/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("diff"))
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := md.uploadCount(); n != 2 {
+		t.Errorf("Close uploads unchanged file, %d uploads", n)
+	}
+
+	plain, md := newMockFS(t)
+	plain.WriteFile("/a.txt", []byte("same"))
+	plain.WriteFile("/a.txt", []byte("same"))
+	if n := md.uploadCount(); n != 2 {
+		t.Errorf("uploads are skipped without option, %d uploads", n)
+	}
+}
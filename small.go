@@ -0,0 +1,64 @@
+package ydfs
+
+import (
+	"context"
+	"os"
+)
+
+// WithSmallFileThreshold makes Open request download link of a file
+// concurrently with its metadata and download contents of files
+// smaller than n bytes right away, so that reading a small file takes
+// a single round trip after Open. Download links of larger files are
+// kept to be used by the first Read. The link is not requested for
+// files opened for creation or overwriting and for directories known
+// from the metadata cache (see WithMetadataCache), but opening other
+// directories costs a request for it. Zero or negative n disables
+// the fast path.
+func WithSmallFileThreshold(n int64) Option {
+	return func(o *options) {
+		o.smallFile = n
+	}
+}
+
+// linkResult is download link or error fetching it.
+type linkResult struct {
+	link link
+	err  error
+}
+
+// prefetchLink starts fetching download link of the file at full path
+// name opened with flag if small file fast path is enabled. The link is
+// sent to the returned channel, which is nil if the fast path is
+// disabled or the link would not be used: the file is opened to be
+// created or overwritten, or it is known to be a directory.
+func (y *ydfs) prefetchLink(ctx context.Context, name string, flag int) <-chan linkResult {
+	if y.opts.smallFile <= 0 || flag&(os.O_WRONLY|os.O_CREATE|os.O_TRUNC) != 0 || name == y.path {
+		return nil
+	}
+	if res, ok := y.client.cache.get(name, false); ok && res.IsDir() {
+		return nil
+	}
+	links := make(chan linkResult, 1)
+	go func() {
+		l, err := y.client.getDownloadLink(ctx, name)
+		links <- linkResult{l, err}
+	}()
+	return links
+}
+
+// prefetch attaches download link received from links to file and
+// downloads contents of the file if it is small. Errors are ignored,
+// Read fetches the file the usual way then.
+func (y *ydfs) prefetch(ctx context.Context, file *ydfile, links <-chan linkResult) {
+	lr := <-links
+	if lr.err != nil {
+		return
+	}
+	file.link = &lr.link
+	if file.size >= y.opts.smallFile {
+		return
+	}
+	if data, err := y.client.getFileLink(ctx, lr.link); err == nil {
+		file.data = data
+	}
+}
@@ -0,0 +1,73 @@
+package ydfs
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSmallFileThreshold(t *testing.T) {
+	fsys, md := newMockFS(t, WithSmallFileThreshold(1024))
+	md.put("/small.txt", []byte("small"))
+	big := bytes.Repeat([]byte("x"), 4096)
+	md.put("/big.bin", big)
+	md.put("/dir/x", nil)
+
+	f, err := fsys.Open("/small.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := md.requests()
+	data, err := io.ReadAll(f)
+	if err != nil || string(data) != "small" {
+		t.Fatalf("read %q, %v", data, err)
+	}
+	if md.requests() != n {
+		t.Errorf("Read of small file sends %d requests", md.requests()-n)
+	}
+
+	f, err = fsys.Open("/big.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n = md.requests()
+	data, err = io.ReadAll(f)
+	if err != nil || !bytes.Equal(data, big) {
+		t.Fatalf("read %d bytes, %v", len(data), err)
+	}
+	if md.requests() != n+1 {
+		t.Errorf("Read of big file sends %d requests, want only download", md.requests()-n)
+	}
+
+	if _, err := fsys.Open("/dir"); err != nil {
+		t.Errorf("Open of directory fails: %v", err)
+	}
+	if _, err := fsys.Open("/missing"); err == nil {
+		t.Error("Open of missing file succeeds")
+	}
+}
+
+func TestSmallFileThresholdSkipsLinks(t *testing.T) {
+	fsys, md := newMockFS(t, WithSmallFileThreshold(1024), WithMetadataCache(time.Minute))
+	md.put("/dir/x", nil)
+	if _, err := fsys.Stat("/dir"); err != nil {
+		t.Fatal(err)
+	}
+	for _, open := range []func() error{
+		func() error { _, err := fsys.Open("/"); return err },
+		func() error { _, err := fsys.Open("/dir"); return err },
+		func() error { _, err := fsys.OpenFile("/new.txt", os.O_RDWR|os.O_CREATE, 0); return err },
+	} {
+		n := md.requests()
+		if err := open(); err != nil {
+			t.Fatal(err)
+		}
+		// the link would be requested concurrently with metadata
+		time.Sleep(20 * time.Millisecond)
+		if got := md.requests() - n; got != 1 {
+			t.Errorf("open sends %d requests, want metadata request only", got)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package ydfs
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SnapshotEntry is a file or directory recorded in TreeSnapshot.
+type SnapshotEntry struct {
+	Path     string     `json:"path"` // slash-separated path relative to the root
+	Dir      bool       `json:"dir,omitempty"`
+	Size     int64      `json:"size,omitempty"`
+	Modified *time.Time `json:"modified,omitempty"` // nil for directories
+	MD5      string     `json:"md5,omitempty"`
+	SHA256   string     `json:"sha256,omitempty"`
+}
+
+// TreeSnapshot is metadata of files of a directory tree taken at some
+// moment. Directories are recorded as parents of files, so empty ones
+// are not recorded. Snapshots can be saved as JSON or CSV and loaded
+// again to be compared on another machine or kept as manifests of
+// backups.
+type TreeSnapshot struct {
+	Root    string          `json:"root"`    // snapshotted directory
+	Taken   time.Time       `json:"taken"`   // time the snapshot was taken at
+	Entries []SnapshotEntry `json:"entries"` // sorted by path, parents before children
+}
+
+// Snapshot implements FS
+func (y *ydfs) Snapshot(ctx context.Context, root string) (*TreeSnapshot, error) {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	taken := time.Now().Round(0)
+	tree, err := y.remoteTree(ctx, root, "sha256")
+	if err != nil {
+		return nil, &fs.PathError{Op: "snapshot", Path: root, Err: err}
+	}
+	s := &TreeSnapshot{Root: root, Taken: taken}
+	dirs := map[string]bool{".": true}
+	for p, res := range tree {
+		for d := path.Dir(p); !dirs[d]; d = path.Dir(d) {
+			dirs[d] = true
+			s.Entries = append(s.Entries, SnapshotEntry{Path: d, Dir: true})
+		}
+		s.Entries = append(s.Entries, SnapshotEntry{Path: p, Size: res.Size, Modified: &res.Modified, MD5: res.MD5, SHA256: res.SHA256})
+	}
+	sort.Slice(s.Entries, func(i, j int) bool {
+		return s.Entries[i].Path < s.Entries[j].Path
+	})
+	return s, nil
+}
+
+// Diff returns changes of files turning s into next sorted by path.
+// Metadata of events holds path relative to the root, size,
+// modification time and hashes of files.
+func (s *TreeSnapshot) Diff(next *TreeSnapshot) []Event {
+	return diffTrees(s.files(), next.files())
+}
+
+// files returns files of the snapshot as resources by path.
+func (s *TreeSnapshot) files() map[string]Resource {
+	files := make(map[string]Resource, len(s.Entries))
+	for _, e := range s.Entries {
+		if !e.Dir {
+			res := Resource{Name: path.Base(e.Path), Path: e.Path, Type: TypeFile, Size: e.Size, MD5: e.MD5, SHA256: e.SHA256}
+			if e.Modified != nil {
+				res.Modified = *e.Modified
+			}
+			files[e.Path] = res
+		}
+	}
+	return files
+}
+
+// WriteJSON writes the snapshot to w as JSON.
+func (s *TreeSnapshot) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(s)
+}
+
+// ReadSnapshotJSON reads snapshot written by WriteJSON.
+func ReadSnapshotJSON(r io.Reader) (*TreeSnapshot, error) {
+	s := &TreeSnapshot{}
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, fmt.Errorf("%w: malformed snapshot: %v", fs.ErrInvalid, err)
+	}
+	return s, nil
+}
+
+// snapshotCSVHeader is the first record of snapshots written as CSV.
+var snapshotCSVHeader = []string{"path", "type", "size", "modified", "md5", "sha256"}
+
+// WriteCSV writes entries of the snapshot to w as CSV with a header
+// record, one entry per record. Root and time of the snapshot are not
+// written.
+func (s *TreeSnapshot) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write(snapshotCSVHeader)
+	for _, e := range s.Entries {
+		typ, modified := TypeFile, ""
+		if e.Dir {
+			typ = TypeDir
+		}
+		if e.Modified != nil {
+			modified = e.Modified.Format(time.RFC3339Nano)
+		}
+		cw.Write([]string{e.Path, typ, strconv.FormatInt(e.Size, 10), modified, e.MD5, e.SHA256})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadSnapshotCSV reads entries of snapshot written by WriteCSV.
+// Root and time of the returned snapshot are not set.
+func ReadSnapshotCSV(r io.Reader) (*TreeSnapshot, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(snapshotCSVHeader)
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed snapshot: %v", fs.ErrInvalid, err)
+	}
+	if len(records) == 0 || records[0][0] != snapshotCSVHeader[0] {
+		return nil, fmt.Errorf("%w: malformed snapshot: no header", fs.ErrInvalid)
+	}
+	s := &TreeSnapshot{Entries: make([]SnapshotEntry, 0, len(records)-1)}
+	for i, rec := range records[1:] {
+		e := SnapshotEntry{Path: rec[0], Dir: rec[1] == TypeDir, MD5: rec[4], SHA256: rec[5]}
+		if e.Size, err = strconv.ParseInt(rec[2], 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: malformed snapshot: record %d: %v", fs.ErrInvalid, i+2, err)
+		}
+		if rec[3] != "" {
+			modified, err := time.Parse(time.RFC3339Nano, rec[3])
+			if err != nil {
+				return nil, fmt.Errorf("%w: malformed snapshot: record %d: %v", fs.ErrInvalid, i+2, err)
+			}
+			e.Modified = &modified
+		}
+		s.Entries = append(s.Entries, e)
+	}
+	return s, nil
+}
@@ -0,0 +1,85 @@
+package ydfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	md.put("/docs/sub/deep/b.txt", []byte("b"))
+	md.put("/other/c.txt", []byte("c"))
+	ctx := context.Background()
+
+	snap, err := fsys.Snapshot(ctx, "/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, e := range snap.Entries {
+		paths = append(paths, e.Path)
+	}
+	if have, want := strings.Join(paths, " "), "a.txt sub sub/deep sub/deep/b.txt"; have != want {
+		t.Errorf("want entries %s, have %s", want, have)
+	}
+	if e := snap.Entries[0]; e.Size != 1 || e.MD5 == "" || e.SHA256 == "" || e.Modified == nil {
+		t.Errorf("incomplete entry: %+v", e)
+	}
+
+	var js, js2 bytes.Buffer
+	if err := snap.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(js.String(), `"modified"`); n != 2 {
+		t.Errorf("want modification times of 2 files in JSON, have %d", n)
+	}
+	loaded, err := ReadSnapshotJSON(bytes.NewReader(js.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded.WriteJSON(&js2)
+	if js.String() != js2.String() {
+		t.Errorf("JSON round trip changes snapshot:\n%s\n%s", js.String(), js2.String())
+	}
+
+	var csv1, csv2 bytes.Buffer
+	if err := snap.WriteCSV(&csv1); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = ReadSnapshotCSV(bytes.NewReader(csv1.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded.WriteCSV(&csv2)
+	if csv1.String() != csv2.String() {
+		t.Errorf("CSV round trip changes snapshot:\n%s\n%s", csv1.String(), csv2.String())
+	}
+	if len(snap.Diff(loaded)) != 0 {
+		t.Errorf("loaded snapshot differs: %v", snap.Diff(loaded))
+	}
+
+	md.put("/docs/a.txt", []byte("changed"))
+	md.put("/docs/new.txt", []byte("new"))
+	next, err := fsys.Snapshot(ctx, "/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	for _, e := range loaded.Diff(next) {
+		events = append(events, e.String())
+	}
+	if have, want := strings.Join(events, ", "), "modify a.txt, create new.txt"; have != want {
+		t.Errorf("want changes %s, have %s", want, have)
+	}
+
+	for _, bad := range []string{"", "path,type\n", "name,type,size,modified,md5,sha256\n", "path,type,size,modified,md5,sha256\na,file,x,,,\n"} {
+		if _, err := ReadSnapshotCSV(strings.NewReader(bad)); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("%q: want fs.ErrInvalid, have %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package ydfs
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// Fields which directory listings can be sorted by.
+const (
+	SortByName     = "name"
+	SortByPath     = "path"
+	SortByCreated  = "created"
+	SortByModified = "modified"
+	SortBySize     = "size"
+)
+
+// sortParam returns value of sort parameter for the API.
+func sortParam(sortBy string, desc bool) (string, error) {
+	switch sortBy {
+	case SortByName, SortByPath, SortByCreated, SortByModified, SortBySize:
+	default:
+		return "", fmt.Errorf("%w: unknown sort field %q", fs.ErrInvalid, sortBy)
+	}
+	if desc {
+		return "-" + sortBy, nil
+	}
+	return sortBy, nil
+}
+
+// ReadDirSorted implements FS
+func (y *ydfs) ReadDirSorted(name string, sortBy string, desc bool) ([]fs.DirEntry, error) {
+	sort, err := sortParam(sortBy, desc)
+	if err != nil {
+		return []fs.DirEntry{}, &fs.PathError{Op: "readdirent", Path: name, Err: err}
+	}
+	return y.readDir(y.context(), name, sort)
+}
+
+// SubSorted implements FS
+func (y *ydfs) SubSorted(dir string, sortBy string, desc bool) (FS, error) {
+	sort, err := sortParam(sortBy, desc)
+	if err != nil {
+		return nil, &fs.PathError{Op: "sub", Path: dir, Err: err}
+	}
+	sub, err := y.Sub(dir)
+	if err != nil {
+		return nil, err
+	}
+	sub.(*ydfs).sort = sort
+	return sub, nil
+}
@@ -0,0 +1,77 @@
+package ydfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestReadDirSorted(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/dir/a.txt", []byte("aaa"))
+	md.put("/dir/b.txt", []byte("b"))
+	md.put("/dir/c.txt", []byte("cc"))
+	names := func(entries []fs.DirEntry) (result []string) {
+		for _, e := range entries {
+			result = append(result, e.Name())
+		}
+		return
+	}
+	entries, err := fsys.ReadDirSorted("/dir", SortBySize, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := names(entries); len(have) != 3 || have[0] != "a.txt" || have[2] != "b.txt" {
+		t.Errorf("unexpected order of entries: %v", have)
+	}
+	if md.lastQuery().Get("sort") != "-size" {
+		t.Errorf("want sort=-size, have %q", md.lastQuery().Get("sort"))
+	}
+	if _, err := fsys.ReadDirSorted("/dir", "color", false); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("want fs.ErrInvalid for unknown sort field, have %v", err)
+	}
+
+	sub, err := fsys.SubSorted("/dir", SortBySize, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err = sub.ReadDir("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := names(entries); len(have) != 3 || have[0] != "b.txt" {
+		t.Errorf("unexpected order of entries in sorted sub FS: %v", have)
+	}
+}
+
+func TestReadDirPaging(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/dir/a.txt", []byte("a"))
+	md.put("/dir/b.txt", []byte("b"))
+	md.put("/dir/c.txt", []byte("c"))
+	f, err := fsys.Open("/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var names []string
+	for {
+		entries, err := f.(fs.ReadDirFile).ReadDir(1)
+		if len(entries) > 1 {
+			t.Fatalf("ReadDir(1) returns %d entries", len(entries))
+		}
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if have := strings.Join(names, " "); have != "a.txt b.txt c.txt" {
+		t.Errorf("unexpected entries read by pages: %s", have)
+	}
+}
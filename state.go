@@ -0,0 +1,106 @@
+package ydfs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// SyncRecord is state of a file remembered by sync tools between runs.
+type SyncRecord struct {
+	MD5        string     `json:"md5,omitempty"`         // MD5 of contents when last synced
+	Size       int64      `json:"size"`                  // size of contents
+	Modified   time.Time  `json:"modified"`              // modification time of remote file
+	Revision   int64      `json:"revision,omitempty"`    // revision of remote file
+	ResourceID string     `json:"resource_id,omitempty"` // id of remote file, stable across moves
+	Expires    *time.Time `json:"expires,omitempty"`     // expiry of temporary public link if any, see TempShares
+}
+
+// StateStore keeps sync records by path.
+// Implementations must be safe for concurrent use.
+type StateStore interface {
+	// Get returns the record of path, false if there is none.
+	Get(path string) (SyncRecord, bool, error)
+
+	// Set stores the record of path.
+	Set(path string, rec SyncRecord) error
+
+	// Delete removes the record of path. Deleting of missing
+	// record is not an error.
+	Delete(path string) error
+}
+
+// FileStateStore is StateStore keeping records in memory and writing
+// them to a JSON file on every change. It suits stores of up to tens
+// of thousands of records.
+type FileStateStore struct {
+	mu      sync.Mutex
+	records map[string]SyncRecord
+	file    string // not persisted if empty
+}
+
+// OpenFileStateStore returns store loading records from the named file
+// if it exists. Records are kept in memory only if name is empty.
+func OpenFileStateStore(name string) (*FileStateStore, error) {
+	s := &FileStateStore{records: make(map[string]SyncRecord), file: name}
+	if name == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.records); err != nil {
+		return nil, fmt.Errorf("%w: malformed state file %s: %v", ErrInternal, name, err)
+	}
+	return s, nil
+}
+
+// Get implements StateStore
+func (s *FileStateStore) Get(path string) (SyncRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[path]
+	return rec, ok, nil
+}
+
+// Set implements StateStore
+func (s *FileStateStore) Set(path string, rec SyncRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[path] = rec
+	return s.save()
+}
+
+// Delete implements StateStore
+func (s *FileStateStore) Delete(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.records[path]; !ok {
+		return nil
+	}
+	delete(s.records, path)
+	return s.save()
+}
+
+// save atomically replaces the file with records. Must be called with mu held.
+func (s *FileStateStore) save() error {
+	if s.file == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.records)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInternal, err)
+	}
+	tmp := s.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.file)
+}
@@ -0,0 +1,48 @@
+package ydfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStateStore(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "state.json")
+	s, err := OpenFileStateStore(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ StateStore = s
+	rec := SyncRecord{MD5: "abc", Size: 3, Modified: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), ResourceID: "id:1"}
+	if err := s.Set("/a.txt", rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("/b.txt", SyncRecord{Size: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("/missing"); err != nil {
+		t.Errorf("Delete of missing record returns %v", err)
+	}
+
+	reopened, err := OpenFileStateStore(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, err := reopened.Get("/a.txt"); err != nil || !ok || got != rec {
+		t.Errorf("got record %+v, %v, %v after reopening, want %+v", got, ok, err, rec)
+	}
+	if _, ok, _ := reopened.Get("/b.txt"); ok {
+		t.Error("deleted record is kept")
+	}
+
+	if err := os.WriteFile(name, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFileStateStore(name); err == nil {
+		t.Error("malformed state file is accepted")
+	}
+}
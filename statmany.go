@@ -0,0 +1,56 @@
+package ydfs
+
+import (
+	"context"
+	"io/fs"
+	"sync"
+)
+
+// statManyWorkers is the number of concurrent requests made by StatMany.
+var statManyWorkers = 8
+
+// StatErrors is returned by StatMany if metadata of some paths can not
+// be fetched. It maps the paths to their errors.
+type StatErrors = PathErrors
+
+// StatMany implements FS
+func (y *ydfs) StatMany(ctx context.Context, paths []string) (map[string]fs.FileInfo, error) {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		infos = make(map[string]fs.FileInfo, len(paths))
+		errs  = make(StatErrors)
+		jobs  = make(chan string)
+	)
+	for i := 0; i < min(statManyWorkers, len(paths)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				info, err := y.stat(ctx, "stat", name)
+				mu.Lock()
+				if err != nil {
+					errs[name] = err
+				} else {
+					infos[name] = info
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	seen := make(map[string]bool, len(paths))
+	for _, name := range paths {
+		if !seen[name] {
+			seen[name] = true
+			jobs <- name
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if len(errs) > 0 {
+		return infos, errs
+	}
+	return infos, nil
+}
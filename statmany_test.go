@@ -0,0 +1,27 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStatMany(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	md.put("/docs/b.txt", []byte("bb"))
+	infos, err := fsys.StatMany(context.Background(), []string{"/docs/a.txt", "/docs/b.txt", "/docs/a.txt", "/docs/missing"})
+	if len(infos) != 2 || infos["/docs/b.txt"].Size() != 2 {
+		t.Errorf("unexpected infos: %v", infos)
+	}
+	var serr StatErrors
+	if !errors.As(err, &serr) || len(serr) != 1 || !errors.Is(serr["/docs/missing"], ErrNotFound) {
+		t.Errorf("unexpected errors: %v", err)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("want error matching ErrNotFound, have %v", err)
+	}
+	if _, err := fsys.StatMany(context.Background(), []string{"/docs/a.txt"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
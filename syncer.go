@@ -0,0 +1,467 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SyncDirection tells which of the directories synced by Syncer is the
+// source and which one is made its copy.
+type SyncDirection int
+
+const (
+	SyncUp   SyncDirection = iota + 1 // local directory is copied to the disk
+	SyncDown                          // remote directory is copied to local disk
+)
+
+// ActionKind is a kind of change made by Syncer.
+type ActionKind int
+
+const (
+	ActionUpload       ActionKind = iota + 1 // local file is uploaded
+	ActionDownload                           // remote file is downloaded
+	ActionDeleteRemote                       // remote file is deleted
+	ActionDeleteLocal                        // local file is deleted
+	ActionMoveRemote                         // remote file is moved
+	ActionMoveLocal                          // local file is moved
+)
+
+// String returns the name of the action as shown in plans.
+func (k ActionKind) String() string {
+	switch k {
+	case ActionUpload:
+		return "upload"
+	case ActionDownload:
+		return "download"
+	case ActionDeleteRemote:
+		return "delete remote"
+	case ActionDeleteLocal:
+		return "delete local"
+	case ActionMoveRemote:
+		return "move remote"
+	case ActionMoveLocal:
+		return "move local"
+	}
+	return "unknown"
+}
+
+// Action is a change of a single file planned by Syncer.
+type Action struct {
+	Kind ActionKind
+	Path string // slash-separated and relative to synced directories
+	From string // previous path of moved file
+}
+
+// String returns the action as a line of dry-run output,
+// e.g. "upload docs/a.txt" or "move remote a.txt -> b.txt".
+func (a Action) String() string {
+	if a.From != "" {
+		return a.Kind.String() + " " + a.From + " -> " + a.Path
+	}
+	return a.Kind.String() + " " + a.Path
+}
+
+// SyncOption configures Syncer.
+type SyncOption func(*Syncer)
+
+// WithSyncDelete makes Syncer mirror the source directory deleting
+// files missing in it from the destination one. By default files are
+// only added and updated. Files renamed or moved within the source
+// directory are then moved in the destination one instead of being
+// copied again.
+func WithSyncDelete() SyncOption {
+	return func(s *Syncer) {
+		s.delete = true
+	}
+}
+
+// WithSyncState makes Syncer keep records of synced files in store.
+func WithSyncState(store StateStore) SyncOption {
+	return func(s *Syncer) {
+		s.state = store
+	}
+}
+
+// WithSyncFilter makes Syncer only sync files passing filter. Files
+// excluded by filter are neither copied nor deleted on either side.
+func WithSyncFilter(filter *Filter) SyncOption {
+	return func(s *Syncer) {
+		s.filter = filter
+	}
+}
+
+// WithSyncProgress makes Syncer send SyncProgress to ch as actions are
+// finished. Sends block until ch is read or the sync is cancelled. The
+// channel is not closed by Syncer.
+func WithSyncProgress(ch chan<- SyncProgress) SyncOption {
+	return func(s *Syncer) {
+		s.progress = ch
+	}
+}
+
+// syncFields are fields of remote files kept in sync records.
+var syncFields = []string{"md5", "revision", "resource_id"}
+
+// Syncer makes one of a local and a remote directory a copy of the other
+// comparing files by sizes and MD5 sums. Only files are synced:
+// directories are created as files are copied into them, and empty
+// directories are neither created nor deleted.
+type Syncer struct {
+	fsys      *ydfs
+	remote    string
+	local     string
+	direction SyncDirection
+	delete    bool
+	state     StateStore
+	filter    *Filter
+	progress  chan<- SyncProgress
+}
+
+// SyncReport summarizes results of applying a sync plan.
+type SyncReport struct {
+	Uploaded   int           // number of uploaded files
+	Downloaded int           // number of downloaded files
+	BytesUp    int64         // size of uploaded files
+	BytesDown  int64         // size of downloaded files
+	Moved      int           // number of files moved on either side
+	Deleted    int           // number of files deleted on either side
+	Skipped    int           // number of unchanged files, only counted by Run
+	Failed     PathErrors    // errors of failed actions by their paths
+	Duration   time.Duration // time the sync took
+}
+
+// add counts the finished action which transferred n bytes.
+func (r *SyncReport) add(a Action, n int64, err error) {
+	switch {
+	case err != nil:
+		if r.Failed == nil {
+			r.Failed = make(PathErrors)
+		}
+		r.Failed[a.Path] = err
+	case a.Kind == ActionUpload:
+		r.Uploaded++
+		r.BytesUp += n
+	case a.Kind == ActionDownload:
+		r.Downloaded++
+		r.BytesDown += n
+	case a.Kind == ActionMoveRemote, a.Kind == ActionMoveLocal:
+		r.Moved++
+	case a.Kind == ActionDeleteRemote, a.Kind == ActionDeleteLocal:
+		r.Deleted++
+	}
+}
+
+// SyncProgress reports an action finished by Syncer
+// (see WithSyncProgress).
+type SyncProgress struct {
+	Action Action
+	Err    error // nil if the action succeeded
+	Done   int   // number of finished actions including this one
+	Total  int   // number of actions in the plan
+	Bytes  int64 // number of bytes transferred by the action
+}
+
+// Syncer implements FS
+func (y *ydfs) Syncer(remoteDir, localDir string, direction SyncDirection, opts ...SyncOption) *Syncer {
+	s := &Syncer{fsys: y, remote: remoteDir, local: localDir, direction: direction}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Plan compares the directories and returns actions Run would execute
+// sorted by paths. Nothing is changed, so the plan may be shown to the
+// user or checked before it is passed to Apply.
+func (s *Syncer) Plan(ctx context.Context) ([]Action, error) {
+	plan, _, err := s.plan(ctx)
+	return plan, err
+}
+
+// plan implements Plan also returning the number of unchanged files.
+func (s *Syncer) plan(ctx context.Context) ([]Action, int, error) {
+	ctx, cancel := s.fsys.bind(ctx)
+	defer cancel()
+	if s.direction != SyncUp && s.direction != SyncDown {
+		return nil, 0, &fs.PathError{Op: "sync", Path: s.remote, Err: fs.ErrInvalid}
+	}
+	remote, err := s.fsys.remoteTree(ctx, s.remote, syncFields...)
+	if err != nil {
+		return nil, 0, &fs.PathError{Op: "sync", Path: s.remote, Err: err}
+	}
+	for rel, res := range remote {
+		if !s.filter.Match(rel, s.fsys.opts.info(res)) {
+			delete(remote, rel)
+		}
+	}
+	var report *VerifyReport
+	if _, err := os.Stat(s.local); errors.Is(err, fs.ErrNotExist) && s.direction == SyncDown {
+		report = &VerifyReport{}
+		for rel := range remote {
+			report.Extra = append(report.Extra, rel)
+		}
+	} else if report, err = s.fsys.compareTree(ctx, remote, s.local, s.filter); err != nil {
+		return nil, 0, err
+	}
+	unchanged := len(remote) - len(report.Extra) - len(report.Modified)
+
+	plan, err := s.detectMoves(ctx, remote, report)
+	if err != nil {
+		return nil, 0, err
+	}
+	add := func(kind ActionKind, paths []string) {
+		for _, p := range paths {
+			plan = append(plan, Action{Kind: kind, Path: p})
+		}
+	}
+	if s.direction == SyncUp {
+		add(ActionUpload, report.Missing)
+		add(ActionUpload, report.Modified)
+		if s.delete {
+			add(ActionDeleteRemote, report.Extra)
+		}
+	} else {
+		add(ActionDownload, report.Extra)
+		add(ActionDownload, report.Modified)
+		if s.delete {
+			add(ActionDeleteLocal, report.Missing)
+		}
+	}
+	sort.Slice(plan, func(i, j int) bool { return plan[i].Path < plan[j].Path })
+	return plan, unchanged, nil
+}
+
+// detectMoves finds files created in the source directory having the
+// same contents as files removed from it, and returns moves of removed
+// files to new paths in the destination directory. Moved files are
+// taken off report. Moves are only made when Syncer deletes files.
+//
+// For SyncDown files removed locally are first matched by resource ids
+// kept in sync records, since the disk keeps ids of moved resources.
+// Otherwise files are matched by sizes and MD5 sums, and local files
+// are only hashed if there are remote ones of the same size.
+func (s *Syncer) detectMoves(ctx context.Context, remote map[string]Resource, report *VerifyReport) ([]Action, error) {
+	if !s.delete {
+		return nil, nil
+	}
+	kind, created, removed := ActionMoveRemote, &report.Missing, &report.Extra
+	if s.direction == SyncDown {
+		kind, created, removed = ActionMoveLocal, &report.Extra, &report.Missing
+	}
+	createdLocally := s.direction == SyncUp
+	sums := map[string]string{}
+	stat := func(rel string, local bool) (int64, error) {
+		if !local {
+			return remote[rel].Size, nil
+		}
+		info, err := os.Stat(filepath.Join(s.local, filepath.FromSlash(rel)))
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	}
+	sum := func(rel string, local bool) (string, error) {
+		if !local {
+			return remote[rel].MD5, nil
+		}
+		if sum, ok := sums[rel]; ok {
+			return sum, nil
+		}
+		sum, err := md5File(filepath.Join(s.local, filepath.FromSlash(rel)))
+		sums[rel] = sum
+		return sum, err
+	}
+
+	bySize := map[int64][]string{}
+	byID := map[string]string{}
+	for _, rel := range *removed {
+		size, err := stat(rel, !createdLocally)
+		if err != nil {
+			return nil, err
+		}
+		bySize[size] = append(bySize[size], rel)
+		if s.state == nil || createdLocally {
+			continue
+		}
+		if rec, ok, err := s.state.Get(rel); err != nil {
+			return nil, err
+		} else if ok && rec.ResourceID != "" {
+			byID[rec.ResourceID] = rel
+		}
+	}
+	var moves []Action
+	moved := map[string]bool{}
+	for _, rel := range *created {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		size, err := stat(rel, createdLocally)
+		if err != nil {
+			return nil, err
+		}
+		candidates := bySize[size]
+		if from, ok := byID[remote[rel].ResourceID]; ok && !createdLocally {
+			candidates = append([]string{from}, candidates...)
+		}
+		for _, from := range candidates {
+			if moved[from] {
+				continue
+			}
+			relSum, err := sum(rel, createdLocally)
+			if err != nil {
+				return nil, err
+			}
+			fromSum, err := sum(from, !createdLocally)
+			if err != nil {
+				return nil, err
+			}
+			if relSum != "" && relSum == fromSum {
+				moved[from], moved[rel] = true, true
+				moves = append(moves, Action{Kind: kind, Path: rel, From: from})
+				break
+			}
+		}
+	}
+	unmoved := func(paths []string) []string {
+		var result []string
+		for _, p := range paths {
+			if !moved[p] {
+				result = append(result, p)
+			}
+		}
+		return result
+	}
+	*created, *removed = unmoved(*created), unmoved(*removed)
+	return moves, nil
+}
+
+// Apply executes actions of plan (normally returned by Plan) in order
+// and reports the results. Failed actions do not stop the sync: their
+// errors are collected in the report and returned joined. Only
+// cancellation of ctx stops it early.
+func (s *Syncer) Apply(ctx context.Context, plan []Action) (*SyncReport, error) {
+	return s.applyPlan(ctx, plan, &SyncReport{}, time.Now())
+}
+
+// Run plans the sync and applies the plan.
+func (s *Syncer) Run(ctx context.Context) (*SyncReport, error) {
+	start := time.Now()
+	plan, unchanged, err := s.plan(ctx)
+	if err != nil {
+		return &SyncReport{Duration: time.Since(start)}, err
+	}
+	return s.applyPlan(ctx, plan, &SyncReport{Skipped: unchanged}, start)
+}
+
+// applyPlan implements Apply adding results to report.
+func (s *Syncer) applyPlan(ctx context.Context, plan []Action, report *SyncReport, start time.Time) (*SyncReport, error) {
+	ctx, cancel := s.fsys.bind(ctx)
+	defer cancel()
+	var errs []error
+	for i, a := range plan {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		n, err := s.apply(ctx, a)
+		report.add(a, n, err)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if s.progress != nil {
+			select {
+			case s.progress <- SyncProgress{Action: a, Err: err, Done: i + 1, Total: len(plan), Bytes: n}:
+			case <-ctx.Done():
+			}
+		}
+	}
+	report.Duration = time.Since(start)
+	return report, errors.Join(errs...)
+}
+
+// apply executes a single action, updates its sync record and returns
+// the number of bytes transferred.
+func (s *Syncer) apply(ctx context.Context, a Action) (int64, error) {
+	remoteName := path.Join(s.remote, a.Path)
+	localName := filepath.Join(s.local, filepath.FromSlash(a.Path))
+	fsys := s.fsys.WithContext(ctx)
+	var (
+		n   int64
+		err error
+	)
+	switch a.Kind {
+	case ActionUpload:
+		n, err = s.upload(ctx, localName, remoteName)
+	case ActionDownload:
+		if err = os.MkdirAll(filepath.Dir(localName), s.fsys.opts.dirMode); err == nil {
+			err = s.fsys.DownloadToFile(ctx, remoteName, localName, false)
+		}
+		if info, serr := os.Stat(localName); err == nil && serr == nil {
+			n = info.Size()
+		}
+	case ActionDeleteRemote:
+		err = fsys.Remove(remoteName)
+	case ActionDeleteLocal:
+		if err = os.Remove(localName); errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+	case ActionMoveRemote:
+		if err = fsys.MkdirAll(path.Dir(remoteName)); err == nil {
+			err = fsys.Rename(path.Join(s.remote, a.From), remoteName)
+		}
+	case ActionMoveLocal:
+		if err = os.MkdirAll(filepath.Dir(localName), s.fsys.opts.dirMode); err == nil {
+			err = os.Rename(filepath.Join(s.local, filepath.FromSlash(a.From)), localName)
+		}
+	default:
+		err = &fs.PathError{Op: "sync", Path: a.Path, Err: fs.ErrInvalid}
+	}
+	if err != nil || s.state == nil {
+		return n, err
+	}
+	return n, s.record(ctx, a, remoteName)
+}
+
+// record updates sync record of the file changed by action.
+func (s *Syncer) record(ctx context.Context, a Action, remoteName string) error {
+	if a.Kind == ActionDeleteRemote || a.Kind == ActionDeleteLocal {
+		return s.state.Delete(a.Path)
+	}
+	if a.From != "" {
+		if err := s.state.Delete(a.From); err != nil {
+			return err
+		}
+	}
+	res, err := s.fsys.client.getResource(ctx, s.fsys.fullPath(remoteName), 0, append([]string{"size", "modified"}, syncFields...)...)
+	if err != nil {
+		return &fs.PathError{Op: "sync", Path: remoteName, Err: err}
+	}
+	return s.state.Set(a.Path, SyncRecord{MD5: res.MD5, Size: res.Size, Modified: res.Modified, Revision: res.Revision, ResourceID: res.ResourceID})
+}
+
+// upload uploads the local file creating remote directories as needed
+// and returns its size.
+func (s *Syncer) upload(ctx context.Context, localName, remoteName string) (int64, error) {
+	f, err := os.Open(localName)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if err := s.fsys.WithContext(ctx).MkdirAll(path.Dir(remoteName)); err != nil {
+		return 0, err
+	}
+	if err := s.fsys.writeStream(ctx, remoteName, f, info.Size()); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,222 @@
+package ydfs
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeLocalFiles creates files under dir.
+func writeLocalFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSyncerUp(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/backup/same.txt", []byte("same"))
+	md.put("/backup/changed.txt", []byte("old"))
+	md.put("/backup/extra.txt", []byte("extra"))
+	local := t.TempDir()
+	writeLocalFiles(t, local, map[string]string{
+		"same.txt":        "same",
+		"changed.txt":     "new",
+		"dir/missing.txt": "missing",
+	})
+
+	state, _ := OpenFileStateStore("")
+	s := fsys.Syncer("/backup", local, SyncUp, WithSyncDelete(), WithSyncState(state))
+	plan, err := s.Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Action{
+		{Kind: ActionUpload, Path: "changed.txt"},
+		{Kind: ActionUpload, Path: "dir/missing.txt"},
+		{Kind: ActionDeleteRemote, Path: "extra.txt"},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Fatalf("want plan %v, have %v", want, plan)
+	}
+	if plan[2].String() != "delete remote extra.txt" {
+		t.Errorf("unexpected action string %q", plan[2])
+	}
+	if _, ok := md.get("/backup/extra.txt"); !ok {
+		t.Fatal("Plan changes remote directory")
+	}
+
+	if _, err := s.Apply(context.Background(), plan); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := md.get("/backup/dir/missing.txt"); !ok || string(e.data) != "missing" {
+		t.Error("missing file is not uploaded")
+	}
+	if e, ok := md.get("/backup/changed.txt"); !ok || string(e.data) != "new" {
+		t.Error("changed file is not uploaded")
+	}
+	if _, ok := md.get("/backup/extra.txt"); ok {
+		t.Error("extra file is not deleted")
+	}
+	if rec, ok, _ := state.Get("dir/missing.txt"); !ok || rec.Size != 7 || rec.MD5 == "" {
+		t.Errorf("unexpected sync record %+v", rec)
+	}
+	if _, ok, _ := state.Get("extra.txt"); ok {
+		t.Error("record of deleted file is kept")
+	}
+
+	if plan, err := s.Plan(context.Background()); err != nil || len(plan) != 0 {
+		t.Errorf("want empty plan after sync, have %v %v", plan, err)
+	}
+}
+
+func TestSyncerDown(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/backup/a.txt", []byte("a"))
+	md.put("/backup/dir/b.txt", []byte("b"))
+	local := filepath.Join(t.TempDir(), "copy")
+
+	s := fsys.Syncer("/backup", local, SyncDown)
+	if _, err := s.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(local, "dir", "b.txt")); string(data) != "b" {
+		t.Errorf("unexpected contents %q", data)
+	}
+
+	writeLocalFiles(t, local, map[string]string{"local.txt": "local"})
+	plan, err := s.Plan(context.Background())
+	if err != nil || len(plan) != 0 {
+		t.Errorf("extra local file is planned without WithSyncDelete: %v %v", plan, err)
+	}
+	s = fsys.Syncer("/backup", local, SyncDown, WithSyncDelete())
+	if _, err := s.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(local, "local.txt")); !os.IsNotExist(err) {
+		t.Errorf("extra local file is not deleted: %v", err)
+	}
+}
+
+func TestSyncerMoves(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/backup/old.txt", []byte("moved contents"))
+	md.put("/backup/twin1.txt", []byte("twin"))
+	local := t.TempDir()
+	writeLocalFiles(t, local, map[string]string{
+		"dir/new.txt": "moved contents",
+		"twin1.txt":   "twin",
+		"twin2.txt":   "twin",
+	})
+
+	state, _ := OpenFileStateStore("")
+	up := fsys.Syncer("/backup", local, SyncUp, WithSyncDelete(), WithSyncState(state))
+	plan, err := up.Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Action{
+		{Kind: ActionMoveRemote, Path: "dir/new.txt", From: "old.txt"},
+		{Kind: ActionUpload, Path: "twin2.txt"},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Fatalf("want plan %v, have %v", want, plan)
+	}
+	if plan[0].String() != "move remote old.txt -> dir/new.txt" {
+		t.Errorf("unexpected action string %q", plan[0])
+	}
+	if _, err := up.Apply(context.Background(), plan); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := md.get("/backup/dir/new.txt"); !ok || string(e.data) != "moved contents" {
+		t.Error("file is not moved")
+	}
+	if _, ok := md.get("/backup/old.txt"); ok {
+		t.Error("moved file is kept")
+	}
+
+	// remote renames are found by resource ids even if other files
+	// have the same contents
+	down := fsys.Syncer("/backup", local, SyncDown, WithSyncDelete(), WithSyncState(state))
+	if err := fsys.Rename("/backup/twin2.txt", "/backup/renamed.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Remove("/backup/twin1.txt"); err != nil {
+		t.Fatal(err)
+	}
+	md.put("/backup/twin3.txt", []byte("twin"))
+	plan, err = down.Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Action{
+		{Kind: ActionMoveLocal, Path: "renamed.txt", From: "twin2.txt"},
+		{Kind: ActionMoveLocal, Path: "twin3.txt", From: "twin1.txt"},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Fatalf("want plan %v, have %v", want, plan)
+	}
+	if _, err := down.Apply(context.Background(), plan); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(local, "renamed.txt")); string(data) != "twin" {
+		t.Errorf("unexpected contents %q", data)
+	}
+	if _, ok, _ := state.Get("twin2.txt"); ok {
+		t.Error("record of moved file is kept")
+	}
+	if rec, ok, _ := state.Get("renamed.txt"); !ok || rec.ResourceID == "" {
+		t.Errorf("unexpected record of moved file %+v", rec)
+	}
+}
+
+func TestSyncerReport(t *testing.T) {
+	fsys, md := newMockFS(t, WithDeleteGuard(func(name string, info fs.FileInfo) bool {
+		return path.Base(name) != "extra.txt"
+	}))
+	md.put("/backup/same.txt", []byte("same"))
+	md.put("/backup/extra.txt", []byte("extra"))
+	md.put("/backup/dir", []byte("file in place of directory"))
+	local := t.TempDir()
+	writeLocalFiles(t, local, map[string]string{
+		"same.txt":  "same",
+		"new.txt":   "12345",
+		"dir/a.txt": "a",
+	})
+
+	progress := make(chan SyncProgress, 10)
+	s := fsys.Syncer("/backup", local, SyncUp, WithSyncDelete(), WithSyncProgress(progress))
+	report, err := s.Run(context.Background())
+	if err == nil {
+		t.Error("failed deletion is not reported")
+	}
+	if report.Uploaded != 2 || report.BytesUp != 6 || report.Deleted != 1 || report.Skipped != 1 || len(report.Failed) != 1 || report.Failed["extra.txt"] == nil {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if report.Duration <= 0 {
+		t.Error("duration is not reported")
+	}
+	close(progress)
+	var done []string
+	for p := range progress {
+		if p.Total != 4 || p.Done != len(done)+1 {
+			t.Errorf("unexpected progress %+v", p)
+		}
+		done = append(done, p.Action.String())
+	}
+	want := []string{"delete remote dir", "upload dir/a.txt", "delete remote extra.txt", "upload new.txt"}
+	if !reflect.DeepEqual(done, want) {
+		t.Errorf("want progress of %v, have %v", want, done)
+	}
+}
@@ -0,0 +1,47 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"time"
+)
+
+// tempDir is the directory within the reserved directory (see
+// WithReservedDir) keeping temporary objects of the library, e.g. files
+// being written, which are moved to their places when complete. Objects
+// left there by interrupted processes are removed by CleanupTemp.
+const tempDir = "tmp"
+
+// CleanupTemp implements FS
+func (y *ydfs) CleanupTemp(ctx context.Context, olderThan time.Duration) error {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	dir := path.Join(y.opts.reservedDir, tempDir)
+	deadline := time.Now().Add(-olderThan)
+	var stale []string
+	err := y.client.listDir(ctx, dir, "", dirPageSize, func(res Resource) bool {
+		if res.Modified.Before(deadline) {
+			stale = append(stale, path.Join(dir, res.Name))
+		}
+		return true
+	})
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return &fs.PathError{Op: "cleanup", Path: dir, Err: err}
+	}
+	errs := make(PathErrors)
+	for _, name := range stale {
+		err := y.client.delResourcePermanently(ctx, name)
+		y.opts.auditRecord(AuditRemove, name, 0, err)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			errs.add(name, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package ydfs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCleanupTemp(t *testing.T) {
+	fsys, md := newMockFS(t)
+	ctx := context.Background()
+	if err := fsys.CleanupTemp(ctx, time.Hour); err != nil {
+		t.Errorf("cleanup without temp directory fails: %v", err)
+	}
+
+	md.put("/.ydfs/tmp/stale", []byte("stale"))
+	md.put("/.ydfs/tmp/stale-dir/part", []byte("part"))
+	md.put("/.ydfs/tmp/fresh", []byte("fresh"))
+	old := time.Now().Add(-2 * time.Hour)
+	md.update("/.ydfs/tmp/stale", func(e *mockEntry) { e.modified = old })
+	md.update("/.ydfs/tmp/stale-dir", func(e *mockEntry) { e.modified = old })
+
+	if err := fsys.CleanupTemp(ctx, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	for p, want := range map[string]bool{
+		"/.ydfs/tmp/stale":          false,
+		"/.ydfs/tmp/stale-dir/part": false,
+		"/.ydfs/tmp/fresh":          true,
+	} {
+		if _, ok := md.get(p); ok != want {
+			t.Errorf("%s: want exists %v, have %v", p, want, ok)
+		}
+	}
+}
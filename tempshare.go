@@ -0,0 +1,160 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// tempSharePageSize is the number of published resources fetched per
+// request by TempShares.Expire.
+const tempSharePageSize = 100
+
+// TempShares publishes resources for a limited time. The API has no
+// expiring public links, so links are closed by TempShares itself: by
+// timers while the process runs and by Expire, which closes links
+// expired while no process was running. Expiry times are kept in
+// StateStore (see SyncRecord.Expires), so they survive restarts.
+// Resources are unpublished when links expire even if they had been
+// published before they were shared.
+type TempShares struct {
+	y     *ydfs
+	state StateStore
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer // by full path
+	closed bool
+}
+
+// NewTempShares returns TempShares of fsys keeping expiry times in
+// state. Fsys must be created by New. Call Expire after creation to
+// close links expired since the last run and to schedule the rest.
+func NewTempShares(fsys FS, state StateStore) (*TempShares, error) {
+	y, ok := fsys.(*ydfs)
+	if !ok {
+		return nil, &fs.PathError{Op: "share", Path: "/", Err: fs.ErrInvalid}
+	}
+	return &TempShares{y: y, state: state, timers: make(map[string]*time.Timer)}, nil
+}
+
+// Share publishes the named resource and returns its public URL, which
+// is closed after ttl. Sharing of already shared resource extends its
+// link to ttl from now.
+func (t *TempShares) Share(ctx context.Context, name string, ttl time.Duration) (string, error) {
+	if ttl <= 0 {
+		return "", &fs.PathError{Op: "share", Path: name, Err: fs.ErrInvalid}
+	}
+	ctx, cancel := t.y.bind(ctx)
+	defer cancel()
+	fullname := t.y.fullPath(name)
+	expires := time.Now().Add(ttl)
+	// recorded first, so that the link is closed by Expire even if
+	// the process stops right after publishing
+	if err := t.state.Set(fullname, SyncRecord{Expires: &expires}); err != nil {
+		return "", &fs.PathError{Op: "share", Path: name, Err: err}
+	}
+	err := t.y.client.publish(ctx, fullname)
+	t.y.opts.auditRecord(AuditPublish, fullname, 0, err)
+	if err != nil {
+		return "", &fs.PathError{Op: "publish", Path: name, Err: err}
+	}
+	res, err := t.y.client.getResource(ctx, fullname, 0, "public_url")
+	if err != nil {
+		return "", &fs.PathError{Op: "publish", Path: name, Err: err}
+	}
+	t.schedule(fullname, expires)
+	return res.PublicURL, nil
+}
+
+// Expire unpublishes resources whose links have expired and schedules
+// closing of links of other resources shared by TempShares. Failures
+// are returned as PathErrors; links which failed to close are retried
+// by the next call.
+func (t *TempShares) Expire(ctx context.Context) error {
+	ctx, cancel := t.y.bind(ctx)
+	defer cancel()
+	var published []string
+	err := t.y.client.listPublic(ctx, tempSharePageSize, []string{"path"}, func(res Resource) bool {
+		t.y.client.normalizePath(&res)
+		published = append(published, res.Path)
+		return true
+	})
+	if err != nil {
+		return &fs.PathError{Op: "share", Path: "/", Err: err}
+	}
+	errs := make(PathErrors)
+	now := time.Now()
+	for _, fullname := range published {
+		rec, ok, err := t.state.Get(fullname)
+		switch {
+		case err != nil:
+			errs.add(fullname, err)
+		case !ok || rec.Expires == nil:
+			// published permanently
+		case rec.Expires.After(now):
+			t.schedule(fullname, *rec.Expires)
+		default:
+			if err := t.unpublish(ctx, fullname); err != nil {
+				errs.add(fullname, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Close stops timers of TempShares. Links stay recorded and are closed
+// by Expire of the next TempShares using the same StateStore.
+func (t *TempShares) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	for fullname, timer := range t.timers {
+		timer.Stop()
+		delete(t.timers, fullname)
+	}
+}
+
+// schedule sets the timer closing the link of fullname at expires.
+func (t *TempShares) schedule(fullname string, expires time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	if timer, ok := t.timers[fullname]; ok {
+		timer.Stop()
+	}
+	t.timers[fullname] = time.AfterFunc(time.Until(expires), func() {
+		t.mu.Lock()
+		closed := t.closed
+		delete(t.timers, fullname)
+		t.mu.Unlock()
+		if !closed {
+			// failures are left for Expire to retry
+			_ = t.unpublish(t.y.context(), fullname)
+		}
+	})
+}
+
+// unpublish closes the link of fullname unless it was extended and
+// forgets its expiry.
+func (t *TempShares) unpublish(ctx context.Context, fullname string) error {
+	rec, ok, err := t.state.Get(fullname)
+	if err != nil {
+		return err
+	}
+	if ok && rec.Expires != nil && rec.Expires.After(time.Now()) {
+		return nil
+	}
+	err = t.y.client.unpublish(ctx, fullname)
+	t.y.opts.auditRecord(AuditUnpublish, fullname, 0, err)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return t.state.Delete(fullname)
+}
@@ -0,0 +1,76 @@
+package ydfs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTempShares(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	md.put("/docs/b.txt", []byte("b"))
+	md.put("/docs/permanent.txt", []byte("p"))
+	ctx := context.Background()
+	state, _ := OpenFileStateStore("")
+	published := func(name string) bool {
+		t.Helper()
+		res, err := fsys.StatExtended(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.PublicKey != ""
+	}
+
+	shares, err := NewTempShares(fsys, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := shares.Share(ctx, "/docs/a.txt", 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u == "" || !published("/docs/a.txt") {
+		t.Fatalf("resource is not published, URL %q", u)
+	}
+	if _, err := shares.Share(ctx, "/docs/b.txt", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Publish("/docs/permanent.txt"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for published("/docs/a.txt") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if published("/docs/a.txt") {
+		t.Error("link is not closed after TTL")
+	}
+	if _, ok, _ := state.Get("/docs/a.txt"); ok {
+		t.Error("expiry of closed link is still recorded")
+	}
+	shares.Close()
+
+	// the link of b.txt expires while no process is running
+	expired := time.Now().Add(-time.Minute)
+	if err := state.Set("/docs/b.txt", SyncRecord{Expires: &expired}); err != nil {
+		t.Fatal(err)
+	}
+	shares, err = NewTempShares(fsys, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shares.Close()
+	if err := shares.Expire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if published("/docs/b.txt") {
+		t.Error("expired link is not closed by Expire")
+	}
+	if !published("/docs/permanent.txt") {
+		t.Error("permanent link is closed by Expire")
+	}
+	if _, err := shares.Share(ctx, "/docs/a.txt", 0); err == nil {
+		t.Error("Share succeeds with zero TTL")
+	}
+}
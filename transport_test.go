@@ -0,0 +1,134 @@
+package ydfs
+
+import (
+	"crypto/tls"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestWithTransport(t *testing.T) {
+	md := newMockDisk()
+	md.put("/a.txt", []byte("a"))
+	srv := httptest.NewServer(md)
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+	fsys, err := New("mocktoken", nil, WithTransport(&rewriteTransport{target: target}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := fsys.ReadFile("/a.txt"); err != nil || string(data) != "a" {
+		t.Errorf("ReadFile returned %q, %v", data, err)
+	}
+	if c := fsys.(*ydfs).client; c.metadataTimeout != defaultMetadataTimeout {
+		t.Errorf("default client has metadata timeout %v", c.metadataTimeout)
+	}
+	fsys, err = New("mocktoken", nil, WithTransport(&rewriteTransport{target: target}), WithMetadataTimeout(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := fsys.(*ydfs).client; c.metadataTimeout != 0 {
+		t.Errorf("metadata timeout turned off is %v", c.metadataTimeout)
+	}
+}
+
+func TestDefaultTransport(t *testing.T) {
+	o := newOptions()
+	c, err := o.httpClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Transport == nil || c.Timeout != 0 {
+		t.Errorf("default client is not tuned: %+v", c)
+	}
+	tr := newDefaultTransport()
+	if !tr.ForceAttemptHTTP2 || tr.MaxIdleConnsPerHost < 8 {
+		t.Errorf("default transport is not tuned for parallel transfers: %+v", tr)
+	}
+}
+
+func TestProxyAndTLS(t *testing.T) {
+	conf := &tls.Config{ServerName: "example.com"}
+	o := newOptions(WithProxy("http://proxy.local:3128"), WithTLSConfig(conf))
+	given := &http.Client{}
+	c, err := o.httpClient(given)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if given.Transport != nil {
+		t.Error("client provided by the caller is modified")
+	}
+	tr := c.Transport.(*http.Transport)
+	proxy, err := tr.Proxy(httptest.NewRequest(http.MethodGet, urlBase, nil))
+	if err != nil || proxy.String() != "http://proxy.local:3128" {
+		t.Errorf("proxy is not set: %v, %v", proxy, err)
+	}
+	if tr.TLSClientConfig.ServerName != "example.com" {
+		t.Error("TLS config is not set")
+	}
+
+	o = newOptions(WithProxy("::not a url"))
+	if _, err := o.httpClient(nil); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("invalid proxy URL: want fs.ErrInvalid, have %v", err)
+	}
+	o = newOptions(WithProxy("http://proxy.local"), WithTransport(&rewriteTransport{}))
+	if _, err := o.httpClient(nil); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("proxy with custom round tripper: want fs.ErrInvalid, have %v", err)
+	}
+}
+
+func TestWithMiddleware(t *testing.T) {
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				if r.Header.Get("Authorization") == "" {
+					t.Errorf("middleware %s sees request without authorization", name)
+				}
+				r.Header.Set("X-Signature", name)
+				return next.RoundTrip(r)
+			})
+		}
+	}
+	failing := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Method == http.MethodDelete {
+				return nil, errors.New("injected")
+			}
+			return next.RoundTrip(r)
+		})
+	}
+	md := newMockDisk()
+	md.put("/a.txt", []byte("a"))
+	var signature string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Signature")
+		md.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+	fsys, err := New("mocktoken", &http.Client{Transport: &rewriteTransport{target: target}}, WithMiddleware(tag("outer"), tag("inner")), WithMiddleware(failing))
+	if err != nil {
+		t.Fatal(err)
+	}
+	order = nil
+	if data, err := fsys.ReadFile("/a.txt"); err != nil || string(data) != "a" {
+		t.Fatalf("ReadFile returned %q, %v", data, err)
+	}
+	if len(order) < 4 || order[0] != "outer" || order[1] != "inner" {
+		t.Errorf("unexpected order of middleware: %q", order)
+	}
+	if signature != "inner" {
+		t.Errorf("request is not changed by middleware: %q", signature)
+	}
+	if err := fsys.Remove("/a.txt"); !errors.Is(err, ErrNetwork) {
+		t.Errorf("want injected ErrNetwork, have %v", err)
+	}
+	if _, ok := md.get("/a.txt"); !ok {
+		t.Error("request failed by middleware reached the server")
+	}
+}
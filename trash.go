@@ -0,0 +1,108 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// trashSource provides trashed resources to roFS.
+type trashSource struct {
+	client *apiclient // api client
+}
+
+// resource implements roSource
+func (t *trashSource) resource(ctx context.Context, name string, limit, offset int) (Resource, error) {
+	res, err := t.client.getTrashResource(ctx, name, limit, offset)
+	if err != nil {
+		return Resource{}, err
+	}
+	normalizeTrashed(&res)
+	for i := range res.Embedded.Items {
+		normalizeTrashed(&res.Embedded.Items[i])
+	}
+	return res, nil
+}
+
+// readFile implements roSource. The API provides no way to download
+// trashed files.
+func (t *trashSource) readFile(ctx context.Context, name string) ([]byte, error) {
+	return nil, fmt.Errorf("%w: contents of trashed files can not be read", errors.ErrUnsupported)
+}
+
+// normalizeTrashed strips schemes from path and origin path of
+// trashed resource.
+func normalizeTrashed(r *Resource) {
+	normalizeResourcePath(r)
+	origin := Resource{Path: r.OriginPath}
+	normalizeResourcePath(&origin)
+	r.OriginPath = origin.Path
+}
+
+// TrashFS implements FS
+func (y *ydfs) TrashFS() (fs.FS, error) {
+	src := &trashSource{client: y.client}
+	if _, err := src.resource(y.context(), "/", 0, 0); err != nil {
+		return nil, &fs.PathError{Op: "trash", Path: "/", Err: err}
+	}
+	return &roFS{src: src, opts: y.opts}, nil
+}
+
+// RestoreAs implements FS
+func (y *ydfs) RestoreAs(trashPath, newName string, overwrite bool) error {
+	ctx := y.context()
+	name := roPath(trashPath)
+	if strings.Contains(newName, "/") {
+		return &fs.PathError{Op: "restore", Path: trashPath, Err: fmt.Errorf("%w: name %q contains slash", fs.ErrInvalid, newName)}
+	}
+	res, err := (&trashSource{client: y.client}).resource(ctx, name, 0, 0)
+	if err != nil {
+		return &fs.PathError{Op: "restore", Path: trashPath, Err: err}
+	}
+	dest := res.OriginPath
+	if newName != "" {
+		dest = path.Join(path.Dir(dest), newName)
+	}
+	err = y.client.restoreTrash(ctx, name, newName, dest, overwrite)
+	y.opts.auditRecord(AuditRestore, dest, 0, err)
+	if err != nil {
+		return &fs.PathError{Op: "restore", Path: trashPath, Err: err}
+	}
+	return nil
+}
+
+// PurgeTrash implements FS
+func (y *ydfs) PurgeTrash(olderThan time.Duration) (int, error) {
+	ctx := y.context()
+	src := &trashSource{client: y.client}
+	cutoff := time.Now().Add(-olderThan)
+	var trashed []Resource
+	for offset := 0; ; {
+		res, err := src.resource(ctx, "/", roPageSize, offset)
+		if err != nil {
+			return 0, &fs.PathError{Op: "purge", Path: "/", Err: err}
+		}
+		for _, item := range res.Embedded.Items {
+			if !item.Deleted.After(cutoff) {
+				trashed = append(trashed, item)
+			}
+		}
+		offset += len(res.Embedded.Items)
+		if len(res.Embedded.Items) == 0 || offset >= res.Embedded.Total {
+			break
+		}
+	}
+	// entries are deleted after listing as deletion shifts offsets
+	for i, item := range trashed {
+		err := y.client.delTrashResource(ctx, item.Path)
+		y.opts.auditRecord(AuditPurge, item.Path, item.Size, err)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return i, &fs.PathError{Op: "purge", Path: item.Path, Err: err}
+		}
+	}
+	return len(trashed), nil
+}
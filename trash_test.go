@@ -0,0 +1,113 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestTrashFS(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	md.put("/docs/sub/b.txt", []byte("b"))
+	c := fsys.(*ydfs).client
+	for _, name := range []string{"/docs/a.txt", "/docs/sub"} {
+		if err := c.delResourceTrash(context.Background(), name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	trash, err := fsys.TrashFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var walked []string
+	err = fs.WalkDir(trash, "/", func(p string, d fs.DirEntry, err error) error {
+		walked = append(walked, p)
+		return err
+	})
+	if err != nil || len(walked) != 4 {
+		t.Errorf("WalkDir visited %v, %v", walked, err)
+	}
+	info, err := fs.Stat(trash, "/sub/b.txt")
+	if err != nil || info.Size() != 1 || info.IsDir() {
+		t.Errorf("unexpected Stat of trashed file: %v %v", info, err)
+	}
+	if _, err := fs.ReadFile(trash, "/a.txt"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("want errors.ErrUnsupported reading trashed file, have %v", err)
+	}
+	if _, err := fs.Stat(trash, "/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for missing trashed file, have %v", err)
+	}
+	src := &trashSource{client: c}
+	res, err := src.resource(context.Background(), "/sub", 0, 0)
+	if err != nil || res.OriginPath != "/docs/sub" || res.Deleted.IsZero() {
+		t.Errorf("unexpected trashed resource: %+v %v", res, err)
+	}
+}
+
+func TestRestoreAs(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	md.put("/docs/sub/b.txt", []byte("b"))
+	c := fsys.(*ydfs).client
+	for _, name := range []string{"/docs/a.txt", "/docs/sub"} {
+		if err := c.delResourceTrash(context.Background(), name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	md.put("/docs/a.txt", []byte("new"))
+	if err := fsys.RestoreAs("/a.txt", "", false); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("want fs.ErrExist restoring over existing file, have %v", err)
+	}
+	if err := fsys.RestoreAs("/a.txt", "old.txt", false); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := fsys.ReadFile("/docs/old.txt"); err != nil || string(data) != "a" {
+		t.Errorf("unexpected contents of restored file: %q %v", data, err)
+	}
+	// directories are restored asynchronously
+	if err := fsys.RestoreAs("/sub", "", false); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := fsys.ReadFile("/docs/sub/b.txt"); err != nil || string(data) != "b" {
+		t.Errorf("unexpected contents of restored directory: %q %v", data, err)
+	}
+	if err := fsys.RestoreAs("/sub", "", false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound restoring missing resource, have %v", err)
+	}
+	if err := fsys.RestoreAs("/x", "a/b", false); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("want fs.ErrInvalid for name with slash, have %v", err)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/old.txt", []byte("a"))
+	md.put("/docs/new.txt", []byte("b"))
+	c := fsys.(*ydfs).client
+	for _, name := range []string{"/docs/old.txt", "/docs/new.txt"} {
+		if err := c.delResourceTrash(context.Background(), name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	md.mu.Lock()
+	md.trash["/old.txt"].modified = time.Now().Add(-48 * time.Hour)
+	md.mu.Unlock()
+
+	n, err := fsys.PurgeTrash(24 * time.Hour)
+	if err != nil || n != 1 {
+		t.Fatalf("unexpected result of purge: %d %v", n, err)
+	}
+	trash, _ := fsys.TrashFS()
+	if _, err := fs.Stat(trash, "/old.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("old entry is not purged: %v", err)
+	}
+	if _, err := fs.Stat(trash, "/new.txt"); err != nil {
+		t.Errorf("new entry is purged: %v", err)
+	}
+	if n, err := fsys.PurgeTrash(0); err != nil || n != 1 {
+		t.Errorf("unexpected result of purging everything: %d %v", n, err)
+	}
+}
@@ -0,0 +1,87 @@
+package ydfs
+
+import (
+	"net/url"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// UnicodeForm is a Unicode normalization form of paths (see
+// WithUnicodeNormalization).
+type UnicodeForm uint8
+
+const (
+	// UnicodeAsIs leaves paths as they are. It is the default.
+	UnicodeAsIs UnicodeForm = iota
+
+	// UnicodeNFC is the composed form used by Windows, Linux and
+	// most web applications.
+	UnicodeNFC
+
+	// UnicodeNFD is the decomposed form, in which macOS file systems
+	// used to store names.
+	UnicodeNFD
+)
+
+// WithUnicodeNormalization makes FS convert paths to form: paths sent
+// to the API, so that files uploaded from different systems get names
+// in the same form, and paths and names of resources returned by FS.
+// Resources stored with names in the other form (e.g. NFD names of
+// files uploaded from macOS by other apps) are still found: requests
+// which fail with ErrNotFound are retried with paths in the other
+// form. Paths mixing both forms are not found that way.
+func WithUnicodeNormalization(form UnicodeForm) Option {
+	return func(o *options) {
+		o.unicodeForm = form
+	}
+}
+
+// normalize returns s in form f.
+func (f UnicodeForm) normalize(s string) string {
+	switch f {
+	case UnicodeNFC:
+		return norm.NFC.String(s)
+	case UnicodeNFD:
+		return norm.NFD.String(s)
+	}
+	return s
+}
+
+// other returns the form names are looked up in if they are not found
+// in form f.
+func (f UnicodeForm) other() UnicodeForm {
+	switch f {
+	case UnicodeNFC:
+		return UnicodeNFD
+	case UnicodeNFD:
+		return UnicodeNFC
+	}
+	return UnicodeAsIs
+}
+
+// pathParams are query parameters of API requests holding paths.
+var pathParams = []string{"path", "from"}
+
+// otherFormURL returns rawURL of request with paths converted to the
+// form other than the one of the client. It returns false if the form
+// is not set or the paths are the same in both forms.
+func (c *apiclient) otherFormURL(rawURL string) (string, bool) {
+	if c.unicodeForm == UnicodeAsIs {
+		return "", false
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	q, changed := u.Query(), false
+	for _, param := range pathParams {
+		if p := q.Get(param); p != "" {
+			if other := c.unicodeForm.other().normalize(p); other != p {
+				q.Set(param, other)
+				changed = true
+			}
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), changed
+}
@@ -0,0 +1,50 @@
+package ydfs
+
+import (
+	"testing"
+)
+
+func TestUnicodeNormalization(t *testing.T) {
+	const (
+		nfc = "/caf\u00e9.txt"
+		nfd = "/cafe\u0301.txt"
+	)
+	fsys, md := newMockFS(t, WithUnicodeNormalization(UnicodeNFC))
+	md.put(nfd, []byte("uploaded from mac"))
+
+	// names stored in NFD are found by NFC paths
+	if info, err := fsys.Stat(nfc); err != nil || info.Name() != nfc[1:] {
+		t.Errorf("Stat returned %v, %v", info, err)
+	}
+	if data, err := fsys.ReadFile(nfc); err != nil || string(data) != "uploaded from mac" {
+		t.Errorf("ReadFile returned %q, %v", data, err)
+	}
+	entries, err := fsys.ReadDir("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != nfc[1:] {
+		t.Errorf("listed names are not normalized: %v", entries)
+	}
+
+	// paths sent to the API are normalized
+	if err := fsys.WriteFile("/new-"+nfd[1:], []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := md.get("/new-" + nfc[1:]); !ok {
+		t.Error("uploaded file has name not in NFC")
+	}
+	if err := fsys.Remove(nfc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := md.get(nfd); ok {
+		t.Error("file stored in NFD is not removed by NFC path")
+	}
+
+	// without the option paths are left as they are
+	fsys, md = newMockFS(t)
+	md.put(nfd, nil)
+	if _, err := fsys.Stat(nfc); err == nil {
+		t.Error("file is found by path in other form without normalization")
+	}
+}
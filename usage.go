@@ -0,0 +1,59 @@
+package ydfs
+
+import (
+	"context"
+	"io/fs"
+	"strings"
+)
+
+// Usage is space used by files under a directory (see UsageReport).
+type Usage struct {
+	Total       int64            // bytes used by all files
+	Files       int              // number of files
+	ByMediaType map[string]int64 // bytes by media type (MediaTypeImage etc.), MediaTypeUnknown if not reported
+	ByFolder    map[string]int64 // bytes by name of top level folder, "" for files directly in the directory
+}
+
+// UsageReport implements FS
+func (y *ydfs) UsageReport(ctx context.Context, root string) (*Usage, error) {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	res, err := y.client.getResourceMinTraffic(ctx, y.fullPath(root))
+	if err != nil {
+		return nil, &fs.PathError{Op: "usage", Path: root, Err: err}
+	}
+	if !res.IsDir() {
+		return nil, &fs.PathError{Op: "usage", Path: root, Err: ErrNotDir}
+	}
+	y.client.normalize(&res)
+	prefix := strings.TrimSuffix(res.Path, "/") + "/"
+	usage := &Usage{ByMediaType: map[string]int64{}, ByFolder: map[string]int64{}}
+	fields := []string{"path", "size", "media_type"}
+	err = y.client.listFiles(ctx, filesPageSize, fields, func(file Resource) bool {
+		y.client.normalize(&file)
+		rel, ok := strings.CutPrefix(file.Path, prefix)
+		if !ok {
+			return ctx.Err() == nil
+		}
+		folder, _, found := strings.Cut(rel, "/")
+		if !found {
+			folder = ""
+		}
+		media := file.MediaType
+		if media == "" {
+			media = MediaTypeUnknown
+		}
+		usage.Total += file.Size
+		usage.Files++
+		usage.ByMediaType[media] += file.Size
+		usage.ByFolder[folder] += file.Size
+		return ctx.Err() == nil
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, &fs.PathError{Op: "usage", Path: root, Err: err}
+	}
+	return usage, nil
+}
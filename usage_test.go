@@ -0,0 +1,41 @@
+package ydfs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestUsageReport(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/home/Photos/a.jpg", make([]byte, 100))
+	md.put("/home/Photos/2020/b.mp4", make([]byte, 1000))
+	md.put("/home/Docs/c.txt", make([]byte, 10))
+	md.put("/home/d.bin", make([]byte, 1))
+	md.put("/other/e.jpg", make([]byte, 5))
+
+	usage, err := fsys.UsageReport(context.Background(), "/home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Usage{
+		Total:       1111,
+		Files:       4,
+		ByMediaType: map[string]int64{MediaTypeImage: 100, MediaTypeVideo: 1000, MediaTypeText: 10, MediaTypeUnknown: 1},
+		ByFolder:    map[string]int64{"Photos": 1100, "Docs": 10, "": 1},
+	}
+	if !reflect.DeepEqual(usage, want) {
+		t.Errorf("got usage %+v, want %+v", usage, want)
+	}
+
+	usage, err = fsys.UsageReport(context.Background(), "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Total != 1116 || usage.ByFolder["home"] != 1111 || usage.ByFolder["other"] != 5 {
+		t.Errorf("got usage of disk %+v", usage)
+	}
+	if _, err := fsys.UsageReport(context.Background(), "/home/d.bin"); err == nil {
+		t.Error("UsageReport of file succeeds")
+	}
+}
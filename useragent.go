@@ -0,0 +1,62 @@
+package ydfs
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// modulePath is the import path of the library, used to find its
+// version in build information.
+const modulePath = "github.com/dmfed/ydfs"
+
+// WithAppName makes FS identify the application in User-Agent header
+// of all requests as Yandex asks API clients to do, e.g. WithAppName
+// ("backup", "1.2.0") sends "backup/1.2.0 ydfs/v1.0.0 Go/1.23.0".
+// Version may be empty. Without the option FS identifies itself only.
+func WithAppName(name, version string) Option {
+	return func(o *options) {
+		o.appName = product(name, version)
+	}
+}
+
+// userAgent returns value of User-Agent header sent by FS.
+func (o *options) userAgent() string {
+	ua := product("ydfs", libraryVersion()) + " " + product("Go", strings.TrimPrefix(runtime.Version(), "go"))
+	if o.appName != "" {
+		ua = o.appName + " " + ua
+	}
+	return ua
+}
+
+// product formats product token of User-Agent header.
+func product(name, version string) string {
+	name = strings.Join(strings.Fields(name), "-")
+	if version = strings.Join(strings.Fields(version), "-"); version == "" {
+		return name
+	}
+	return name + "/" + version
+}
+
+// libraryVersion returns version of the library the binary is built
+// with or "devel" if it is unknown, e.g. in tests or local builds.
+var libraryVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+	mod := &info.Main
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			mod = dep
+		}
+	}
+	if mod.Path != modulePath || mod.Version == "" || mod.Version == "(devel)" {
+		return "devel"
+	}
+	if mod.Replace != nil && mod.Replace.Version != "" {
+		return mod.Replace.Version
+	}
+	return mod.Version
+})
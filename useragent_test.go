@@ -0,0 +1,48 @@
+package ydfs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestUserAgent(t *testing.T) {
+	goProduct := "Go/" + strings.TrimPrefix(runtime.Version(), "go")
+	for _, tc := range []struct {
+		opts []Option
+		want string
+	}{
+		{nil, "ydfs/devel " + goProduct},
+		{[]Option{WithAppName("backup", "1.2.0")}, "backup/1.2.0 ydfs/devel " + goProduct},
+		{[]Option{WithAppName("my app", "")}, "my-app ydfs/devel " + goProduct},
+	} {
+		md := newMockDisk()
+		var seen []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = append(seen, r.Header.Get("User-Agent"))
+			md.ServeHTTP(w, r)
+		}))
+		target, _ := url.Parse(srv.URL)
+		fsys, err := New("mocktoken", &http.Client{Transport: &rewriteTransport{target: target}}, tc.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		md.put("/a.txt", []byte("data"))
+		if _, err := fsys.ReadFile("/a.txt"); err != nil {
+			t.Fatal(err)
+		}
+		srv.Close()
+		if len(seen) < 2 {
+			t.Fatalf("too few requests: %d", len(seen))
+		}
+		for _, ua := range seen {
+			if ua != tc.want {
+				t.Errorf("want User-Agent %q, have %q", tc.want, ua)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package ydfs
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// VerifyReport lists differences between local and remote directory
+// found by VerifyTree. Paths are slash-separated and relative to the
+// compared directories.
+type VerifyReport struct {
+	Missing  []string // local files absent in the remote directory
+	Modified []string // files with different contents
+	Extra    []string // remote files absent in the local directory
+}
+
+// OK reports whether directories have the same files.
+func (r *VerifyReport) OK() bool {
+	return len(r.Missing) == 0 && len(r.Modified) == 0 && len(r.Extra) == 0
+}
+
+// VerifyTree implements FS
+func (y *ydfs) VerifyTree(ctx context.Context, remoteDir, localDir string) (*VerifyReport, error) {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	remote, err := y.remoteTree(ctx, remoteDir)
+	if err != nil {
+		return nil, &fs.PathError{Op: "verify", Path: remoteDir, Err: err}
+	}
+	return y.compareTree(ctx, remote, localDir, nil)
+}
+
+// remoteTree returns files under remoteDir by their slash-separated paths
+// relative to it. Files are found in the flat listing of the disk and
+// have MD5 and extra fields set besides the ones FS requests.
+func (y *ydfs) remoteTree(ctx context.Context, remoteDir string, fields ...string) (map[string]Resource, error) {
+	res, err := y.client.getResourceMinTraffic(ctx, y.fullPath(remoteDir))
+	if err != nil {
+		return nil, err
+	}
+	if !res.IsDir() {
+		return nil, ErrNotDir
+	}
+	y.client.normalize(&res)
+	prefix := strings.TrimSuffix(res.Path, "/") + "/"
+	remote := map[string]Resource{}
+	fields = mergeFields(y.client.fields, append([]string{"md5"}, fields...))
+	err = y.client.listFiles(ctx, filesPageSize, fields, func(file Resource) bool {
+		y.client.normalize(&file)
+		if strings.HasPrefix(file.Path, prefix) {
+			remote[strings.TrimPrefix(file.Path, prefix)] = file
+		}
+		return ctx.Err() == nil
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	return remote, err
+}
+
+// compareTree compares files of localDir passing filter with remote
+// files as returned by remoteTree. Filter may be nil.
+func (y *ydfs) compareTree(ctx context.Context, remote map[string]Resource, localDir string, filter *Filter) (*VerifyReport, error) {
+	report := &VerifyReport{}
+	var toHash []string
+	seen := map[string]bool{}
+	err := filepath.WalkDir(localDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		info, err := d.Info()
+		switch {
+		case err != nil:
+			return err
+		case !filter.Match(rel, info) && d.IsDir():
+			return filepath.SkipDir
+		case d.IsDir() || !filter.Match(rel, info):
+			return nil
+		}
+		file, ok := remote[rel]
+		seen[rel] = true
+		switch {
+		case !ok:
+			report.Missing = append(report.Missing, rel)
+		case info.Size() != file.Size || file.MD5 == "":
+			report.Modified = append(report.Modified, rel)
+		default:
+			toHash = append(toHash, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for rel := range remote {
+		if !seen[rel] {
+			report.Extra = append(report.Extra, rel)
+		}
+	}
+
+	modified, err := y.compareHashes(ctx, localDir, toHash, remote)
+	if err != nil {
+		return nil, err
+	}
+	report.Modified = append(report.Modified, modified...)
+	for _, list := range [][]string{report.Missing, report.Modified, report.Extra} {
+		sort.Strings(list)
+	}
+	return report, nil
+}
+
+// compareHashes computes MD5 of local files in parallel and returns those
+// with sums different from ones of remote files with the same name.
+func (y *ydfs) compareHashes(ctx context.Context, localDir string, names []string, remote map[string]Resource) ([]string, error) {
+	var (
+		mu       sync.Mutex
+		modified []string
+		firstErr error
+		wg       sync.WaitGroup
+		work     = make(chan string)
+	)
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rel := range work {
+				sum, err := md5File(filepath.Join(localDir, filepath.FromSlash(rel)))
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				} else if err == nil && sum != remote[rel].MD5 {
+					modified = append(modified, rel)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, rel := range names {
+		if ctx.Err() != nil {
+			break
+		}
+		work <- rel
+	}
+	close(work)
+	wg.Wait()
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return modified, firstErr
+}
+
+// md5File returns hex encoded MD5 of the named local file.
+func md5File(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
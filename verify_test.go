@@ -0,0 +1,66 @@
+package ydfs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestVerifyTree(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/backup/same.txt", []byte("same"))
+	md.put("/backup/dir/changed.txt", []byte("old"))
+	md.put("/backup/resized.txt", []byte("short"))
+	md.put("/backup/extra.txt", []byte("extra"))
+	md.put("/backupx/outside.txt", []byte("outside"))
+
+	local := t.TempDir()
+	for name, data := range map[string]string{
+		"same.txt":        "same",
+		"dir/changed.txt": "new",
+		"resized.txt":     "longer",
+		"dir/missing.txt": "missing",
+	} {
+		p := filepath.Join(local, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := fsys.VerifyTree(context.Background(), "/backup", local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &VerifyReport{
+		Missing:  []string{"dir/missing.txt"},
+		Modified: []string{"dir/changed.txt", "resized.txt"},
+		Extra:    []string{"extra.txt"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("got report %+v, want %+v", report, want)
+	}
+	if report.OK() {
+		t.Error("report with differences is OK")
+	}
+
+	sub, err := fsys.Sub("/backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err = sub.VerifyTree(context.Background(), "/dir", filepath.Join(local, "dir"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Modified) != 1 || len(report.Missing) != 1 || len(report.Extra) != 0 {
+		t.Errorf("got report %+v for sub FS", report)
+	}
+
+	if _, err := fsys.VerifyTree(context.Background(), "/backup/same.txt", local); err == nil {
+		t.Error("VerifyTree of file succeeds")
+	}
+}
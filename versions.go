@@ -0,0 +1,154 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+)
+
+// versionsDir is the directory within the reserved directory (see
+// WithReservedDir) keeping previous contents of overwritten files.
+// Versions of a file are stored in the directory named after the
+// file's path on the disk, so sub FS share them with the root FS.
+const versionsDir = "versions"
+
+// versionLayout is the layout of version names, which sort by time.
+const versionLayout = "20060102T150405.000000000Z"
+
+// Version describes previous contents of a file kept by WithVersioning.
+type Version struct {
+	ID       string    // identifies version for RestoreVersion
+	Created  time.Time // when the file was overwritten
+	Size     int64     // size of contents
+	Resource Resource  // metadata of the copy on the disk
+}
+
+// WithVersioning keeps up to n previous copies of files overwritten by
+// WriteFile, WriteFileStream and File.Sync. Before a file is overwritten
+// its contents are copied on the server side to the hidden
+// /.ydfs/versions directory (see WithReservedDir), older copies beyond n
+// are removed. See ListVersions and
+// RestoreVersion. Zero or negative n turns versioning off.
+func WithVersioning(n int) Option {
+	return func(o *options) {
+		o.versions = n
+	}
+}
+
+// versionPath returns directory keeping versions of the file with the
+// given full path.
+func (o *options) versionPath(fullname string) string {
+	return path.Join(o.reservedDir, versionsDir, fullname)
+}
+
+// keepVersion copies the file with the given full path to versions
+// before it is overwritten and prunes old versions.
+func (o *options) keepVersion(ctx context.Context, c *apiclient, fullname string) error {
+	if o.versions <= 0 {
+		return nil
+	}
+	if err := o.saveVersion(ctx, c, fullname); err != nil {
+		return err
+	}
+	return o.pruneVersions(ctx, c, fullname)
+}
+
+// saveVersion copies the file with the given full path to versions.
+// Missing file has nothing to keep.
+func (o *options) saveVersion(ctx context.Context, c *apiclient, fullname string) error {
+	res, err := c.getResourceMinTraffic(ctx, fullname)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if res.IsDir() {
+		return nil
+	}
+	root := &ydfs{client: c, opts: o, path: "/"}
+	dir := o.versionPath(fullname)
+	if err := root.MkdirAll(dir); err != nil {
+		return err
+	}
+	err = c.copyResource(ctx, fullname, path.Join(dir, time.Now().UTC().Format(versionLayout)), false)
+	o.auditRecord(AuditVersion, fullname, res.Size, err)
+	return err
+}
+
+// pruneVersions removes the oldest versions of the file with the given
+// full path leaving the configured number of them.
+func (o *options) pruneVersions(ctx context.Context, c *apiclient, fullname string) error {
+	versions, err := o.listVersions(ctx, c, fullname)
+	if err != nil {
+		return err
+	}
+	for len(versions) > o.versions {
+		err := c.delResourcePermanently(ctx, versions[0].Resource.Path)
+		o.auditRecord(AuditRemove, versions[0].Resource.Path, 0, err)
+		if err != nil {
+			return err
+		}
+		versions = versions[1:]
+	}
+	return nil
+}
+
+// listVersions returns versions of the file with the given full path
+// from the oldest to the newest.
+func (o *options) listVersions(ctx context.Context, c *apiclient, fullname string) ([]Version, error) {
+	var versions []Version
+	err := c.listDir(ctx, o.versionPath(fullname), "", dirPageSize, func(res Resource) bool {
+		created, err := time.Parse(versionLayout, res.Name)
+		if err != nil || !res.IsFile() {
+			return true
+		}
+		versions = append(versions, Version{ID: res.Name, Created: created, Size: res.Size, Resource: res})
+		return true
+	})
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].ID < versions[j].ID })
+	return versions, nil
+}
+
+// ListVersions implements FS
+func (y *ydfs) ListVersions(name string) ([]Version, error) {
+	versions, err := y.opts.listVersions(y.context(), y.client, y.fullPath(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "versions", Path: name, Err: err}
+	}
+	return versions, nil
+}
+
+// RestoreVersion implements FS
+func (y *ydfs) RestoreVersion(name, id string) error {
+	ctx := y.context()
+	fullname := y.fullPath(name)
+	if _, err := time.Parse(versionLayout, id); err != nil {
+		return &fs.PathError{Op: "restore", Path: name, Err: fmt.Errorf("%w: invalid version %q", fs.ErrInvalid, id)}
+	}
+	version := path.Join(y.opts.versionPath(fullname), id)
+	if _, err := y.client.getResourceMinTraffic(ctx, version); err != nil {
+		return &fs.PathError{Op: "restore", Path: name, Err: err}
+	}
+	// the restored version is pruned only after it is copied back
+	if y.opts.versions > 0 {
+		if err := y.opts.saveVersion(ctx, y.client, fullname); err != nil {
+			return &fs.PathError{Op: "restore", Path: name, Err: err}
+		}
+	}
+	err := y.client.copyResource(ctx, version, fullname, true)
+	y.opts.auditRecord(AuditRestore, fullname, 0, err)
+	if err == nil && y.opts.versions > 0 {
+		err = y.opts.pruneVersions(ctx, y.client, fullname)
+	}
+	if err != nil {
+		return &fs.PathError{Op: "restore", Path: name, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package ydfs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVersioning(t *testing.T) {
+	fsys, md := newMockFS(t, WithVersioning(2))
+	md.put("/docs/a.txt", []byte("v1"))
+	sub, err := fsys.Sub("/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.WriteFile("/a.txt", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.WriteFileStream("/a.txt", strings.NewReader("v3")); err != nil {
+		t.Fatal(err)
+	}
+	f, err := sub.Create("/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("v4"))
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := sub.ListVersions("/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("got %d versions, want 2", len(versions))
+	}
+	for i, want := range []string{"v2", "v3"} {
+		if e, ok := md.get(versions[i].Resource.Path); !ok || string(e.data) != want {
+			t.Errorf("version %d is not %q", i, want)
+		}
+	}
+	if !versions[0].Created.Before(versions[1].Created) {
+		t.Error("versions are not sorted by time")
+	}
+	if root, err := fsys.ListVersions("/docs/a.txt"); err != nil || len(root) != 2 {
+		t.Errorf("root FS lists %d versions, %v", len(root), err)
+	}
+
+	if err := sub.RestoreVersion("/a.txt", versions[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := md.get("/docs/a.txt"); string(e.data) != "v2" {
+		t.Errorf("restored contents are %q, want v2", e.data)
+	}
+	versions, _ = sub.ListVersions("/a.txt")
+	if len(versions) != 2 {
+		t.Fatalf("got %d versions after restore, want 2", len(versions))
+	}
+	if e, _ := md.get(versions[1].Resource.Path); string(e.data) != "v4" {
+		t.Error("contents replaced by restore are not kept")
+	}
+	if err := sub.RestoreVersion("/a.txt", "bogus"); err == nil {
+		t.Error("restore of invalid version succeeds")
+	}
+
+	// new files have no versions
+	if err := sub.WriteFile("/b.txt", []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if versions, err := sub.ListVersions("/b.txt"); err != nil || len(versions) != 0 {
+		t.Errorf("new file has versions %v, %v", versions, err)
+	}
+}
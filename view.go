@@ -0,0 +1,134 @@
+package ydfs
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Names of views of FS (see FS.View).
+const (
+	ViewRecent    = "recent"    // the most recently uploaded files
+	ViewPhotos    = "photos"    // images and videos
+	ViewVideos    = "videos"    // videos
+	ViewAudio     = "audio"     // audio files
+	ViewDocuments = "documents" // documents, spreadsheets and books
+)
+
+// viewMediaTypes are media types of files shown by views other than
+// ViewRecent.
+var viewMediaTypes = map[string][]string{
+	ViewPhotos:    {MediaTypeImage, MediaTypeVideo},
+	ViewVideos:    {MediaTypeVideo},
+	ViewAudio:     {MediaTypeAudio},
+	ViewDocuments: {MediaTypeDocument, MediaTypeSpreadsheet, MediaTypeBook},
+}
+
+// viewRecentLimit is the number of files shown by ViewRecent.
+const viewRecentLimit = 1000
+
+// viewSource provides files of a view to roFS. Files are listed when
+// the view is created and shown at their paths, directories are made
+// up of paths of the files.
+type viewSource struct {
+	y     *ydfs
+	files map[string]Resource   // by path within the view
+	dirs  map[string][]Resource // entries of directories sorted by name
+}
+
+// View implements FS
+func (y *ydfs) View(ctx context.Context, name string) (fs.FS, error) {
+	ctx, cancel := y.bind(ctx)
+	defer cancel()
+	var (
+		files []Resource
+		err   error
+	)
+	if name == ViewRecent {
+		files, err = y.client.listLastUploaded(ctx, viewRecentLimit, nil, y.client.fields)
+	} else if types, ok := viewMediaTypes[name]; ok {
+		query := url.Values{"media_type": {strings.Join(types, ",")}}
+		err = y.client.listItemsQuery(ctx, urlResourcesFiles, query, filesPageSize, "", y.client.fields, func(file Resource) bool {
+			files = append(files, file)
+			return ctx.Err() == nil
+		})
+	} else {
+		return nil, &fs.PathError{Op: "view", Path: name, Err: fmt.Errorf("%w: unknown view", fs.ErrInvalid)}
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, &fs.PathError{Op: "view", Path: name, Err: err}
+	}
+	return &roFS{src: y.newViewSource(files), opts: y.opts}, nil
+}
+
+// newViewSource returns viewSource of files within FS.
+func (y *ydfs) newViewSource(files []Resource) *viewSource {
+	v := &viewSource{y: y, files: make(map[string]Resource), dirs: map[string][]Resource{"/": nil}}
+	prefix := strings.TrimSuffix(y.fullPath("/"), "/") + "/"
+	for _, file := range files {
+		y.client.normalizePath(&file)
+		if !strings.HasPrefix(file.Path, prefix) || y.client.unlisted(file.Path, true) {
+			continue
+		}
+		file.Path = "/" + strings.TrimPrefix(file.Path, prefix)
+		if _, ok := v.files[file.Path]; ok {
+			continue
+		}
+		v.files[file.Path] = file
+		entry := file
+		for p := file.Path; p != "/"; p = path.Dir(p) {
+			dir := path.Dir(p)
+			_, known := v.dirs[dir]
+			v.dirs[dir] = append(v.dirs[dir], entry)
+			if known {
+				break
+			}
+			entry = Resource{Name: path.Base(dir), Path: dir, Type: TypeDir}
+		}
+	}
+	for _, entries := range v.dirs {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Name < entries[j].Name
+		})
+	}
+	return v
+}
+
+// resource implements roSource
+func (v *viewSource) resource(ctx context.Context, name string, limit, offset int) (Resource, error) {
+	if file, ok := v.files[name]; ok {
+		return file, nil
+	}
+	entries, ok := v.dirs[name]
+	if !ok {
+		return Resource{}, ErrNotFound
+	}
+	res := Resource{Name: path.Base(name), Path: name, Type: TypeDir}
+	res.Embedded = ResourceList{Path: name, Limit: limit, Offset: offset, Total: len(entries)}
+	if offset < len(entries) {
+		res.Embedded.Items = entries[offset:min(offset+limit, len(entries))]
+	}
+	return res, nil
+}
+
+// readFile implements roSource
+func (v *viewSource) readFile(ctx context.Context, name string) ([]byte, error) {
+	if _, ok := v.files[name]; !ok {
+		if _, ok := v.dirs[name]; ok {
+			return nil, ErrIsDir
+		}
+		return nil, ErrNotFound
+	}
+	fullname := v.y.fullPath(name)
+	if err := v.y.checkAntivirusByName(ctx, fullname); err != nil {
+		return nil, err
+	}
+	return v.y.client.getFile(ctx, fullname)
+}
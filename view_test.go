@@ -0,0 +1,83 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+	"time"
+)
+
+// viewFiles returns paths of files of view fsys.
+func viewFiles(t *testing.T, fsys fs.FS) []string {
+	t.Helper()
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, p)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestView(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/camera/2024/a.jpg", []byte("a"))
+	md.put("/camera/2024/clip.mp4", []byte("clip"))
+	md.put("/music/song.mp3", []byte("song"))
+	md.put("/docs/notes.txt", []byte("notes"))
+	md.put("/docs/old.txt", []byte("old"))
+	md.update("/docs/old.txt", func(e *mockEntry) { e.modified = time.Now().Add(-time.Hour) })
+
+	photos, err := fsys.View(context.Background(), ViewPhotos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files, want := viewFiles(t, photos), []string{"camera/2024/a.jpg", "camera/2024/clip.mp4"}; !slices.Equal(files, want) {
+		t.Errorf("view has files %q, want %q", files, want)
+	}
+	if data, err := fs.ReadFile(photos, "camera/2024/clip.mp4"); err != nil || string(data) != "clip" {
+		t.Errorf("ReadFile returned %q, %v", data, err)
+	}
+	if _, err := fs.Stat(photos, "music/song.mp3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for file outside of view, have %v", err)
+	}
+
+	audio, err := fsys.View(context.Background(), ViewAudio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := fs.ReadDir(audio, "."); err != nil || len(entries) != 1 || entries[0].Name() != "music" || !entries[0].IsDir() {
+		t.Errorf("ReadDir returned %v, %v", entries, err)
+	}
+
+	recent, err := fsys.View(context.Background(), ViewRecent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md.put("/later.txt", []byte("later"))
+	if files, want := viewFiles(t, recent), []string{"camera/2024/a.jpg", "camera/2024/clip.mp4", "docs/notes.txt", "docs/old.txt", "music/song.mp3"}; !slices.Equal(files, want) {
+		t.Errorf("view has files %q, want %q", files, want)
+	}
+
+	sub, err := fsys.Sub("/camera")
+	if err != nil {
+		t.Fatal(err)
+	}
+	photos, err = sub.View(context.Background(), ViewPhotos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files, want := viewFiles(t, photos), []string{"2024/a.jpg", "2024/clip.mp4"}; !slices.Equal(files, want) {
+		t.Errorf("view has files %q, want %q", files, want)
+	}
+
+	if _, err := fsys.View(context.Background(), "nonexistent"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("want fs.ErrInvalid for unknown view, have %v", err)
+	}
+}
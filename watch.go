@@ -0,0 +1,285 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EventType is a kind of change reported by Watch. Types are bits,
+// so that sets of them can be passed to WithEventTypes.
+type EventType uint8
+
+const (
+	EventCreate EventType = 1 << iota // file appeared
+	EventModify                       // contents or metadata of file changed
+	EventDelete                       // file disappeared
+)
+
+func (t EventType) String() string {
+	var names []string
+	for _, e := range []struct {
+		t    EventType
+		name string
+	}{{EventCreate, "create"}, {EventModify, "modify"}, {EventDelete, "delete"}} {
+		if t&e.t != 0 {
+			names = append(names, e.name)
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("EventType(%d)", uint8(t))
+	}
+	return strings.Join(names, "|")
+}
+
+// Event is a change of a file seen by Watch. Old and New hold metadata
+// of the file before and after the change, so that consumers can act
+// without extra Stat calls.
+type Event struct {
+	Type EventType
+	Path string    // slash-separated path relative to the watched directory
+	Old  *Resource // nil for EventCreate
+	New  *Resource // nil for EventDelete
+}
+
+func (e Event) String() string {
+	return e.Type.String() + " " + e.Path
+}
+
+// defaultWatchInterval is the delay between polls of Watch unless
+// WithPollInterval is given.
+const defaultWatchInterval = time.Minute
+
+// watchOptions holds configuration of Watch.
+type watchOptions struct {
+	types       EventType
+	prefix      string
+	globs       []string
+	interval    time.Duration // delay between polls, the shortest one if adaptive
+	maxInterval time.Duration // the longest delay if adaptive, zero if not
+	errs        func(error)
+}
+
+// WatchOption configures Watch.
+type WatchOption func(*watchOptions)
+
+// WithEventTypes makes Watch deliver events of the given types only,
+// e.g. WithEventTypes(EventCreate|EventDelete).
+func WithEventTypes(types EventType) WatchOption {
+	return func(o *watchOptions) {
+		o.types = types
+	}
+}
+
+// WithPathPrefix makes Watch deliver events of files whose paths
+// relative to the watched directory start with prefix only.
+func WithPathPrefix(prefix string) WatchOption {
+	return func(o *watchOptions) {
+		o.prefix = strings.TrimPrefix(prefix, "/")
+	}
+}
+
+// WithGlob makes Watch deliver events of files whose paths relative
+// to the watched directory or base names match pattern (see path.Match)
+// only. Events matching any of patterns given with several WithGlob
+// options are delivered.
+func WithGlob(pattern string) WatchOption {
+	return func(o *watchOptions) {
+		o.globs = append(o.globs, pattern)
+	}
+}
+
+// WithPollInterval sets delay between polls of Watch (one minute by
+// default). Polls throttled by the API are retried with doubled delay
+// or after the delay the API asks for.
+func WithPollInterval(d time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.interval = d
+	}
+}
+
+// WithAdaptiveInterval makes Watch adapt delay between polls to the
+// observed frequency of changes: the delay is halved down to min after
+// polls which found changes and doubled up to max after polls which
+// found none. This balances freshness against quota usage when many
+// directories are watched.
+func WithAdaptiveInterval(min, max time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.interval, o.maxInterval = min, max
+	}
+}
+
+// WithWatchErrors sets function called with errors of failed polls.
+// Failed polls are retried after the interval.
+func WithWatchErrors(fn func(error)) WatchOption {
+	return func(o *watchOptions) {
+		o.errs = fn
+	}
+}
+
+// nextDelay returns delay before the next poll after the poll made
+// delay after the previous one. Changed tells whether the poll found
+// changes and err is its error. Polls throttled by the API are delayed
+// at least as long as the API asks.
+func (o *watchOptions) nextDelay(delay time.Duration, changed bool, err error) time.Duration {
+	longest := o.maxInterval
+	if longest == 0 {
+		longest = defaultBackoffFactor * o.interval
+	}
+	switch {
+	case errors.Is(err, ErrTooManyRequests):
+		return max(min(2*delay, longest), retryAfter(err))
+	case o.maxInterval == 0:
+		return o.interval
+	case err != nil:
+		return delay
+	case changed:
+		return max(delay/2, o.interval)
+	}
+	return min(2*delay, longest)
+}
+
+// match reports whether the event passes filters of options.
+func (o *watchOptions) match(e Event) bool {
+	if e.Type&o.types == 0 || !strings.HasPrefix(e.Path, o.prefix) {
+		return false
+	}
+	if len(o.globs) == 0 {
+		return true
+	}
+	for _, g := range o.globs {
+		if ok, _ := path.Match(g, e.Path); ok {
+			return true
+		}
+		if ok, _ := path.Match(g, path.Base(e.Path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch implements FS
+func (y *ydfs) Watch(ctx context.Context, root string, opts ...WatchOption) (<-chan Event, error) {
+	o := &watchOptions{types: EventCreate | EventModify | EventDelete, interval: defaultWatchInterval}
+	for _, opt := range opts {
+		opt(o)
+	}
+	for _, g := range o.globs {
+		if _, err := path.Match(g, ""); err != nil {
+			return nil, &fs.PathError{Op: "watch", Path: root, Err: fmt.Errorf("%w: pattern %q: %v", fs.ErrInvalid, g, err)}
+		}
+	}
+	if o.interval <= 0 || o.maxInterval != 0 && o.maxInterval < o.interval {
+		return nil, &fs.PathError{Op: "watch", Path: root, Err: fs.ErrInvalid}
+	}
+	ctx, cancel := y.bind(ctx)
+	revision, _ := y.client.diskRevision(ctx)
+	tree, err := y.remoteTree(ctx, root, syncFields...)
+	if err != nil {
+		cancel()
+		return nil, &fs.PathError{Op: "watch", Path: root, Err: err}
+	}
+	w := &watcher{y: y, root: root, opts: o, revision: revision, tree: tree, ch: make(chan Event)}
+	go func() {
+		defer cancel()
+		defer close(w.ch)
+		timer := time.NewTimer(o.interval)
+		defer timer.Stop()
+		delay := o.interval
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			changed, err := w.poll(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil && o.errs != nil {
+				o.errs(&fs.PathError{Op: "watch", Path: root, Err: err})
+			}
+			delay = o.nextDelay(delay, changed, err)
+			timer.Reset(delay)
+		}
+	}()
+	return w.ch, nil
+}
+
+// watcher is state of Watch between polls.
+type watcher struct {
+	y        *ydfs
+	root     string
+	opts     *watchOptions
+	revision int64               // revision of the disk at the last poll
+	tree     map[string]Resource // files found by the last poll
+	ch       chan Event
+}
+
+// poll lists the tree of root unless revision of the disk is the same
+// as the last seen one and sends events of changes since the previous
+// poll. It reports whether there were any changes, even if all were
+// filtered out.
+func (w *watcher) poll(ctx context.Context) (bool, error) {
+	// listing the tree is expensive, the revision of the disk tells
+	// whether there is anything to list
+	current, err := w.y.client.diskRevision(ctx)
+	if errors.Is(err, ErrTooManyRequests) {
+		return false, err
+	}
+	if err == nil && current == w.revision && current != 0 {
+		return false, nil
+	}
+	next, err := w.y.remoteTree(ctx, w.root, syncFields...)
+	if err != nil {
+		return false, err
+	}
+	events := diffTrees(w.tree, next)
+	w.revision, w.tree = current, next
+	for _, e := range events {
+		if !w.opts.match(e) {
+			continue
+		}
+		select {
+		case w.ch <- e:
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+	return len(events) > 0, nil
+}
+
+// diffTrees returns events turning files of old into files of next
+// sorted by path. Trees are as returned by remoteTree.
+func diffTrees(old, next map[string]Resource) []Event {
+	var events []Event
+	for p, res := range next {
+		prev, ok := old[p]
+		switch {
+		case !ok:
+			events = append(events, Event{Type: EventCreate, Path: p, New: &res})
+		case fileChanged(prev, res):
+			events = append(events, Event{Type: EventModify, Path: p, Old: &prev, New: &res})
+		}
+	}
+	for p, res := range old {
+		if _, ok := next[p]; !ok {
+			events = append(events, Event{Type: EventDelete, Path: p, Old: &res})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Path < events[j].Path
+	})
+	return events
+}
+
+// fileChanged reports whether metadata of a file differs between two
+// listings.
+func fileChanged(a, b Resource) bool {
+	return a.MD5 != b.MD5 || a.Size != b.Size || a.Revision != b.Revision || !a.Modified.Equal(b.Modified)
+}
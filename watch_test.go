@@ -0,0 +1,158 @@
+package ydfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	md.put("/docs/b.txt", []byte("b"))
+	md.put("/docs/img/c.jpg", []byte("c"))
+	md.put("/other/d.txt", []byte("d"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := fsys.Watch(ctx, "/docs", WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md.put("/docs/a.txt", []byte("changed"))
+	md.put("/docs/new.txt", []byte("new"))
+	md.put("/other/e.txt", []byte("e"))
+	md.mu.Lock()
+	delete(md.entries, "/docs/b.txt")
+	md.mu.Unlock()
+
+	want := []string{"modify a.txt", "delete b.txt", "create new.txt"}
+	for _, w := range want {
+		select {
+		case e := <-events:
+			if e.String() != w {
+				t.Errorf("want event %q, have %q", w, e)
+			}
+			if e.Path == "a.txt" && (e.Old.Size != 1 || e.New.Size != 7) {
+				t.Errorf("unexpected metadata of modified file: %+v -> %+v", e.Old, e.New)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no event %q", w)
+		}
+	}
+	cancel()
+	for range events {
+	}
+}
+
+func TestWatchFilters(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	md.put("/docs/img/x.jpg", []byte("x"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := fsys.Watch(ctx, "/docs",
+		WithPollInterval(10*time.Millisecond),
+		WithEventTypes(EventCreate|EventDelete),
+		WithPathPrefix("img/"),
+		WithGlob("*.jpg"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md.put("/docs/img/x.jpg", []byte("modified"))
+	md.put("/docs/img/y.png", []byte("y"))
+	md.put("/docs/b.jpg", []byte("b"))
+	md.put("/docs/img/z.jpg", []byte("z"))
+	select {
+	case e := <-events:
+		if e.String() != "create img/z.jpg" || e.New == nil || e.Old != nil {
+			t.Errorf("unexpected event %v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event")
+	}
+
+	if _, err := fsys.Watch(ctx, "/docs", WithGlob("[")); err == nil {
+		t.Error("Watch succeeds with malformed pattern")
+	}
+	if _, err := fsys.Watch(ctx, "/docs/a.txt"); err == nil {
+		t.Error("Watch succeeds on a file")
+	}
+}
+
+func TestEventTypeString(t *testing.T) {
+	if s := (EventCreate | EventDelete).String(); s != "create|delete" {
+		t.Errorf("unexpected string %q", s)
+	}
+}
+
+func TestWatchNextDelay(t *testing.T) {
+	const s = time.Second
+	throttled := fmt.Errorf("%w", &RequestError{Err: ErrTooManyRequests, RetryAfter: 90 * s})
+	adaptive := &watchOptions{interval: s, maxInterval: 8 * s}
+	fixed := &watchOptions{interval: s}
+	for _, c := range []struct {
+		o       *watchOptions
+		delay   time.Duration
+		changed bool
+		err     error
+		want    time.Duration
+	}{
+		{adaptive, 4 * s, false, nil, 8 * s},
+		{adaptive, 8 * s, false, nil, 8 * s},
+		{adaptive, 4 * s, true, nil, 2 * s},
+		{adaptive, s, true, nil, s},
+		{adaptive, 4 * s, false, ErrNetwork, 4 * s},
+		{adaptive, 2 * s, false, ErrTooManyRequests, 4 * s},
+		{adaptive, 2 * s, false, throttled, 90 * s},
+		{fixed, s, false, nil, s},
+		{fixed, 8 * s, false, ErrTooManyRequests, 16 * s},
+		{fixed, 16 * s, false, ErrTooManyRequests, 16 * s},
+		{fixed, 16 * s, false, nil, s},
+	} {
+		if have := c.o.nextDelay(c.delay, c.changed, c.err); have != c.want {
+			t.Errorf("delay %v, changed %v, error %v: want %v, have %v", c.delay, c.changed, c.err, c.want, have)
+		}
+	}
+}
+
+func TestWatchAdaptive(t *testing.T) {
+	fsys, md := newMockFS(t)
+	md.put("/docs/a.txt", []byte("a"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var errs []error
+	events, err := fsys.Watch(ctx, "/docs",
+		WithAdaptiveInterval(5*time.Millisecond, 20*time.Millisecond),
+		WithWatchErrors(func(err error) { errs = append(errs, err) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// throttled polls are retried
+	md.failNext(http.StatusTooManyRequests, "TooManyRequestsError")
+	time.Sleep(10 * time.Millisecond)
+	md.put("/docs/b.txt", []byte("b"))
+	select {
+	case e := <-events:
+		if e.String() != "create b.txt" {
+			t.Errorf("unexpected event %v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event")
+	}
+	cancel()
+	for range events {
+	}
+	if len(errs) == 0 || !errors.Is(errs[0], ErrTooManyRequests) {
+		t.Errorf("want throttling error reported, have %v", errs)
+	}
+	if _, err := fsys.Watch(ctx, "/docs", WithAdaptiveInterval(time.Second, time.Millisecond)); err == nil {
+		t.Error("Watch succeeds with max interval shorter than min")
+	}
+}
@@ -1,13 +1,16 @@
 package ydfs
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"iter"
 	"net/http"
+	"os"
 	"path"
+	"slices"
 	"strings"
 	"time"
 )
@@ -19,15 +22,70 @@ import (
 // specific to metainformation stored by Yandex -
 // see DiskInfo and UserInfo methods.
 type FS interface {
-	// Open opens the named file.
+	// Open opens the named file for reading. Stat of the returned file
+	// reports metadata fetched at open, the file implements File and
+	// its Refresh method fetches metadata again.
 	Open(name string) (fs.File, error)
 
+	// OpenWithContentType opens the named file and returns its MIME type
+	// as detected by Yandex Disk. MIME type is empty for directories.
+	OpenWithContentType(name string) (fs.File, string, error)
+
+	// Create creates or truncates the named file. The file is
+	// uploaded when it is synced or closed.
+	Create(name string) (File, error)
+
+	// OpenFile opens the named file with specified flag (os.O_RDONLY,
+	// os.O_RDWR|os.O_CREATE etc.). Permissions are not supported
+	// by the disk, so perm is ignored.
+	// Files created with os.O_EXCL are not overwritten when uploaded:
+	// Sync and Close fail with fs.ErrExist if the file has been
+	// created by someone else since it was opened.
+	OpenFile(name string, flag int, perm fs.FileMode) (File, error)
+
 	// Stat returns a FileInfo describing the named file from the file system.
 	Stat(name string) (fs.FileInfo, error)
 
+	// ReadLink always fails as there are no symbolic links on the disk:
+	// with fs.ErrInvalid for existing files. Together with Lstat it
+	// makes FS satisfy fs.ReadLinkFS.
+	ReadLink(name string) (string, error)
+
+	// Lstat is the same as Stat.
+	Lstat(name string) (fs.FileInfo, error)
+
+	// StatMany fetches metadata of paths concurrently. Infos of existing
+	// files are returned even if some of the paths fail, errors of
+	// those are reported by StatErrors.
+	StatMany(ctx context.Context, paths []string) (map[string]fs.FileInfo, error)
+
+	// ContentHash returns hex encoded hash of contents of the named file
+	// as stored by the disk, without downloading the file. Supported
+	// algorithms are listed by Hashes (see Hasher).
+	ContentHash(name, algo string) (string, error)
+
+	// Hashes returns names of algorithms supported by ContentHash.
+	Hashes() []string
+
 	// Sub returns an FS corresponding to the subtree rooted at dir.
 	Sub(dir string) (FS, error)
 
+	// SubSorted is like Sub, but directory listings of the returned FS
+	// are sorted by sortBy field, in descending order if desc is true.
+	SubSorted(dir string, sortBy string, desc bool) (FS, error)
+
+	// StatExtended returns full metadata of the named resource as
+	// provided by the API, including antivirus status of files.
+	// Options can be used to request previews
+	// of specific size (see WithPreviewSize).
+	StatExtended(name string, opts ...QueryOption) (Resource, error)
+
+	// OpenPreview returns preview image of the named file scaled to
+	// size (see WithPreviewSize). It fails with ErrNoPreview if the disk
+	// has no preview of the file, which is the case for most files other
+	// than images and videos. Caller must close the returned reader.
+	OpenPreview(ctx context.Context, name, size string) (io.ReadCloser, error)
+
 	// ReadFile reads the named file and returns its contents.
 	// A successful call returns a nil error, not io.EOF.
 	// (Because ReadFile reads the whole file, the expected EOF
@@ -38,14 +96,58 @@ type FS interface {
 	ReadFile(name string) ([]byte, error)
 
 	// ReadDir reads the named directory
-	// and returns a list of directory entries sorted by filename.
+	// and returns a list of directory entries sorted by filename
+	// (or in the order set with SubSorted).
 	ReadDir(name string) ([]fs.DirEntry, error)
 
+	// ReadDirIter returns iterator over entries of the named directory.
+	// Entries are fetched from the API page by page as iteration goes,
+	// so huge directories are never held in memory as a whole.
+	// An error stops the iteration and is yielded with nil entry.
+	ReadDirIter(ctx context.Context, name string) iter.Seq2[fs.DirEntry, error]
+
+	// WalkIter returns iterator over the file tree rooted at root
+	// yielding paths and entries of root and its descendants, parents
+	// before their children. Files come from the flat listing of the
+	// disk (see FilesByMimeType) and directories are yielded when the
+	// first file within them is seen, so memory use does not depend on
+	// the number of files. Empty directories are not yielded.
+	// If filters are given only files passing all of them (with paths
+	// relative to root) and their directories are yielded.
+	// Error which stopped the iteration is returned by err.
+	WalkIter(ctx context.Context, root string, filters ...*Filter) (seq iter.Seq2[string, fs.DirEntry], err func() error)
+
+	// ReadDirExtended reads the named directory and returns full
+	// metadata of its entries. Options are the same as for StatExtended.
+	ReadDirExtended(name string, opts ...QueryOption) ([]Resource, error)
+
+	// ReadDirSorted is like ReadDir, but entries are sorted by the API
+	// by sortBy field (one of SortByName, SortByModified etc.), in descending order if desc is true.
+	ReadDirSorted(name string, sortBy string, desc bool) ([]fs.DirEntry, error)
+
 	// WriteFile writes data to the named file, creating it if necessary.
 	// If the file does not exist, WriteFile creates it
-	// otherwise WriteFile truncates it before writing.
+	// otherwise WriteFile truncates it before writing
+	// (or fails with fs.ErrExist if FS is created WithoutOverwrite).
 	WriteFile(name string, data []byte) error
 
+	// WriteFileExcl is like WriteFile, but fails with fs.ErrExist if
+	// the file exists. The check is made by the API with the upload,
+	// so concurrent writers can not replace each other's files.
+	WriteFileExcl(name string, data []byte) error
+
+	// WriteFileStream is like WriteFile, but contents of the file
+	// are read from r and uploaded as they are read, without
+	// buffering the whole file in memory.
+	WriteFileStream(name string, r io.Reader) error
+
+	// OpenWrite starts upload of the named file and returns writer
+	// streaming written data to it. The upload is finished by Close,
+	// which returns its error. If the upload fails earlier, writes fail
+	// with its error too. Options may limit the size of the file and
+	// make Close verify checksums of the uploaded file.
+	OpenWrite(ctx context.Context, name string, opts ...WriteOption) (io.WriteCloser, error)
+
 	// Mkdir creates a new directory with the specified name
 	Mkdir(name string) error
 
@@ -56,163 +158,524 @@ type FS interface {
 	// Remove removes the named file or (empty) directory.
 	Remove(name string) error
 
+	// Rename moves the named file or directory to newname. It fails with
+	// fs.ErrExist if newname exists unless FS is created with another
+	// policy (see WithRenamePolicy).
+	Rename(oldname, newname string) error
+
 	// RemoveAll removes path and any children it contains. It removes everything it can
-	// but returns the first error it encounters. If the path does not exist,
+	// but returns the first error it encounters (or PathErrors listing all of them
+	// if FS is created WithContinueOnError). If the path does not exist,
 	// RemoveAll returns nil (no error).
 	RemoveAll(path string) error
+
+	// FilesByMimeType returns metadata of all files within FS whose
+	// MIME type starts with prefix, e.g. "image/" or "text/plain".
+	// The whole flat list of files on the disk is scanned to find them.
+	FilesByMimeType(ctx context.Context, prefix string) ([]Resource, error)
+
+	// SearchName returns metadata of up to limit files within FS whose
+	// names contain substring ignoring case. The API has no search, so
+	// the whole flat list of files on the disk is scanned until limit
+	// files are found, which takes a while on large disks.
+	SearchName(ctx context.Context, substring string, limit int) ([]Resource, error)
+
+	// SystemFolders returns paths of system folders on the disk by their
+	// keys (SystemFolderPhotostream, "downloads" etc.). Paths are relative
+	// to the root of the disk even if FS is a sub FS.
+	SystemFolders(ctx context.Context) (map[string]string, error)
+
+	// UploadMedia uploads photo or video read from r to the camera uploads
+	// (SystemFolderPhotostream) folder and returns the path of the uploaded
+	// file. The file is named after the time it was taken like Yandex Disk
+	// apps do, keeping the extension of name; a numeric suffix is added if
+	// the name is taken. Upload of the file that is already on the disk
+	// finishes without transfer if r is an io.ReadSeeker.
+	UploadMedia(ctx context.Context, name string, r io.Reader, taken time.Time) (string, error)
+
+	// ListVersions returns previous contents of the named file kept by
+	// WithVersioning from the oldest to the newest.
+	ListVersions(name string) ([]Version, error)
+
+	// RestoreVersion replaces contents of the named file with the version
+	// identified by id (see Version). Current contents are kept as a new
+	// version.
+	RestoreVersion(name, id string) error
+
+	// VerifyTree compares files of the local directory with files of the
+	// remote one by MD5 sums (hashing local files in parallel) and reports
+	// missing, modified and extra files. Neither of directories is changed.
+	VerifyTree(ctx context.Context, remoteDir, localDir string) (*VerifyReport, error)
+
+	// Syncer returns Syncer which makes remoteDir a copy of localDir
+	// (SyncUp) or localDir a copy of remoteDir (SyncDown).
+	Syncer(remoteDir, localDir string, direction SyncDirection, opts ...SyncOption) *Syncer
+
+	// UsageReport sums sizes of files under root by media type and by
+	// top level folder. It pages through the flat list of all files on
+	// the disk, which is faster than walking the tree on large disks.
+	UsageReport(ctx context.Context, root string) (*Usage, error)
+
+	// ListModifiedSince returns up to limit files modified after since,
+	// the oldest first. Modified time of the last file is the cursor to
+	// pass as since to get the next page; files modified at the same time
+	// are returned together even if limit is exceeded. Deletions are not
+	// reported.
+	ListModifiedSince(ctx context.Context, since time.Time, limit int) ([]Resource, error)
+
+	// Validate checks the token and reachability of the API like New
+	// does unless WithLazyInit is given.
+	Validate(ctx context.Context) error
+
+	// Capabilities probes the API to find access granted by the token:
+	// whether it is limited to the app folder, whether FS can be read
+	// and written, so that UIs can disable unsupported actions upfront.
+	// Probing does not change anything on the disk.
+	Capabilities(ctx context.Context) (Capabilities, error)
+
+	// WithContext returns FS which makes requests with ctx, so that
+	// cancelling ctx cancels all requests and transfers started through
+	// the returned FS and files opened with it. Methods accepting
+	// context are cancelled by either of the contexts.
+	WithContext(ctx context.Context) FS
+
+	// Ping performs a lightweight authenticated request to check that
+	// the API is reachable and the token is valid, e.g. for readiness
+	// probes.
+	Ping(ctx context.Context) error
+
+	// View returns read-only fs.FS over the special listing of files
+	// named name: ViewRecent for the most recently uploaded files,
+	// ViewPhotos, ViewVideos, ViewAudio or ViewDocuments for files of
+	// media types. Files are shown at their paths within FS, directories
+	// holding them are made up. Files are listed when View is called,
+	// call it again to see later changes.
+	View(ctx context.Context, name string) (fs.FS, error)
+
+	// TrashFS returns read-only fs.FS over contents of the trash. Trashed
+	// resources can be listed and inspected by their paths in the trash,
+	// but their contents can not be read.
+	TrashFS() (fs.FS, error)
+
+	// RestoreAs restores resource at trashPath within the trash (see
+	// TrashFS) to the directory it was deleted from. Unless newName is
+	// empty the restored resource is renamed to newName. Existing
+	// resource is replaced only if overwrite is set, otherwise
+	// RestoreAs fails with fs.ErrExist.
+	RestoreAs(trashPath, newName string, overwrite bool) error
+
+	// Prefetch fetches metadata of root directory and its subdirectories
+	// up to depth levels below it (the whole subtree if depth is negative)
+	// into the metadata cache, so that subsequent Stat and ReadDir calls
+	// are served from the cache. It does nothing unless FS is created
+	// WithMetadataCache.
+	Prefetch(ctx context.Context, root string, depth int) error
+
+	// TryLock takes advisory lock of the named resource for owner until
+	// ttl passes and reports whether it is taken. Locks are kept in
+	// custom properties of resources, so they are seen by all clients of
+	// the disk using TryLock. Owner holding the lock may call TryLock
+	// again to extend it. Expired locks are taken over.
+	//
+	// Locking is best-effort and does not guarantee mutual exclusion:
+	// the API has no conditional updates, so owners racing for a free
+	// lock may both be told they have taken it. Use it to avoid
+	// duplicate work rather than to protect data from corruption.
+	TryLock(name, owner string, ttl time.Duration) (bool, error)
+
+	// Unlock releases the lock of the named resource taken by owner.
+	// It fails with ErrLocked if the lock is held by another owner.
+	Unlock(name, owner string) error
+
+	// PurgeTrash permanently deletes resources which were moved to the
+	// trash at least olderThan ago and returns their number.
+	PurgeTrash(olderThan time.Duration) (int, error)
+
+	// ListSharedFolders returns metadata of shared folders within FS
+	// (see ShareInfo), so that read-only shares can be told from owned
+	// folders before attempting writes. Every directory outside of shared
+	// folders is listed to find them.
+	ListSharedFolders(ctx context.Context) ([]Resource, error)
+
+	// Operations returns asynchronous operations (e.g. removal of large
+	// directories) started by FS which have not been seen finished.
+	// See WithOperationsFile to keep the list across restarts.
+	Operations() []Operation
+
+	// GetOperationStatus fetches status of asynchronous operation
+	// identified by id: OperationSuccess, OperationFailed or
+	// OperationInProgress. Finished operations are removed from
+	// the list returned by Operations.
+	GetOperationStatus(id string) (string, error)
+
+	// Watch polls the named directory for changes of files in its tree
+	// and delivers them to the returned channel, which is closed when
+	// ctx is done. Options filter events and set the poll interval.
+	Watch(ctx context.Context, root string, opts ...WatchOption) (<-chan Event, error)
+
+	// Snapshot records metadata of files of the named directory tree,
+	// including their hashes, see TreeSnapshot.
+	Snapshot(ctx context.Context, root string) (*TreeSnapshot, error)
+
+	// Restore recreates the tree recorded in snapshot under its root,
+	// making directories and uploading contents of files from src.
+	// Hashes of uploaded files are checked against the snapshot and
+	// files which fail the check are removed. Files which are already
+	// there with the recorded hashes are skipped, so an interrupted
+	// restore can be resumed. Failures are returned as PathErrors.
+	Restore(ctx context.Context, snapshot *TreeSnapshot, src ContentSource) error
+
+	// CleanupTemp removes temporary objects which the library keeps in
+	// the hidden directory reserved for them and which are older than
+	// olderThan, e.g. ones left by processes interrupted while writing.
+	CleanupTemp(ctx context.Context, olderThan time.Duration) error
+
+	// Publish makes the named resource publicly available
+	// and returns its public URL.
+	Publish(name string) (string, error)
+
+	// PublishWithInfo is like Publish, but returns public key, number of
+	// views and other metadata of the link along with its URL. Resources
+	// already published keep their links.
+	PublishWithInfo(ctx context.Context, name string) (*PublicLink, error)
+
+	// Unpublish closes public access to the named resource.
+	Unpublish(name string) error
+
+	// DownloadFile downloads the named file and writes its contents to w.
+	// Large files are split into at most parallel ranges which are
+	// fetched concurrently and written to w at their offsets.
+	DownloadFile(ctx context.Context, name string, w io.WriterAt, parallel int) error
+
+	// DownloadIfChanged downloads the named file to localPath unless the
+	// local file has the same MD5 as the remote one and reports whether
+	// the file was downloaded. The local file is replaced only when the
+	// download succeeds. Modification time of the local file is set to
+	// the one of the remote file (see DownloadToFile) either way.
+	DownloadIfChanged(ctx context.Context, name, localPath string) (bool, error)
+
+	// DownloadToFile downloads the named file to localPath. If resume is
+	// set and localPath is a part of the file left by an interrupted
+	// download, only the rest of the file is fetched. The end of the
+	// part is compared with the remote file before resuming and the
+	// whole file is checked with MD5 after, the download starts over if
+	// either differs. Without resume localPath is only replaced when
+	// the download succeeds. Modification time of localPath is set to
+	// the time stored in "ydfs_mtime" custom property of the remote file
+	// (RFC 3339) if any or to the time it was modified on the disk, so
+	// that tools comparing timestamps do not copy the file again.
+	DownloadToFile(ctx context.Context, name, localPath string, resume bool) error
 }
 
 // ydfs implements FS interface
 type ydfs struct {
-	client *apiclient // api client
-	path   string     // base path
-	issub  bool       // is this a sub FS?
+	client *apiclient      // api client
+	opts   *options        // configuration shared with sub FS
+	path   string          // base path
+	issub  bool            // is this a sub FS?
+	sort   string          // sort order of directory listings, API default if empty
+	ctx    context.Context // base context of requests, see WithContext
 }
 
 // New returns ydfs.FS which is compliant with
 // standard library's fs.FS interface. Token is required for authorization.
 // Pre-configured http.Client can be supplied (e.g. with timeout set to specific value).
-// If client is nil then http.DefaultClient is used.
-func New(token string, client *http.Client) (FS, error) {
-	if client == nil {
-		client = http.DefaultClient
-	}
-	c := newApiClient(token, client)
-	// checking whether we can fetch disk metadata to
-	// make sure that token is valid and we we can send
-	// requests to the API.
-	if _, err := c.getDiskInfo(); err != nil {
+// If client is nil then a client with transport tuned for parallel transfers
+// is used and metadata requests time out after 30 seconds
+// (see WithMetadataTimeout).
+// Options can be used to tune the behaviour of returned FS.
+func New(token string, client *http.Client, opts ...Option) (FS, error) {
+	return NewContext(context.TODO(), token, client, opts...)
+}
+
+// NewContext is like New, but the request validating the token is
+// made with ctx, so that it can be cancelled or time out.
+func NewContext(ctx context.Context, token string, client *http.Client, opts ...Option) (FS, error) {
+	c, err := newClientContext(ctx, token, client, opts...)
+	if err != nil {
 		return nil, err
 	}
-	return &ydfs{client: c, path: "/", issub: false}, nil
+	return NewFromClient(c, "/"), nil
+}
+
+// fullPath returns path of the named resource on the disk.
+// Names are accepted both rooted ("/docs/a.txt") and unrooted as
+// io/fs expects ("docs/a.txt", "." for the root). ".." elements do not
+// lead outside the base path, see checkName.
+func (y *ydfs) fullPath(name string) string {
+	return path.Join("/", y.path, path.Clean("/"+name))
+}
+
+// checkName fails with fs.ErrInvalid if name has ".." elements, which
+// io/fs does not allow and which would refer to resources outside
+// the subtree of sub FS.
+func checkName(op, name string) error {
+	for _, elem := range strings.Split(name, "/") {
+		if elem == ".." {
+			return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+		}
+	}
+	return nil
 }
 
 // Open implements fs.Fs interface
 func (y *ydfs) Open(name string) (fs.File, error) {
-	var fullname string
-	if y.issub {
-		fullname = path.Join(y.path, name)
-	} else {
-		fullname = name
+	file, _, err := y.open(y.context(), name, os.O_RDONLY, y.client.fields)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// open opens the named file fetching the requested fields of its
+// metadata. Flag tells whether contents of the file are going to be read.
+func (y *ydfs) open(ctx context.Context, name string, flag int, fields []string) (*ydfile, Resource, error) {
+	if err := checkName("open", name); err != nil {
+		return nil, Resource{}, err
 	}
-	res, err := y.client.getResourceMinTraffic(fullname)
+	fullname := y.fullPath(name)
+	links := y.prefetchLink(ctx, fullname, flag)
+	res, err := y.client.getResource(ctx, fullname, 0, fields...)
 	if err != nil {
-		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		return nil, Resource{}, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	y.client.normalize(&res)
+	if err := y.checkAntivirus(res); err != nil {
+		return nil, Resource{}, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	file := y.newFile(name, res)
+	if links != nil && !file.isdir {
+		y.prefetch(ctx, file, links)
+	}
+	return file, res, nil
+}
+
+// newFile returns file opened by name and described by res,
+// which must have normalized path.
+func (y *ydfs) newFile(name string, res Resource) *ydfile {
+	return &ydfile{
+		client:    y.client,
+		opts:      y.opts,
+		name:      name,
+		path:      res.Path,
+		isdir:     res.IsDir(),
+		sort:      y.sort,
+		size:      res.Size,
+		res:       res,
+		chunkSize: y.opts.chunkSize,
+		readAhead: y.opts.readAhead,
+		window:    readWindow{size: int(y.opts.readWindow)},
+		ctx:       y.context(),
 	}
-	normalizeResourcePath(&res)
-	var file ydfile
-	file.client = y.client
-	file.path = res.Path
-	file.isdir = (res.Type == "dir")
-	file.size = res.Size
-	return &file, nil
 }
 
 // Stat implements fs.StatFS
 func (y *ydfs) Stat(name string) (fs.FileInfo, error) {
-	if y.issub {
-		name = path.Join(y.path, name)
+	return y.stat(y.context(), "stat", name)
+}
+
+// stat returns FileInfo of the named file reporting errors as op.
+func (y *ydfs) stat(ctx context.Context, op, name string) (fs.FileInfo, error) {
+	if err := checkName(op, name); err != nil {
+		return nil, err
 	}
-	res, err := y.client.getResourceMinTraffic(name)
+	res, err := y.client.getResourceMinTraffic(ctx, y.fullPath(name))
 	if err != nil {
-		return nil, &fs.PathError{Op: "stat", Path: y.path, Err: err}
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
 	}
-	normalizeResourcePath(&res)
+	y.client.normalize(&res)
 	if y.issub {
 		res.Path = strings.TrimPrefix(res.Path, y.path)
 		if res.Path == "" {
 			res.Path = "/"
 		}
 	}
-	return &ydinfo{res}, nil
+	return y.opts.info(res), nil
 }
 
 // Sub implements fs.SubFS
 func (y *ydfs) Sub(dir string) (FS, error) {
-	if y.issub {
-		dir = path.Join(y.path, dir)
+	if err := checkName("sub", dir); err != nil {
+		return nil, err
 	}
-	res, err := y.client.getResourceMinTraffic(dir)
+	res, err := y.client.getResourceMinTraffic(y.context(), y.fullPath(dir))
 	if err != nil {
-		return nil, &fs.PathError{Op: "read", Path: y.path, Err: err}
+		return nil, &fs.PathError{Op: "sub", Path: dir, Err: err}
 	}
-	if res.Type != "dir" {
-		return nil, &fs.PathError{Op: "sub", Path: y.path, Err: fmt.Errorf("not a directory")}
+	if !res.IsDir() {
+		return nil, &fs.PathError{Op: "sub", Path: dir, Err: ErrNotDir}
 	}
-	normalizeResourcePath(&res)
-	return &ydfs{client: y.client, path: res.Path, issub: true}, nil
+	y.client.normalize(&res)
+	return &ydfs{client: y.client, opts: y.opts, path: res.Path, issub: true, sort: y.sort, ctx: y.ctx}, nil
 }
 
 // ReadFile implements fs.ReadFileFS
 func (y *ydfs) ReadFile(name string) ([]byte, error) {
-	if y.issub {
-		name = path.Join(y.path, name)
+	if err := checkName("read", name); err != nil {
+		return []byte{}, err
+	}
+	fullname := y.fullPath(name)
+	if err := y.checkAntivirusByName(y.context(), fullname); err != nil {
+		return []byte{}, &fs.PathError{Op: "read", Path: name, Err: err}
 	}
-	data, err := y.client.getFile(name)
+	data, err := y.client.getFile(y.context(), fullname)
 	if err != nil {
-		return []byte{}, &fs.PathError{Op: "read", Path: y.path, Err: err}
+		return []byte{}, &fs.PathError{Op: "read", Path: name, Err: err}
 	}
 	return data, nil
 }
 
 // ReadDir implements fs.ReadDirFS
 func (y *ydfs) ReadDir(name string) ([]fs.DirEntry, error) {
-	if y.issub {
-		name = path.Join(y.path, name)
+	return y.readDir(y.context(), name, y.sort)
+}
+
+// readDir lists the named directory sorted by sort (see getResourceSorted).
+func (y *ydfs) readDir(ctx context.Context, name string, sort string) ([]fs.DirEntry, error) {
+	if err := checkName("readdirent", name); err != nil {
+		return []fs.DirEntry{}, err
 	}
-	res, err := y.client.getResourceWithEmbedded(name)
+	res, err := y.client.getResourceListing(ctx, y.fullPath(name), sort)
 	if err != nil {
-		return []fs.DirEntry{}, &fs.PathError{Op: "open", Path: name, Err: err}
+		return []fs.DirEntry{}, &fs.PathError{Op: "readdirent", Path: name, Err: err}
 	}
-	if res.Type != "dir" {
-		return []fs.DirEntry{}, &fs.PathError{Op: "readdirent", Path: name, Err: fmt.Errorf("not a directory")}
+	if !res.IsDir() {
+		return []fs.DirEntry{}, &fs.PathError{Op: "readdirent", Path: name, Err: ErrNotDir}
 	}
+	y.client.normalize(&res)
 	entries := make([]fs.DirEntry, len(res.Embedded.Items))
-
-	// TODO: implement sort by filename
 	for i := 0; i < len(res.Embedded.Items); i++ {
-		entries[i] = &ydinfo{res.Embedded.Items[i]}
+		entries[i] = y.opts.info(res.Embedded.Items[i])
+	}
+	if sort == "" {
+		// fs.ReadDirFS requires byte order of names, while the API
+		// may order them as humans would
+		slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+			return strings.Compare(a.Name(), b.Name())
+		})
 	}
 	return entries, nil
 }
 
+// WriteFile implements FS
 func (y *ydfs) WriteFile(name string, data []byte) error {
-	if y.issub {
-		name = path.Join(y.path, name)
+	return y.writeFile(name, data, !y.opts.noOverwrite)
+}
+
+// writeFile uploads data to the named file. Unless overwrite is set
+// existing file is not replaced and fs.ErrExist is returned.
+func (y *ydfs) writeFile(name string, data []byte, overwrite bool) error {
+	if err := checkName("write", name); err != nil {
+		return err
+	}
+	fullname := y.fullPath(name)
+	if overwrite && y.opts.skipSame && unchanged(y.context(), y.client, fullname, data) {
+		return nil
+	}
+	if overwrite {
+		if err := y.opts.keepVersion(y.context(), y.client, fullname); err != nil {
+			return &fs.PathError{Op: "write", Path: name, Err: err}
+		}
 	}
-	if err := y.client.putFileTruncate(name, data); err != nil {
+	err := y.client.putFile(y.context(), fullname, overwrite, data)
+	y.opts.auditRecord(AuditWrite, fullname, int64(len(data)), err)
+	if err != nil {
 		return &fs.PathError{Op: "write", Path: name, Err: err}
 	}
 	return nil
 }
 
+// WriteFileStream implements FS
+func (y *ydfs) WriteFileStream(name string, r io.Reader) error {
+	return y.writeStream(y.context(), name, r, -1)
+}
+
+// writeStream uploads contents of r to the named file.
+// If size is negative the length of r is unknown. Large seekable
+// streams are hashed first to offer the uploader to skip the transfer.
+func (y *ydfs) writeStream(ctx context.Context, name string, r io.Reader, size int64) error {
+	if err := checkName("write", name); err != nil {
+		return err
+	}
+	if err := y.upload(ctx, y.fullPath(name), !y.opts.noOverwrite, r, size); err != nil {
+		return &fs.PathError{Op: "write", Path: name, Err: err}
+	}
+	return nil
+}
+
+// upload uploads contents of r to the full path. Size of r may be unknown
+// (negative), contents of io.ReadSeeker are offered for instant upload.
+func (y *ydfs) upload(ctx context.Context, fullname string, overwrite bool, r io.Reader, size int64) error {
+	var hashes *contentHashes
+	if rs, ok := r.(io.ReadSeeker); ok && y.client.instantUpload > 0 {
+		n, h, err := hashReadSeeker(rs)
+		if err != nil {
+			return err
+		}
+		size = n
+		if n >= y.client.instantUpload {
+			hashes = h
+		}
+	}
+	if overwrite {
+		if err := y.opts.keepVersion(ctx, y.client, fullname); err != nil {
+			return err
+		}
+	}
+	cr := &countingReader{r: r}
+	err := y.client.putStream(ctx, fullname, overwrite, cr, size, hashes)
+	written := size
+	if written < 0 {
+		written = cr.n
+	}
+	y.opts.auditRecord(AuditWrite, fullname, written, err)
+	return err
+}
+
+// countingReader counts bytes read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
 func (y *ydfs) Mkdir(name string) error {
-	if y.issub {
-		name = path.Join(y.path, name)
+	if err := checkName("mkdir", name); err != nil {
+		return err
 	}
-	if err := y.client.mkdir(name); err != nil {
+	fullname := y.fullPath(name)
+	err := y.client.mkdir(y.context(), fullname)
+	y.opts.auditRecord(AuditMkdir, fullname, 0, err)
+	if err != nil {
 		return &fs.PathError{Op: "mkdir", Path: name, Err: err}
 	}
 	return nil
 }
 
 func (y *ydfs) MkdirAll(dir string) error {
-	if y.issub {
-		dir = path.Join(y.path, dir)
+	if err := checkName("mkdir", dir); err != nil {
+		return err
 	}
-	split := strings.Split(strings.Trim(dir, "/"), "/")
-	toMake := bytes.Buffer{}
-	for i := range split {
-		toMake.WriteString("/" + split[i])
-		s, err := y.Stat(toMake.String())
+	toMake := "/"
+	for _, elem := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if elem == "" {
+			continue
+		}
+		toMake = path.Join(toMake, elem)
+		res, err := y.client.getResourceMinTraffic(y.context(), y.fullPath(toMake))
 		if err != nil && !errors.Is(err, ErrNotFound) {
-			return &fs.PathError{Op: "mkdir", Path: toMake.String(), Err: err}
-		} else if err == nil && !s.IsDir() {
-			return &fs.PathError{Op: "mkdir", Path: toMake.String(), Err: fmt.Errorf("not a directory")}
-		} else if err == nil && s.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: toMake, Err: err}
+		} else if err == nil && !res.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: toMake, Err: ErrNotDir}
+		} else if err == nil {
 			continue
 		}
-		if err := y.Mkdir(toMake.String()); err != nil {
+		if err := y.Mkdir(toMake); err != nil {
 			return err
 		}
 	}
@@ -221,41 +684,74 @@ func (y *ydfs) MkdirAll(dir string) error {
 
 // Remove implements FS
 func (y *ydfs) Remove(name string) error {
-	if y.issub {
-		name = path.Join(y.path, name)
+	if err := checkName("remove", name); err != nil {
+		return err
 	}
-	res, err := y.client.getResourceWithEmbedded(name)
+	fullname := y.fullPath(name)
+	res, err := y.client.getResourceListing(y.context(), fullname, "")
 	if err != nil {
-		return &fs.PathError{Op: "stat", Path: name, Err: err}
-	} else if res.Type == "dir" && len(res.Embedded.Items) > 0 {
-		return &fs.PathError{Op: "remove", Path: name, Err: fmt.Errorf("directory not empty")}
+		return &fs.PathError{Op: "remove", Path: name, Err: err}
+	} else if res.IsDir() && len(res.Embedded.Items) > 0 {
+		return &fs.PathError{Op: "remove", Path: name, Err: ErrNotEmpty}
 	}
-	if err := y.client.delResourcePermanently(name); err != nil {
+	if err := y.guardDelete(name, res); err != nil {
+		return err
+	}
+	err = y.client.delResourcePermanently(y.context(), fullname)
+	y.opts.auditRecord(AuditRemove, fullname, 0, err)
+	if err != nil {
 		return &fs.PathError{Op: "remove", Path: name, Err: err}
 	}
 	return nil
 }
 
 // RemoveAll implements FS
-func (y *ydfs) RemoveAll(dir string) error {
-	if y.issub {
-		dir = path.Join(y.path, dir)
+func (y *ydfs) RemoveAll(name string) error {
+	if err := checkName("remove", name); err != nil {
+		return err
 	}
-	res, err := y.client.getResourceWithEmbedded(dir)
+	if !y.opts.continueOnError {
+		return y.removeAll(name, nil)
+	}
+	errs := make(PathErrors)
+	if y.removeAll(name, errs); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// removeAll removes the named resource and its children. Errors are
+// collected in errs unless it is nil, otherwise the first error stops
+// the removal. Directories with children which are not removed are kept.
+func (y *ydfs) removeAll(name string, errs PathErrors) error {
+	fullname := y.fullPath(name)
+	res, err := y.client.getResourceListing(y.context(), fullname, "")
 	if err != nil && errors.Is(err, ErrNotFound) {
 		return nil
 	} else if err != nil {
-		return &fs.PathError{Op: "remove", Path: dir, Err: err}
+		return errs.add(name, &fs.PathError{Op: "remove", Path: name, Err: err})
+	}
+	if err := y.guardDelete(name, res); err != nil {
+		return errs.add(name, err)
 	}
 	// remove children first
+	var childErr error
 	for i := range res.Embedded.Items {
-		if err := y.RemoveAll(res.Embedded.Items[i].Path); err != nil {
-			return err
+		if err := y.removeAll(path.Join(name, res.Embedded.Items[i].Name), errs); err != nil {
+			if errs == nil {
+				return err
+			}
+			childErr = err
 		}
 	}
+	if childErr != nil {
+		return childErr
+	}
 	// remove parent
-	if err := y.client.delResourcePermanently(dir); err != nil {
-		return &fs.PathError{Op: "remove", Path: dir, Err: err}
+	err = y.client.delResourcePermanently(y.context(), fullname)
+	y.opts.auditRecord(AuditRemove, fullname, 0, err)
+	if err != nil {
+		return errs.add(name, &fs.PathError{Op: "remove", Path: name, Err: err})
 	}
 	return nil
 }
@@ -263,82 +759,184 @@ func (y *ydfs) RemoveAll(dir string) error {
 // ydfile implements File interface
 type ydfile struct {
 	client *apiclient // api client
+	opts   *options   // configuration of FS which opened the file
+	name   string     // name the file is opened with
 	path   string     // file path including its name
 	// name     string     // file name
 	isdir bool // sets to true if file is a directory
 	// mode     fs.FileMode
-	rdoffset int    // read dir offset for directories
-	roffset  int    // read offset for regular files
-	size     int64  // actual data size in bytes
-	data     []byte // payload of a file
+	sort     string          // sort order of directory entries
+	rdoffset int             // read dir offset for directories
+	roffset  int             // read and write offset for regular files
+	size     int64           // actual data size in bytes
+	data     []byte          // payload of a file
+	res      Resource        // metadata fetched at open or by Refresh
+	link     *link           // download link fetched at open if any
+	ctx      context.Context // base context of the FS which opened the file
+
+	chunkSize int64        // stream contents in chunks of this size if positive
+	readAhead int          // number of chunks fetched ahead
+	stream    *chunkReader // streams contents in chunked mode
+	window    readWindow   // the last bytes read from stream
+
+	flag  int  // flags the file is opened with, see OpenFile
+	dirty bool // contents are changed and not uploaded yet
+	excl  bool // created with O_EXCL and not uploaded yet
 }
 
 // Read implements fs.File
 func (file *ydfile) Read(b []byte) (int, error) {
 	if file.isdir {
-		return 0, &fs.PathError{Op: "read", Path: file.path, Err: fmt.Errorf("is a directory")}
+		return 0, &fs.PathError{Op: "read", Path: file.name, Err: ErrIsDir}
 	}
-	// TODO: implement download in chunks to only fetch
-	// required data
-	if file.data == nil {
-		fileBytes, err := file.client.getFile(file.path)
-		if err != nil {
-			return 0, &fs.PathError{Op: "read", Path: file.path, Err: err}
-		}
-		file.data = fileBytes
-		file.roffset = 0
+	if file.flag&(os.O_WRONLY|os.O_RDWR) == os.O_WRONLY {
+		return 0, &fs.PathError{Op: "read", Path: file.name, Err: fs.ErrPermission}
 	}
-	if file.roffset == len(file.data) {
+	if file.data == nil && file.chunkSize > 0 {
+		return file.readChunked(b)
+	}
+	if err := file.load("read"); err != nil {
+		return 0, err
+	}
+	if file.roffset >= len(file.data) {
 		return 0, io.EOF
 	}
+	n := copy(b, file.data[file.roffset:])
+	file.roffset += n
+	if file.roffset == len(file.data) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteTo implements io.WriterTo, so that io.Copy consumes contents
+// of the file without intermediate buffers. Contents not loaded into
+// memory yet are written to w as they are downloaded instead.
+func (file *ydfile) WriteTo(w io.Writer) (int64, error) {
+	if file.isdir {
+		return 0, &fs.PathError{Op: "read", Path: file.name, Err: ErrIsDir}
+	}
+	if file.flag&(os.O_WRONLY|os.O_RDWR) == os.O_WRONLY {
+		return 0, &fs.PathError{Op: "read", Path: file.name, Err: fs.ErrPermission}
+	}
+	if file.data == nil && file.chunkSize > 0 {
+		// hides WriteTo from io.Copy
+		return io.Copy(w, struct{ io.Reader }{file})
+	}
+	if file.data == nil && file.roffset == 0 {
+		return file.streamTo(w)
+	}
+	if err := file.load("read"); err != nil {
+		return 0, err
+	}
+	if file.roffset >= len(file.data) {
+		return 0, nil
+	}
+	n, err := w.Write(file.data[file.roffset:])
+	file.roffset += n
+	return int64(n), err
+}
+
+// streamTo downloads contents of the file to w without loading them
+// into memory. Reads after that continue from the offset reached.
+func (file *ydfile) streamTo(w io.Writer) (int64, error) {
 	var (
-		err         error
-		toRead      int = len(b)
-		doneReading int = 0
+		body io.ReadCloser
+		err  error
 	)
-	if toRead > len(file.data[file.roffset:]) {
-		toRead = len(file.data[file.roffset:])
-		err = io.EOF
+	if file.link != nil {
+		body, err = file.client.getFileLinkStream(file.ctx, *file.link)
+	} else {
+		body, err = file.client.getFileStream(file.ctx, file.path)
 	}
-	for i := 0; i < toRead; i++ {
-		b[i] = file.data[file.roffset]
-		file.roffset++
-		doneReading++
+	if err != nil {
+		return 0, &fs.PathError{Op: "read", Path: file.name, Err: err}
+	}
+	defer body.Close()
+	n, err := io.Copy(w, body)
+	file.roffset = int(n)
+	if err != nil {
+		return n, &fs.PathError{Op: "read", Path: file.name, Err: fmt.Errorf("%w: %w", ErrNetwork, err)}
 	}
-	return doneReading, err
+	return n, nil
 }
 
-// Stat implements fs.File.
+// Stat implements fs.File. It returns metadata fetched when the file
+// was opened (or last refreshed) without a request to the API.
 func (file *ydfile) Stat() (fs.FileInfo, error) {
-	res, err := file.client.getResourceMinTraffic(file.path)
+	return file.opts.info(file.res), nil
+}
+
+// Refresh fetches metadata of the file from the API again, so that
+// subsequent calls to Stat reflect changes made since the file was
+// opened. Contents of the file already read are not affected.
+func (file *ydfile) Refresh() error {
+	res, err := file.client.getResourceMinTraffic(file.ctx, file.path)
+	if err != nil {
+		return &fs.PathError{Op: "stat", Path: file.name, Err: err}
+	}
+	file.client.normalize(&res)
+	file.res = res
+	file.size = res.Size
+	return nil
+}
+
+// load downloads contents of the file unless they are in memory already.
+func (file *ydfile) load(op string) error {
+	if file.data != nil {
+		return nil
+	}
+	var (
+		fileBytes []byte
+		err       error
+	)
+	if file.link != nil {
+		fileBytes, err = file.client.getFileLink(file.ctx, *file.link)
+	} else {
+		fileBytes, err = file.client.getFile(file.ctx, file.path)
+	}
 	if err != nil {
-		return nil, &fs.PathError{Op: "stat", Path: file.path, Err: err}
+		return &fs.PathError{Op: op, Path: file.name, Err: err}
+	}
+	file.data = fileBytes
+	if file.stream != nil {
+		file.stream.Close()
+		file.stream = nil
 	}
-	normalizeResourcePath(&res)
-	return &ydinfo{res}, err
+	return nil
 }
 
-// Close implements fs.File
+// Close implements fs.File. Changes made to contents of the
+// file are uploaded before the file is closed.
 func (file *ydfile) Close() error {
+	var err error
+	if file.dirty {
+		err = file.Sync()
+	}
+	if file.stream != nil {
+		file.stream.Close()
+		file.stream = nil
+	}
+	file.window = readWindow{size: file.window.size}
 	file.data = []byte{}
 	file.roffset = 0
-	return nil
+	return err
 }
 
 // ReadDir implements fs.ReadDirFile.
 func (file *ydfile) ReadDir(n int) ([]fs.DirEntry, error) {
 	if !file.isdir {
-		return []fs.DirEntry{}, &fs.PathError{Op: "readdirent", Path: file.path, Err: fmt.Errorf("not a directory")}
+		return []fs.DirEntry{}, &fs.PathError{Op: "readdirent", Path: file.name, Err: ErrNotDir}
 	}
-	res, err := file.client.getResourceWithEmbedded(file.path)
+	res, err := file.client.getResourceListing(file.ctx, file.path, file.sort)
 	if err != nil {
-		return []fs.DirEntry{}, &fs.PathError{Op: "readdirent", Path: file.path, Err: err}
+		return []fs.DirEntry{}, &fs.PathError{Op: "readdirent", Path: file.name, Err: err}
 	}
+	file.client.normalize(&res)
 	var (
 		entries   []fs.DirEntry
 		errResult error
 	)
-	// TODO: test logic here
 	total := len(res.Embedded.Items)
 	remaining := total - file.rdoffset
 	if n < 1 {
@@ -347,26 +945,31 @@ func (file *ydfile) ReadDir(n int) ([]fs.DirEntry, error) {
 	} else if n > remaining {
 		n = remaining
 		errResult = io.EOF
-	} else if n < remaining {
-		n = remaining
 	}
 	entries = make([]fs.DirEntry, n)
 	for i := 0; i < n; i++ {
-		entries[i] = &ydinfo{res.Embedded.Items[file.rdoffset]}
+		entries[i] = file.opts.info(res.Embedded.Items[file.rdoffset])
 		file.rdoffset++
 	}
 	return entries, errResult
 }
 
+// Default permission bits reported by Mode (see WithFileMode).
+const (
+	defaultFileMode fs.FileMode = 0644
+	defaultDirMode  fs.FileMode = 0755
+)
+
 // ydinfo implements fs.FileInfo and fs.DirEntry.
 type ydinfo struct {
-	res resource
+	res  Resource
+	mode fs.FileMode
 }
 
 // Name implements fs.FileInfo
 func (y *ydinfo) Name() string {
 	normalizeResourcePath(&y.res)
-	return y.res.Path
+	return path.Base(y.res.Path)
 }
 
 // Size implements fs.FileInfo
@@ -376,10 +979,7 @@ func (y *ydinfo) Size() int64 {
 
 // Mode implements fs.FileInfo
 func (y *ydinfo) Mode() fs.FileMode {
-	if y.IsDir() {
-		return fs.ModeDir
-	}
-	return 0
+	return y.mode
 }
 
 // ModTime implements fs.FileInfo
@@ -389,7 +989,7 @@ func (y *ydinfo) ModTime() time.Time {
 
 // IsDir implements fs.FileInfo
 func (y *ydinfo) IsDir() bool {
-	return y.res.Type == "dir"
+	return y.res.IsDir()
 }
 
 // Sys implements fs.FileInfo
@@ -399,7 +999,7 @@ func (y *ydinfo) Sys() interface{} {
 
 // Type implements fs.DirEntry
 func (y *ydinfo) Type() fs.FileMode {
-	return y.Mode()
+	return y.Mode().Type()
 }
 
 // Info implements fs.DirEntry
@@ -407,9 +1007,33 @@ func (y *ydinfo) Info() (fs.FileInfo, error) {
 	return y, nil
 }
 
-func normalizeResourcePath(r *resource) {
-	r.Path = strings.Replace(r.Path, "disk:", "", 1)
-	if r.Path == "/" && r.Name == "disk" {
+// mergeFields returns fields of a followed by fields of b
+// which are not present in a.
+func mergeFields(a, b []string) []string {
+	result := append([]string{}, a...)
+	for _, f := range b {
+		found := false
+		for _, have := range result {
+			if f == have {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+func normalizeResourcePath(r *Resource) {
+	for _, scheme := range []string{"disk:", "app:", "trash:"} {
+		if strings.HasPrefix(r.Path, scheme) {
+			r.Path = strings.TrimPrefix(r.Path, scheme)
+			break
+		}
+	}
+	if r.Path == "/" {
 		r.Name = "/"
 	}
 }
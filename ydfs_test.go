@@ -6,7 +6,6 @@ import (
 	"io"
 	"io/fs"
 	"os"
-	"path"
 	"testing"
 )
 
@@ -22,6 +21,7 @@ var (
 )
 
 func TestWriteFile(t *testing.T) {
+	integration(t)
 	fsys, err := New(os.Getenv("YD"), nil)
 	if err != nil {
 		t.Error(err)
@@ -32,6 +32,7 @@ func TestWriteFile(t *testing.T) {
 }
 
 func TestRead(t *testing.T) {
+	integration(t)
 	filesystem, err := New(os.Getenv("YD"), nil)
 	if err != nil {
 		t.Error(err)
@@ -53,7 +54,7 @@ func TestRead(t *testing.T) {
 		t.Error(err)
 		return
 	}
-	if !(stats.Name() == testRootDirName+testFileName) || stats.IsDir() {
+	if !(stats.Name() == testFileName) || stats.IsDir() {
 		t.Errorf("testfile Stat() method returns incorrect values, want: %v, have: %v", testFileName, stats.Name())
 		return
 	}
@@ -75,6 +76,7 @@ func TestRead(t *testing.T) {
 }
 
 func TestStatFile(t *testing.T) {
+	integration(t)
 	filesystem, err := New(os.Getenv("YD"), nil)
 	if err != nil {
 		t.Error(err)
@@ -83,14 +85,15 @@ func TestStatFile(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	if stat.Name() != testRootDirName+testFileName {
-		t.Errorf("Stat() for test file returns incorrect values, want: %v, have: %v", testRootDirName+testFileName, stat.Name())
+	if stat.Name() != testFileName {
+		t.Errorf("Stat() for test file returns incorrect values, want: %v, have: %v", testFileName, stat.Name())
 	} else if stat.IsDir() {
 		t.Errorf("Stat() for test file returns incorrect type, want IsDir() == false, have: %v", stat.IsDir())
 	}
 }
 
 func TestStatRoot(t *testing.T) {
+	integration(t)
 	filesystem, err := New(os.Getenv("YD"), nil)
 	if err != nil {
 		t.Error(err)
@@ -111,6 +114,7 @@ func TestStatRoot(t *testing.T) {
 }
 
 func TestReadDirFS(t *testing.T) {
+	integration(t)
 	filesystem, err := New(os.Getenv("YD"), nil)
 	if err != nil {
 		t.Error(err)
@@ -121,7 +125,7 @@ func TestReadDirFS(t *testing.T) {
 	}
 	found := false
 	for _, entry := range entries {
-		if entry.Name() == path.Join(testRootDirName, testFileName) && !entry.IsDir() {
+		if entry.Name() == testFileName && !entry.IsDir() {
 			found = true
 			break
 		}
@@ -133,6 +137,7 @@ func TestReadDirFS(t *testing.T) {
 }
 
 func TestOpenReturnsPathErr(t *testing.T) {
+	integration(t)
 	filesystem, err := New(os.Getenv("YD"), nil)
 	if err != nil {
 		t.Error(err)
@@ -145,6 +150,7 @@ func TestOpenReturnsPathErr(t *testing.T) {
 }
 
 func TestMkdir(t *testing.T) {
+	integration(t)
 	filesystem, err := New(os.Getenv("YD"), nil)
 	if err != nil {
 		t.Error(err)
@@ -158,6 +164,7 @@ func TestMkdir(t *testing.T) {
 }
 
 func TestReadOnADir(t *testing.T) {
+	integration(t)
 	filesystem, err := New(os.Getenv("YD"), nil)
 	if err != nil {
 		t.Error(err)
@@ -173,6 +180,7 @@ func TestReadOnADir(t *testing.T) {
 }
 
 func TestMkdirAll(t *testing.T) {
+	integration(t)
 	filesystem, err := New(os.Getenv("YD"), nil)
 	if err != nil {
 		t.Error(err)
@@ -183,6 +191,7 @@ func TestMkdirAll(t *testing.T) {
 }
 
 func TestRemoveFailsOnNonEmptyDir(t *testing.T) {
+	integration(t)
 	filesystem, err := New(os.Getenv("YD"), nil)
 	if err != nil {
 		t.Error(err)
@@ -194,6 +203,7 @@ func TestRemoveFailsOnNonEmptyDir(t *testing.T) {
 }
 
 func TestSubFS(t *testing.T) {
+	integration(t)
 	filesystem, err := New(os.Getenv("YD"), nil)
 	if err != nil {
 		t.Errorf("error creating filesystem: %v", err)
@@ -214,7 +224,7 @@ func TestSubFS(t *testing.T) {
 		t.Errorf("subfs can not stat existing file")
 		return
 	}
-	if s.Name() != path.Join(testRootDirName, testSubFSExistingDir) {
+	if s.Name() != testSubFSExistingDir {
 		t.Errorf("subfs returns incorrect fileinfo")
 	}
 	s, err = subfs.Stat("/")
@@ -228,6 +238,7 @@ func TestSubFS(t *testing.T) {
 }
 
 func TestRemoveAll(t *testing.T) {
+	integration(t)
 	filesystem, err := New(os.Getenv("YD"), nil)
 	if err != nil {
 		t.Error(err)
@@ -238,6 +249,7 @@ func TestRemoveAll(t *testing.T) {
 }
 
 func TestRemove(t *testing.T) {
+	integration(t)
 	filesystem, err := New(os.Getenv("YD"), nil)
 	if err != nil {
 		t.Error(err)
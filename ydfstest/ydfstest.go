@@ -0,0 +1,272 @@
+// Package ydfstest provides helpers for testing code built on ydfs.
+//
+// Transport injects failures into requests according to a scenario,
+// so that retry and resume logic can be tested deterministically:
+//
+//	tr := ydfstest.NewTransport(nil,
+//		ydfstest.Step{Match: ydfstest.MatchPath("/upload"), Fault: ydfstest.Status(http.StatusTooManyRequests, "1")},
+//		ydfstest.Step{Fault: ydfstest.Truncate(1024), Times: 2},
+//	)
+//	fsys, err := ydfs.New(token, &http.Client{Transport: tr})
+//
+// Transport can also be installed with ydfs.WithMiddleware(tr.Wrap).
+package ydfstest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault produces the outcome of a request instead of transport next,
+// which performs the request as is.
+type Fault func(r *http.Request, next http.RoundTripper) (*http.Response, error)
+
+// Step of scenario applies Fault to the next Times requests matched
+// by Match.
+type Step struct {
+	Match func(*http.Request) bool // nil matches any request
+	Fault Fault
+	Times int // number of requests to fail, zero means one
+}
+
+// Transport is http.RoundTripper injecting faults into requests
+// according to scenario. Steps are applied in order: each request is
+// checked against the current step only and passes as is unless the
+// step matches it. Transport is safe for concurrent use.
+type Transport struct {
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	steps    []Step
+	left     int // requests left to fail by steps[0]
+	requests int
+	injected int
+}
+
+// NewTransport returns Transport sending requests by next
+// (http.DefaultTransport if nil) and failing them according to steps.
+func NewTransport(next http.RoundTripper, steps ...Step) *Transport {
+	t := &Transport{next: next}
+	t.Add(steps...)
+	return t
+}
+
+// Add appends steps to the scenario.
+func (t *Transport) Add(steps ...Step) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.steps) == 0 && len(steps) > 0 {
+		t.left = max(steps[0].Times, 1)
+	}
+	t.steps = append(t.steps, steps...)
+}
+
+// Pending returns the number of steps not completed yet. Tests can
+// check that all faults of the scenario have been injected.
+func (t *Transport) Pending() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.steps)
+}
+
+// Requests returns the number of requests seen by Transport and the
+// number of them which were failed.
+func (t *Transport) Requests() (total, injected int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.requests, t.injected
+}
+
+// Wrap returns transport sending requests by next and sharing scenario
+// with t. It can be used as ydfs.Middleware.
+func (t *Transport) Wrap(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return t.roundTrip(r, next)
+	})
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return t.roundTrip(r, t.next)
+}
+
+func (t *Transport) roundTrip(r *http.Request, next http.RoundTripper) (*http.Response, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if fault := t.fault(r); fault != nil {
+		return fault(r, next)
+	}
+	return next.RoundTrip(r)
+}
+
+// fault returns fault to apply to r, if any, and advances scenario.
+func (t *Transport) fault(r *http.Request) Fault {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests++
+	if len(t.steps) == 0 {
+		return nil
+	}
+	step := t.steps[0]
+	if step.Match != nil && !step.Match(r) {
+		return nil
+	}
+	t.injected++
+	if t.left--; t.left == 0 {
+		t.steps = t.steps[1:]
+		if len(t.steps) > 0 {
+			t.left = max(t.steps[0].Times, 1)
+		}
+	}
+	return step.Fault
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// MatchMethod matches requests with the given HTTP method.
+func MatchMethod(method string) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		return r.Method == method
+	}
+}
+
+// MatchPath matches requests whose URL path contains substr, e.g.
+// "/resources/upload" or "/download".
+func MatchPath(substr string) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		return strings.Contains(r.URL.Path, substr)
+	}
+}
+
+// MatchRange matches requests of parts of files (with Range header).
+func MatchRange(r *http.Request) bool {
+	return r.Header.Get("Range") != ""
+}
+
+// Timeout fails requests with timeout error as if the server did not
+// respond in time. The request is not sent.
+func Timeout() Fault {
+	return func(r *http.Request, next http.RoundTripper) (*http.Response, error) {
+		return nil, fmt.Errorf("ydfstest: injected timeout: %w", os.ErrDeadlineExceeded)
+	}
+}
+
+// Status responds to requests with the given status code and error
+// body in the format of the API. RetryAfter is sent in Retry-After
+// header if not empty. The request is not sent.
+func Status(code int, retryAfter string) Fault {
+	return func(r *http.Request, next http.RoundTripper) (*http.Response, error) {
+		body := fmt.Sprintf(`{"message":"injected failure","description":"injected failure","error":%q}`, errorName(code))
+		resp := &http.Response{
+			Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+			StatusCode:    code,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/json"}},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       r,
+		}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp, nil
+	}
+}
+
+// errorName returns name of API error reported with the given status.
+func errorName(code int) string {
+	switch code {
+	case http.StatusTooManyRequests:
+		return "TooManyRequestsError"
+	case http.StatusServiceUnavailable:
+		return "ServiceUnavailableError"
+	case http.StatusInsufficientStorage:
+		return "InsufficientStorageError"
+	}
+	return strings.ReplaceAll(http.StatusText(code), " ", "") + "Error"
+}
+
+// Truncate sends requests and cuts bodies of their responses after n
+// bytes with io.ErrUnexpectedEOF, as if the connection broke.
+func Truncate(n int64) Fault {
+	return func(r *http.Request, next http.RoundTripper) (*http.Response, error) {
+		resp, err := next.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = &truncatedBody{r: io.LimitReader(resp.Body, n), c: resp.Body}
+		return resp, nil
+	}
+}
+
+type truncatedBody struct {
+	r io.Reader
+	c io.Closer
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (b *truncatedBody) Close() error {
+	return b.c.Close()
+}
+
+// Slow delays requests by d before sending them. Requests whose
+// contexts are done meanwhile fail with the context error.
+func Slow(d time.Duration) Fault {
+	return func(r *http.Request, next http.RoundTripper) (*http.Response, error) {
+		if err := sleep(r.Context(), d); err != nil {
+			return nil, err
+		}
+		return next.RoundTrip(r)
+	}
+}
+
+// Hang blocks requests until their contexts are done, as a server
+// which never responds, and fails them with the context error.
+func Hang() Fault {
+	return func(r *http.Request, next http.RoundTripper) (*http.Response, error) {
+		<-r.Context().Done()
+		return nil, r.Context().Err()
+	}
+}
+
+// Error fails requests with err without sending them.
+func Error(err error) Fault {
+	if err == nil {
+		err = errors.New("ydfstest: injected failure")
+	}
+	return func(r *http.Request, next http.RoundTripper) (*http.Response, error) {
+		return nil, err
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
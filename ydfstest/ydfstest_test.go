@@ -0,0 +1,66 @@
+package ydfstest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "0123456789")
+	}))
+	defer srv.Close()
+	tr := NewTransport(nil,
+		Step{Match: MatchMethod(http.MethodPut), Fault: Timeout()},
+		Step{Fault: Status(http.StatusServiceUnavailable, "5"), Times: 2},
+		Step{Fault: Truncate(4)},
+		Step{Fault: Slow(time.Hour)},
+	)
+	c := &http.Client{Transport: tr}
+
+	// requests not matched by the current step pass as is
+	if resp, err := c.Get(srv.URL); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("unmatched request failed: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodPut, srv.URL, nil)
+	if _, err := c.Do(req); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("want timeout, have %v", err)
+	}
+	for range 2 {
+		resp, err := c.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != "5" {
+			t.Errorf("unexpected response: %d %v", resp.StatusCode, resp.Header)
+		}
+	}
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(data) != "0123" || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("body is not truncated: %q, %v", data, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := c.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("slow request is not cancelled: %v", err)
+	}
+	if tr.Pending() != 0 {
+		t.Errorf("%d steps pending", tr.Pending())
+	}
+	if total, injected := tr.Requests(); total != 6 || injected != 5 {
+		t.Errorf("unexpected counts: %d total, %d injected", total, injected)
+	}
+}